@@ -11,9 +11,14 @@ import (
 
 	"whatsapp-api/internal/delivery/http"
 	"whatsapp-api/internal/delivery/http/handler"
-	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/delivery/http/middleware"
 	"whatsapp-api/internal/infrastructure/database"
+	"whatsapp-api/internal/infrastructure/eventbus"
 	"whatsapp-api/internal/infrastructure/langchain"
+	"whatsapp-api/internal/infrastructure/ratelimit"
+	"whatsapp-api/internal/infrastructure/secret"
+	"whatsapp-api/internal/infrastructure/storage"
+	"whatsapp-api/internal/infrastructure/webhook"
 	"whatsapp-api/internal/infrastructure/whatsapp"
 	"whatsapp-api/internal/usecase"
 	"whatsapp-api/pkg/config"
@@ -54,14 +59,27 @@ func main() {
 	defer db.Close()
 
 	// 3. Initialize Repositories
+	secrets, err := secret.NewEnvelopeFromConfig(cfg.Security)
+	if err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+	}
+	if !secrets.Enabled() {
+		log.Printf("security.encryption_key is not set; Langchain API keys and webhook secrets are stored in plaintext")
+	}
 	userRepo := database.NewUserRepository(db)
-	sessionRepo := database.NewSessionRepository(db)
+	sessionRepo := database.NewSessionRepository(db, secrets)
 	messageRepo := database.NewMessageRepository(db)
 	langchainRepo := database.NewLangchainRepository(db)
-
-	// Seed default user if not exists
-	defaultUserID := "admin"
-	seedDefaultUser(userRepo)
+	webhookRepo := database.NewWebhookRepository(db, secrets)
+	apiKeyRepo := database.NewAPIKeyRepository(db)
+	outboundRepo := database.NewOutboundRepository(db)
+	campaignRepo := database.NewCampaignRepository(db)
+	scheduleRepo := database.NewScheduleRepository(db)
+	chatRepo := database.NewChatRepository(db)
+	searchRepo := database.NewSearchRepository(db)
+	retentionRepo := database.NewRetentionRepository(db)
+	analyticsRepo := database.NewAnalyticsRepository(db)
+	healthRepo := database.NewHealthRepository(db)
 
 	// 4. Initialize Infrastructure
 	waManager, err := whatsapp.NewClientManager(db)
@@ -73,37 +91,178 @@ func main() {
 		lcTimeout = 30 * time.Second
 	}
 	langchainClient := langchain.NewClient(lcTimeout)
+	mediaStore, err := storage.NewBlobStore(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize media storage: %v", err)
+	}
+	whTimeout, _ := time.ParseDuration(cfg.Webhook.Timeout)
+	if whTimeout == 0 {
+		whTimeout = 10 * time.Second
+	}
+	webhookClient := webhook.NewClient(whTimeout)
+	eventBus := eventbus.New()
+	rateLimitWindow, _ := time.ParseDuration(cfg.Security.RateLimitWindow)
+	if rateLimitWindow <= 0 {
+		rateLimitWindow = time.Minute
+	}
+	rateLimited := cfg.Security.RateLimitRequests > 0 || cfg.Security.RateLimitIPRequests > 0
+	rateLimitRoutes := make([]middleware.RateLimitRoute, 0, len(cfg.Security.RateLimitRoutes))
+	for _, route := range cfg.Security.RateLimitRoutes {
+		window, _ := time.ParseDuration(route.Window)
+		if window <= 0 {
+			window = rateLimitWindow
+		}
+		rateLimitRoutes = append(rateLimitRoutes, middleware.RateLimitRoute{
+			Method:     route.Method,
+			PathPrefix: route.Path,
+			Rule:       ratelimit.Rule{Requests: route.Requests, Window: window},
+		})
+		rateLimited = rateLimited || route.Requests > 0
+	}
+	var limiter *ratelimit.Limiter
+	if rateLimited {
+		rateLimitStore, err := ratelimit.NewStore(cfg.Security.RateLimitStore, db)
+		if err != nil {
+			log.Fatalf("Failed to initialize rate limit store: %v", err)
+		}
+		limiter = ratelimit.New(rateLimitStore)
+	}
 
 	// 5. Initialize UseCases
 	defaultParams := map[string]interface{}{
 		"max_steps": 5,
 	}
-	langchainUC := usecase.NewLangchainUseCase(sessionRepo, langchainRepo, langchainClient, cfg.Langchain.BaseURL, defaultParams)
-	sessionUC := usecase.NewSessionUseCase(sessionRepo, messageRepo, waManager, defaultUserID, cfg.Langchain.BaseURL, langchainUC)
+	userUC := usecase.NewUserUseCase(userRepo, apiKeyRepo)
+	if err := userUC.Bootstrap(context.Background(), "admin"); err != nil {
+		log.Fatalf("Failed to bootstrap admin user: %v", err)
+	}
+	langchainUC := usecase.NewLangchainUseCase(sessionRepo, langchainRepo, messageRepo, langchainClient, cfg.Langchain.BaseURL, defaultParams)
+	whMaxAttempts := cfg.Webhook.MaxAttempts
+	if whMaxAttempts <= 0 {
+		whMaxAttempts = 6
+	}
+	whRetryDelay, _ := time.ParseDuration(cfg.Webhook.RetryBaseDelay)
+	if whRetryDelay == 0 {
+		whRetryDelay = 30 * time.Second
+	}
+	webhookUC := usecase.NewWebhookUseCase(sessionRepo, webhookRepo, webhookClient, whMaxAttempts, whRetryDelay)
+	outboundCfg := usecase.OutboundConfig{
+		MessagesPerMinute: cfg.Outbound.MessagesPerMinute,
+		MaxAttempts:       cfg.Outbound.MaxAttempts,
+	}
+	if outboundCfg.MessagesPerMinute <= 0 {
+		outboundCfg.MessagesPerMinute = 20
+	}
+	if outboundCfg.MaxAttempts <= 0 {
+		outboundCfg.MaxAttempts = 3
+	}
+	outboundCfg.Jitter = durationOr(cfg.Outbound.Jitter, 3*time.Second)
+	outboundCfg.TypingPerChar = durationOr(cfg.Outbound.TypingPerChar, 40*time.Millisecond)
+	outboundCfg.MaxTyping = durationOr(cfg.Outbound.MaxTyping, 6*time.Second)
+	sessionUC := usecase.NewSessionUseCase(sessionRepo, messageRepo, waManager, cfg.Langchain.BaseURL, langchainUC, mediaStore, webhookUC, eventBus, outboundRepo, outboundCfg, chatRepo)
+	messageUC := usecase.NewMessageUseCase(sessionRepo, messageRepo, sessionUC, mediaStore)
+	campaignCfg := usecase.CampaignConfig{
+		MinDelay:               durationOr(cfg.Campaign.MinDelay, 5*time.Second),
+		MaxDelay:               durationOr(cfg.Campaign.MaxDelay, 15*time.Second),
+		MaxConsecutiveFailures: cfg.Campaign.MaxConsecutiveFailures,
+	}
+	if campaignCfg.MaxDelay < campaignCfg.MinDelay {
+		campaignCfg.MaxDelay = campaignCfg.MinDelay
+	}
+	campaignUC := usecase.NewCampaignUseCase(sessionRepo, campaignRepo, sessionUC, campaignCfg)
+	sessionUC.AddOutboundListener(campaignUC)
+	scheduleUC := usecase.NewScheduleUseCase(sessionRepo, scheduleRepo, sessionUC)
+	sessionUC.AddOutboundListener(scheduleUC)
+	chatUC := usecase.NewChatUseCase(sessionRepo, chatRepo)
+	searchUC := usecase.NewSearchUseCase(sessionRepo, searchRepo, cfg.Search.Language)
+	exportUC := usecase.NewExportUseCase(sessionRepo, messageRepo)
+	analyticsUC := usecase.NewAnalyticsUseCase(sessionRepo, analyticsRepo)
+	healthUC := usecase.NewHealthUseCase(healthRepo, sessionRepo, waManager, sessionUC)
+	retentionCfg := usecase.RetentionConfig{
+		Interval:  durationOr(cfg.Retention.Interval, time.Hour),
+		BatchSize: cfg.Retention.BatchSize,
+	}
+	if retentionCfg.Interval <= 0 {
+		retentionCfg.Interval = time.Hour
+	}
+	if retentionCfg.BatchSize <= 0 {
+		retentionCfg.BatchSize = 1000
+	}
+	retentionUC := usecase.NewRetentionUseCase(sessionRepo, retentionRepo, mediaStore, sessionUC, retentionCfg)
 
 	// Initialize existing sessions
 	if err := sessionUC.InitializeSessions(context.Background()); err != nil {
 		log.Printf("Failed to initialize sessions: %v", err)
 	}
+	go webhookUC.StartRetryWorker(context.Background())
+	go sessionUC.StartOutboundWorker(context.Background())
+	go campaignUC.StartWorker(context.Background())
+	go scheduleUC.StartScheduler(context.Background())
+	go searchUC.ApplyLanguage(context.Background())
+	go retentionUC.StartPurgeWorker(context.Background())
+	if limiter != nil {
+		go limiter.StartCleanup(context.Background())
+	}
 
 	// 6. Initialize Handlers
 	sessionHandler := handler.NewSessionHandler(sessionUC)
+	messageHandler := handler.NewMessageHandler(messageUC)
 	langchainHandler := handler.NewLangchainHandler(langchainUC)
+	webhookHandler := handler.NewWebhookHandler(webhookUC)
+	realtimeHandler := handler.NewRealtimeHandler(sessionUC, messageUC)
+	adminHandler := handler.NewAdminHandler(userUC)
+	campaignHandler := handler.NewCampaignHandler(campaignUC)
+	scheduleHandler := handler.NewScheduleHandler(scheduleUC)
+	chatHandler := handler.NewChatHandler(chatUC)
+	searchHandler := handler.NewSearchHandler(searchUC)
+	retentionHandler := handler.NewRetentionHandler(retentionUC)
+	exportHandler := handler.NewExportHandler(exportUC)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsUC)
+	healthHandler := handler.NewHealthHandler(healthUC)
 
 	// 7. Initialize Fiber App
+	bodyLimitMB := cfg.Server.BodyLimitMB
+	if bodyLimitMB <= 0 {
+		bodyLimitMB = 64
+	}
+	// Behind nginx every request comes from loopback; the client IP, which
+	// rate limits are keyed by, is only taken from the proxy header when a
+	// trusted proxy sent it.
+	proxyHeader := cfg.Security.ProxyHeader
+	if proxyHeader == "" {
+		proxyHeader = "X-Real-IP"
+	}
+	trustedProxies := cfg.Security.TrustedProxies
+	if len(trustedProxies) == 0 {
+		trustedProxies = []string{"127.0.0.1", "::1"}
+	}
 	app := fiber.New(fiber.Config{
-		AppName: cfg.Server.Name,
+		AppName:                 cfg.Server.Name,
+		BodyLimit:               bodyLimitMB * 1024 * 1024,
+		ProxyHeader:             proxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	app.Use(logger.New())
 	app.Use(recover.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", // Adjust this for production security
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowOrigins:  "*", // Adjust this for production security
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization",
+		ExposeHeaders: "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After",
 	}))
 
 	// 8. Setup Router
-	http.NewRouter(app, sessionHandler, langchainHandler)
+	ipRateLimit := middleware.IPRateLimit(limiter, ratelimit.Rule{
+		Requests: cfg.Security.RateLimitIPRequests,
+		Window:   rateLimitWindow,
+	})
+	rateLimit := middleware.RateLimit(limiter, ratelimit.Rule{
+		Requests: cfg.Security.RateLimitRequests,
+		Window:   rateLimitWindow,
+	}, rateLimitRoutes)
+	http.NewRouter(app, sessionHandler, messageHandler, langchainHandler, webhookHandler, realtimeHandler, adminHandler, campaignHandler, scheduleHandler, chatHandler, searchHandler, retentionHandler, exportHandler, analyticsHandler, healthHandler, userUC, ipRateLimit, rateLimit)
 
 	// 9. Start Server
 	if err := startServerWithFallback(app, cfg.Server.Port, 10); err != nil {
@@ -111,30 +270,14 @@ func main() {
 	}
 }
 
-func seedDefaultUser(userRepo interface{}) {
-	// Simple seed for testing
-	repo := userRepo.(interface {
-		GetByAPIKey(ctx context.Context, apiKey string) (*entity.User, error)
-		Create(ctx context.Context, user *entity.User) error
-	})
-
-	ctx := context.Background()
-	apiKey := "secret"
-	user, _ := repo.GetByAPIKey(ctx, apiKey)
-	if user == nil {
-		log.Println("Seeding default user...")
-		newUser := &entity.User{
-			UserID:    "admin",
-			APIKey:    apiKey,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
-		if err := repo.Create(ctx, newUser); err != nil {
-			log.Printf("Failed to seed user: %v", err)
-		} else {
-			log.Printf("Default user created. API Key: %s", apiKey)
-		}
+// durationOr parses a config duration, falling back to def when it is unset
+// or invalid.
+func durationOr(raw string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return def
 	}
+	return d
 }
 
 func startServerWithFallback(app *fiber.App, startPort int, attempts int) error {
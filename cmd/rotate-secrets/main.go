@@ -0,0 +1,47 @@
+// Command rotate-secrets re-encrypts secrets at rest with the active master
+// key. Run it after changing security.encryption_key (listing the previous
+// key in security.encryption_retired_keys) or after enabling encryption, then
+// drop the retired key from the config.
+package main
+
+import (
+	"context"
+	"log"
+
+	"whatsapp-api/internal/infrastructure/database"
+	"whatsapp-api/internal/infrastructure/secret"
+	"whatsapp-api/pkg/config"
+)
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	secrets, err := secret.NewEnvelopeFromConfig(cfg.Security)
+	if err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+	}
+	if !secrets.Enabled() {
+		log.Fatalf("security.encryption_key is not set; nothing to rotate to")
+	}
+
+	db, err := database.NewPostgresConnection(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	rotated, err := database.NewSessionRepository(db, secrets).RotateSecrets(ctx)
+	if err != nil {
+		log.Fatalf("Rotation stopped after %d sessions: %v", rotated, err)
+	}
+	log.Printf("Re-encrypted secrets of %d sessions", rotated)
+
+	rotated, err = database.NewWebhookRepository(db, secrets).RotateSecrets(ctx)
+	if err != nil {
+		log.Fatalf("Rotation stopped after %d webhooks: %v", rotated, err)
+	}
+	log.Printf("Re-encrypted secrets of %d webhooks", rotated)
+}
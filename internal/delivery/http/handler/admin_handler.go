@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminHandler struct {
+	userUC *usecase.UserUseCase
+}
+
+func NewAdminHandler(userUC *usecase.UserUseCase) *AdminHandler {
+	return &AdminHandler{userUC: userUC}
+}
+
+type CreateUserRequest struct {
+	UserID  string `json:"userId"`
+	IsAdmin bool   `json:"isAdmin"`
+	// KeyName names the first API key issued with the user; empty skips it.
+	KeyName string `json:"keyName,omitempty"`
+	// KeyScopes and KeyAgentIDs restrict that first key; see IssueAPIKeyRequest.
+	KeyScopes   []string `json:"keyScopes,omitempty"`
+	KeyAgentIDs []string `json:"keyAgentIds,omitempty"`
+}
+
+type IssueAPIKeyRequest struct {
+	Name string `json:"name"`
+	// Scopes the key is granted, e.g. sessions:read or messages:send.
+	// Empty grants all scopes.
+	Scopes []string `json:"scopes,omitempty"`
+	// AgentIDs limits the key to these sessions. Empty allows all of the
+	// user's sessions.
+	AgentIDs []string `json:"agentIds,omitempty"`
+}
+
+// CreateUser godoc
+// @Summary Create a user
+// @Description Create an API user, optionally issuing its first API key. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body CreateUserRequest true "Create User Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /admin/users [post]
+func (h *AdminHandler) CreateUser(c *fiber.Ctx) error {
+	var req CreateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	user, err := h.userUC.CreateUser(c.Context(), req.UserID, req.IsAdmin)
+	if err != nil {
+		return c.Status(adminErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := fiber.Map{"user": user}
+	if req.KeyName != "" {
+		key, raw, err := h.userUC.IssueKey(c.Context(), usecase.IssueKeyInput{
+			UserID:   user.UserID,
+			Name:     req.KeyName,
+			Scopes:   req.KeyScopes,
+			AgentIDs: req.KeyAgentIDs,
+		})
+		if err != nil {
+			return c.Status(adminErrorStatus(err)).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		data["apiKey"] = apiKeyView(key, raw)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User created successfully",
+		"data":    data,
+	})
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description List API users. Admin only.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /admin/users [get]
+func (h *AdminHandler) ListUsers(c *fiber.Ctx) error {
+	users, err := h.userUC.ListUsers(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if users == nil {
+		users = []*entity.User{}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    users,
+	})
+}
+
+// IssueAPIKey godoc
+// @Summary Issue an API key
+// @Description Issue a new named API key for a user, optionally limited to some scopes and agents. The key is only shown in this response. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param request body IssueAPIKeyRequest true "Issue API Key Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /admin/users/{userId}/keys [post]
+func (h *AdminHandler) IssueAPIKey(c *fiber.Ctx) error {
+	var req IssueAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	key, raw, err := h.userUC.IssueKey(c.Context(), usecase.IssueKeyInput{
+		UserID:   c.Params("userId"),
+		Name:     req.Name,
+		Scopes:   req.Scopes,
+		AgentIDs: req.AgentIDs,
+	})
+	if err != nil {
+		return c.Status(adminErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "API key issued; store it now, it will not be shown again",
+		"data":    apiKeyView(key, raw),
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List a user's API keys by name and prefix. Admin only.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /admin/users/{userId}/keys [get]
+func (h *AdminHandler) ListAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.userUC.ListKeys(c.Context(), c.Params("userId"))
+	if err != nil {
+		return c.Status(adminErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(keys))
+	for _, key := range keys {
+		data = append(data, apiKeyView(key, ""))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @Description Issue a replacement with the same name, scopes and agents and revoke the old key. Admin only.
+// @Tags admin
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /admin/keys/{id}/rotate [post]
+func (h *AdminHandler) RotateAPIKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid key id",
+		})
+	}
+
+	key, raw, err := h.userUC.RotateKey(c.Context(), id)
+	if err != nil {
+		return c.Status(adminErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "API key rotated; store it now, it will not be shown again",
+		"data":    apiKeyView(key, raw),
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key immediately. Admin only.
+// @Tags admin
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /admin/keys/{id} [delete]
+func (h *AdminHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid key id",
+		})
+	}
+
+	if err := h.userUC.RevokeKey(c.Context(), id); err != nil {
+		return c.Status(adminErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "API key revoked",
+	})
+}
+
+// apiKeyView presents a key without its hash; raw is the plaintext key and
+// is only set right after issuing.
+func apiKeyView(key *entity.APIKey, raw string) fiber.Map {
+	view := fiber.Map{
+		"id":        key.ID,
+		"userId":    key.UserID,
+		"name":      key.Name,
+		"prefix":    key.Prefix,
+		"scopes":    json.RawMessage(key.Scopes),
+		"agentIds":  json.RawMessage(key.AgentIDs),
+		"createdAt": key.CreatedAt,
+		"revoked":   key.RevokedAt.Valid,
+	}
+	if raw != "" {
+		view["key"] = raw
+	}
+	if key.LastUsedAt.Valid {
+		view["lastUsedAt"] = key.LastUsedAt.Time
+	}
+	if key.RevokedAt.Valid {
+		view["revokedAt"] = key.RevokedAt.Time
+	}
+	return view
+}
+
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound), errors.Is(err, usecase.ErrAPIKeyNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrUserExists):
+		return fiber.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidUser), errors.Is(err, usecase.ErrInvalidScope):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AnalyticsHandler struct {
+	analyticsUC *usecase.AnalyticsUseCase
+}
+
+func NewAnalyticsHandler(analyticsUC *usecase.AnalyticsUseCase) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsUC: analyticsUC}
+}
+
+// GetAnalytics godoc
+// @Summary Session analytics
+// @Description Message volume per hour, day, week or month, unique contacts, group vs direct split, bot response rate and Langchain success rate with p50/p95/p99 execution time. Defaults to the last 30 days by day.
+// @Tags sessions
+// @Produce json
+// @Param agentId path string true "Agent ID"
+// @Param bucket query string false "hour, day (default), week or month"
+// @Param since query string false "RFC 3339 time, inclusive (default 30 days before until)"
+// @Param until query string false "RFC 3339 time, exclusive (default now)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /sessions/{agentId}/analytics [get]
+func (h *AnalyticsHandler) GetAnalytics(c *fiber.Ctx) error {
+	since, err := timeQuery(c, "since")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	until, err := timeQuery(c, "until")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	analytics, err := h.analyticsUC.Get(c.Context(), usecase.AnalyticsInput{
+		Caller:  currentCaller(c),
+		AgentID: c.Params("agentId"),
+		Bucket:  c.Query("bucket"),
+		Since:   since,
+		Until:   until,
+	})
+	if err != nil {
+		return c.Status(analyticsErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	totals := analytics.Totals
+	langchain := analytics.Langchain
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"since":   analytics.Since,
+			"until":   analytics.Until,
+			"bucket":  analytics.Bucket,
+			"buckets": analytics.Buckets,
+			"totals": fiber.Map{
+				"incoming":       totals.Incoming,
+				"outgoing":       totals.Outgoing,
+				"uniqueContacts": totals.UniqueContacts,
+				"groupMessages":  totals.GroupMessages,
+				"directMessages": totals.DirectMessages,
+				"answered":       totals.Answered,
+				"responseRate":   analytics.ResponseRate,
+			},
+			"langchain": fiber.Map{
+				"executions":  langchain.Executions,
+				"succeeded":   langchain.Succeeded,
+				"failed":      langchain.Failed,
+				"successRate": analytics.SuccessRate,
+				"failureRate": analytics.FailureRate,
+				"executionTimeMs": fiber.Map{
+					"p50": nullFloat(langchain.P50Ms),
+					"p95": nullFloat(langchain.P95Ms),
+					"p99": nullFloat(langchain.P99Ms),
+				},
+			},
+		},
+	})
+}
+
+// nullFloat renders NULL as JSON null.
+func nullFloat(f sql.NullFloat64) interface{} {
+	if !f.Valid {
+		return nil
+	}
+	return f.Float64
+}
+
+func analyticsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidAnalytics):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
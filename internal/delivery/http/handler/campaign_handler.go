@@ -0,0 +1,388 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CampaignHandler struct {
+	campaignUC *usecase.CampaignUseCase
+}
+
+func NewCampaignHandler(campaignUC *usecase.CampaignUseCase) *CampaignHandler {
+	return &CampaignHandler{campaignUC: campaignUC}
+}
+
+type CreateCampaignRequest struct {
+	AgentID  string `json:"agentId" form:"agentId"`
+	Name     string `json:"name" form:"name"`
+	Template string `json:"template" form:"template"`
+	// Recipients is used for JSON requests; multipart requests upload a CSV
+	// file instead.
+	Recipients []usecase.CampaignRecipientInput `json:"recipients,omitempty" form:"-"`
+	// Start starts sending right after creation.
+	Start bool `json:"start,omitempty" form:"start"`
+}
+
+// CreateCampaign godoc
+// @Summary Create a campaign
+// @Description Create a broadcast campaign from a message template with {{variable}} placeholders. Recipients come as JSON or as a multipart CSV upload (field "file") with a phone column, an optional name column and one column per variable.
+// @Tags campaigns
+// @Accept json,mpfd
+// @Produce json
+// @Param request body CreateCampaignRequest true "Create Campaign Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /campaigns [post]
+func (h *CampaignHandler) CreateCampaign(c *fiber.Ctx) error {
+	var req CreateCampaignRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+	if req.AgentID == "" || req.Name == "" || req.Template == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId, name and template are required",
+		})
+	}
+
+	recipients := req.Recipients
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to read uploaded file",
+			})
+		}
+		recipients, err = usecase.ParseRecipientsCSV(f)
+		f.Close()
+		if err != nil {
+			return c.Status(campaignErrorStatus(err)).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	caller := currentCaller(c)
+	campaign, err := h.campaignUC.Create(c.Context(), usecase.CreateCampaignInput{
+		Caller:     caller,
+		AgentID:    req.AgentID,
+		Name:       req.Name,
+		Template:   req.Template,
+		Recipients: recipients,
+	})
+	if err == nil && req.Start {
+		campaign, err = h.campaignUC.Start(c.Context(), caller, campaign.ID)
+	}
+	if err != nil {
+		return c.Status(campaignErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return h.campaignResponse(c, campaign.ID, "Campaign created successfully")
+}
+
+// ListCampaigns godoc
+// @Summary List campaigns
+// @Description List a session's campaigns, newest first
+// @Tags campaigns
+// @Produce json
+// @Param agentId query string true "Agent ID"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /campaigns [get]
+func (h *CampaignHandler) ListCampaigns(c *fiber.Ctx) error {
+	agentID := c.Query("agentId")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId is required",
+		})
+	}
+	limit, offset := pageParams(c)
+
+	campaigns, err := h.campaignUC.List(c.Context(), currentCaller(c), agentID, limit, offset)
+	if err != nil {
+		return c.Status(campaignErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		data = append(data, campaignView(campaign, nil))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetCampaign godoc
+// @Summary Get a campaign
+// @Description Get a campaign with its progress by recipient status
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "Campaign ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /campaigns/{id} [get]
+func (h *CampaignHandler) GetCampaign(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid campaign id",
+		})
+	}
+	return h.campaignResponse(c, id, "")
+}
+
+// ListCampaignRecipients godoc
+// @Summary List campaign recipients
+// @Description List a campaign's recipients with their send, delivery and read status
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "Campaign ID"
+// @Param status query string false "pending, queued, sent, delivered, read or failed"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /campaigns/{id}/recipients [get]
+func (h *CampaignHandler) ListCampaignRecipients(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid campaign id",
+		})
+	}
+	limit, offset := pageParams(c)
+
+	recipients, err := h.campaignUC.ListRecipients(c.Context(), currentCaller(c), id, c.Query("status"), limit, offset)
+	if err != nil {
+		return c.Status(campaignErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(recipients))
+	for _, r := range recipients {
+		data = append(data, recipientView(r))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// StartCampaign godoc
+// @Summary Start a campaign
+// @Description Start sending a draft campaign
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "Campaign ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /campaigns/{id}/start [post]
+func (h *CampaignHandler) StartCampaign(c *fiber.Ctx) error {
+	return h.changeState(c, h.campaignUC.Start, "Campaign started")
+}
+
+// PauseCampaign godoc
+// @Summary Pause a campaign
+// @Description Stop handing recipients to the queue until the campaign is resumed
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "Campaign ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /campaigns/{id}/pause [post]
+func (h *CampaignHandler) PauseCampaign(c *fiber.Ctx) error {
+	return h.changeState(c, h.campaignUC.Pause, "Campaign paused")
+}
+
+// ResumeCampaign godoc
+// @Summary Resume a campaign
+// @Description Resume a paused campaign, or one stopped after repeated failures
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "Campaign ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /campaigns/{id}/resume [post]
+func (h *CampaignHandler) ResumeCampaign(c *fiber.Ctx) error {
+	return h.changeState(c, h.campaignUC.Resume, "Campaign resumed")
+}
+
+// CancelCampaign godoc
+// @Summary Cancel a campaign
+// @Description Cancel a campaign for good; messages already queued are still sent
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "Campaign ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /campaigns/{id}/cancel [post]
+func (h *CampaignHandler) CancelCampaign(c *fiber.Ctx) error {
+	return h.changeState(c, h.campaignUC.Cancel, "Campaign cancelled")
+}
+
+type campaignAction func(ctx context.Context, caller usecase.Caller, id int) (*entity.Campaign, error)
+
+func (h *CampaignHandler) changeState(c *fiber.Ctx, action campaignAction, message string) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid campaign id",
+		})
+	}
+
+	if _, err := action(c.Context(), currentCaller(c), id); err != nil {
+		return c.Status(campaignErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return h.campaignResponse(c, id, message)
+}
+
+// campaignResponse answers with the campaign and its current progress.
+func (h *CampaignHandler) campaignResponse(c *fiber.Ctx, id int, message string) error {
+	campaign, progress, err := h.campaignUC.Get(c.Context(), currentCaller(c), id)
+	if err != nil {
+		return c.Status(campaignErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	resp := fiber.Map{
+		"success": true,
+		"data":    campaignView(campaign, progress),
+	}
+	if message != "" {
+		resp["message"] = message
+	}
+	return c.JSON(resp)
+}
+
+// pageParams reads limit (default 50, max 200) and offset.
+func pageParams(c *fiber.Ctx) (int, int) {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func campaignView(campaign *entity.Campaign, progress *usecase.CampaignProgress) fiber.Map {
+	view := fiber.Map{
+		"id":                  campaign.ID,
+		"agentId":             campaign.AgentID,
+		"name":                campaign.Name,
+		"template":            campaign.Template,
+		"status":              campaign.Status,
+		"consecutiveFailures": campaign.ConsecutiveFailures,
+		"createdAt":           campaign.CreatedAt,
+		"updatedAt":           campaign.UpdatedAt,
+	}
+	if progress != nil {
+		view["progress"] = progress
+	}
+	if campaign.StopReason.Valid {
+		view["stopReason"] = campaign.StopReason.String
+	}
+	if campaign.StartedAt.Valid {
+		view["startedAt"] = campaign.StartedAt.Time
+	}
+	if campaign.FinishedAt.Valid {
+		view["finishedAt"] = campaign.FinishedAt.Time
+	}
+	return view
+}
+
+func recipientView(r *entity.CampaignRecipient) fiber.Map {
+	view := fiber.Map{
+		"id":        r.ID,
+		"phone":     r.Phone,
+		"variables": json.RawMessage(r.Variables),
+		"status":    r.Status,
+		"updatedAt": r.UpdatedAt,
+	}
+	if r.Name.Valid {
+		view["name"] = r.Name.String
+	}
+	if r.MessageID.Valid {
+		view["messageId"] = r.MessageID.String
+	}
+	if r.ErrorMessage.Valid {
+		view["error"] = r.ErrorMessage.String
+	}
+	if r.SentAt.Valid {
+		view["sentAt"] = r.SentAt.Time
+	}
+	if r.DeliveredAt.Valid {
+		view["deliveredAt"] = r.DeliveredAt.Time
+	}
+	if r.ReadAt.Valid {
+		view["readAt"] = r.ReadAt.Time
+	}
+	return view
+}
+
+func campaignErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound), errors.Is(err, usecase.ErrCampaignNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidCampaign):
+		return fiber.StatusBadRequest
+	case errors.Is(err, usecase.ErrCampaignState):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
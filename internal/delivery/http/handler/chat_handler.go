@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ChatHandler struct {
+	chatUC *usecase.ChatUseCase
+}
+
+func NewChatHandler(chatUC *usecase.ChatUseCase) *ChatHandler {
+	return &ChatHandler{chatUC: chatUC}
+}
+
+type UpdateChatRequest struct {
+	Archived *bool `json:"archived,omitempty"`
+	Muted    *bool `json:"muted,omitempty"`
+	// Read resets the unread count when true.
+	Read bool `json:"read,omitempty"`
+}
+
+// ListChats godoc
+// @Summary List chats
+// @Description List a session's direct and group chats, most recently active first, with the last message and unread count
+// @Tags chats
+// @Produce json
+// @Param agentId path string true "Agent ID"
+// @Param type query string false "direct or group"
+// @Param archived query bool false "Only archived (true) or unarchived (false) chats"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /sessions/{agentId}/chats [get]
+func (h *ChatHandler) ListChats(c *fiber.Ctx) error {
+	chatType := c.Query("type")
+	if chatType != "" && chatType != "direct" && chatType != "group" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "type must be direct or group",
+		})
+	}
+	var archived *bool
+	if raw := c.Query("archived"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "archived must be true or false",
+			})
+		}
+		archived = &v
+	}
+	limit, offset := pageParams(c)
+
+	chats, err := h.chatUC.List(c.Context(), usecase.ListChatsInput{
+		Caller:   currentCaller(c),
+		AgentID:  c.Params("agentId"),
+		ChatType: chatType,
+		Archived: archived,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(chats))
+	for _, chat := range chats {
+		data = append(data, chatView(chat))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetChat godoc
+// @Summary Get a chat
+// @Description Get one chat. Its messages are listed by GET /sessions/{agentId}/messages?chat={chatJid}.
+// @Tags chats
+// @Produce json
+// @Param id path int true "Chat ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /chats/{id} [get]
+func (h *ChatHandler) GetChat(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid chat id",
+		})
+	}
+
+	chat, err := h.chatUC.Get(c.Context(), currentCaller(c), id)
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    chatView(chat),
+	})
+}
+
+// UpdateChat godoc
+// @Summary Update a chat
+// @Description Archive or unarchive, mute or unmute a chat, or mark it read. Only the fields sent are changed.
+// @Tags chats
+// @Accept json
+// @Produce json
+// @Param id path int true "Chat ID"
+// @Param request body UpdateChatRequest true "Update Chat Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /chats/{id} [patch]
+func (h *ChatHandler) UpdateChat(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid chat id",
+		})
+	}
+	var req UpdateChatRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	chat, err := h.chatUC.Update(c.Context(), usecase.UpdateChatInput{
+		Caller:   currentCaller(c),
+		ID:       id,
+		Archived: req.Archived,
+		Muted:    req.Muted,
+		MarkRead: req.Read,
+	})
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Chat updated successfully",
+		"data":    chatView(chat),
+	})
+}
+
+func chatView(chat *entity.Chat) fiber.Map {
+	view := fiber.Map{
+		"id":          chat.ID,
+		"agentId":     chat.AgentID,
+		"chatJid":     chat.ChatJID,
+		"type":        chat.ChatType,
+		"displayName": chat.DisplayName.String,
+		"unreadCount": chat.UnreadCount,
+		"archived":    chat.Archived,
+		"muted":       chat.Muted,
+		"createdAt":   chat.CreatedAt,
+		"updatedAt":   chat.UpdatedAt,
+	}
+	if chat.LastMessageAt.Valid {
+		last := fiber.Map{
+			"text":      chat.LastMessageText.String,
+			"type":      chat.LastMessageType.String,
+			"direction": chat.LastMessageDirection.String,
+			"timestamp": chat.LastMessageAt.Time,
+		}
+		if chat.LastMessageID.Valid {
+			last["id"] = chat.LastMessageID.Int64
+		}
+		view["lastMessage"] = last
+	}
+	return view
+}
+
+func chatErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound), errors.Is(err, usecase.ErrChatNotFound):
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
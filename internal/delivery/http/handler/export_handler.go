@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ExportHandler struct {
+	exportUC *usecase.ExportUseCase
+}
+
+func NewExportHandler(exportUC *usecase.ExportUseCase) *ExportHandler {
+	return &ExportHandler{exportUC: exportUC}
+}
+
+// ExportMessages godoc
+// @Summary Export a conversation transcript
+// @Description Stream a session's messages oldest first as JSON Lines, CSV or WhatsApp "Export chat" text. Bot replies carry their Langchain execution (id, status, duration, error). Narrow by chat JID and a since/until range.
+// @Tags messages
+// @Produce application/x-ndjson,text/csv,text/plain
+// @Param agentId path string true "Agent ID"
+// @Param format query string false "jsonl (default), csv or txt"
+// @Param chat query string false "Chat JID"
+// @Param since query string false "RFC 3339 time, inclusive"
+// @Param until query string false "RFC 3339 time, exclusive"
+// @Param timezone query string false "IANA timezone for timestamps, e.g. Asia/Jakarta"
+// @Success 200 {string} string "transcript"
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /sessions/{agentId}/export [get]
+func (h *ExportHandler) ExportMessages(c *fiber.Ctx) error {
+	since, err := timeQuery(c, "since")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	until, err := timeQuery(c, "until")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	export, err := h.exportUC.Prepare(c.Context(), usecase.ExportInput{
+		Caller:   currentCaller(c),
+		AgentID:  c.Params("agentId"),
+		Format:   c.Query("format"),
+		Chat:     c.Query("chat"),
+		Since:    since,
+		Until:    until,
+		Timezone: c.Query("timezone"),
+	})
+	if err != nil {
+		return c.Status(exportErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, export.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", export.FileName))
+	c.Set("X-Accel-Buffering", "no")
+
+	// The request context is recycled once the handler returns, so the
+	// stream runs on its own.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := export.WriteTo(context.Background(), w); err != nil {
+			log.Printf("message export stopped: %v", err)
+		}
+	})
+	return nil
+}
+
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidExport):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// readinessTimeout bounds the dependency checks of /readyz so a hung
+// database fails the probe instead of stalling it.
+const readinessTimeout = 3 * time.Second
+
+type HealthHandler struct {
+	healthUC *usecase.HealthUseCase
+}
+
+func NewHealthHandler(healthUC *usecase.HealthUseCase) *HealthHandler {
+	return &HealthHandler{healthUC: healthUC}
+}
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Reports that the process is up. Served at /healthz, outside /api/v1 and without an API key.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /healthz [get]
+func (h *HealthHandler) Healthz(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":    "ok",
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Checks Postgres, the whatsmeow device store and that a table, column or index each migration creates exists, listing the migrations that look unapplied. Served at /readyz, outside /api/v1 and without an API key.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+
+	checks, ready := h.healthUC.Ready(ctx)
+	components := fiber.Map{}
+	for _, check := range checks {
+		if check.Error == "" {
+			components[check.Name] = "ok"
+		} else {
+			components[check.Name] = check.Error
+		}
+	}
+
+	status, code := "ready", fiber.StatusOK
+	if !ready {
+		status, code = "not_ready", fiber.StatusServiceUnavailable
+	}
+	return c.Status(code).JSON(fiber.Map{
+		"status":     status,
+		"timestamp":  time.Now().UTC(),
+		"components": components,
+	})
+}
+
+// SessionsHealth godoc
+// @Summary Session client health
+// @Description For each of your sessions (all of them for admins), whether its in-memory WhatsApp client is loaded, connected and logged in, next to the status stored in the database. consistent is false when the two disagree.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /health/sessions [get]
+func (h *HealthHandler) SessionsHealth(c *fiber.Ctx) error {
+	sessions, err := h.healthUC.Sessions(c.Context(), currentCaller(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(sessions))
+	inconsistent := 0
+	for _, s := range sessions {
+		if !s.Consistent {
+			inconsistent++
+		}
+		data = append(data, fiber.Map{
+			"agentId":      s.AgentID,
+			"dbStatus":     s.DBStatus,
+			"clientLoaded": s.Client.Loaded,
+			"connected":    s.Client.Connected,
+			"loggedIn":     s.Client.LoggedIn,
+			"consistent":   s.Consistent,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"inconsistent": inconsistent,
+		"data":         data,
+	})
+}
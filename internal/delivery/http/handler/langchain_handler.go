@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 
 	"whatsapp-api/internal/domain/entity"
 	"whatsapp-api/internal/usecase"
@@ -33,6 +34,7 @@ type ExecuteLangchainRequest struct {
 // @Param request body ExecuteLangchainRequest true "Execution request"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /langchain/execute [post]
 func (h *LangchainHandler) Execute(c *fiber.Ctx) error {
@@ -50,7 +52,165 @@ func (h *LangchainHandler) Execute(c *fiber.Ctx) error {
 		})
 	}
 
-	exec, err := h.uc.Execute(c.Context(), req.AgentID, req.Message, req.Sender, req.Params)
+	exec, err := h.uc.ExecuteForUser(c.Context(), currentCaller(c), req.AgentID, req.Message, req.Sender, req.Params)
+	if errors.Is(err, usecase.ErrSessionNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Session not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"data":    h.presentExecution(exec),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    h.presentExecution(exec),
+	})
+}
+
+// ListExecutions godoc
+// @Summary List Langchain executions
+// @Description List a session's Langchain executions, newest first
+// @Tags langchain
+// @Produce json
+// @Param agentId query string true "Agent ID"
+// @Param status query string false "success or failed"
+// @Param sender query string false "Sender the message came from"
+// @Param since query string false "Start time (RFC 3339, inclusive)"
+// @Param until query string false "End time (RFC 3339, exclusive)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /langchain/executions [get]
+func (h *LangchainHandler) ListExecutions(c *fiber.Ctx) error {
+	agentID := c.Query("agentId")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId is required",
+		})
+	}
+	since, err := timeQuery(c, "since")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	until, err := timeQuery(c, "until")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	limit, offset := pageParams(c)
+
+	executions, err := h.uc.ListExecutions(c.Context(), usecase.ListExecutionsInput{
+		Caller:  currentCaller(c),
+		AgentID: agentID,
+		Status:  c.Query("status"),
+		Sender:  c.Query("sender"),
+		Since:   since,
+		Until:   until,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		return c.Status(executionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(executions))
+	for _, exec := range executions {
+		data = append(data, h.presentExecution(exec))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetExecution godoc
+// @Summary Get a Langchain execution
+// @Description Get an execution with the incoming message that triggered it and the replies sent from its response
+// @Tags langchain
+// @Produce json
+// @Param id path int true "Execution ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /langchain/executions/{id} [get]
+func (h *LangchainHandler) GetExecution(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid execution id",
+		})
+	}
+
+	detail, err := h.uc.GetExecution(c.Context(), currentCaller(c), id)
+	if err != nil {
+		return c.Status(executionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := h.presentExecution(detail.Execution)
+	if detail.Incoming != nil {
+		data["incomingMessage"] = messageView(detail.Incoming)
+	}
+	replies := make([]fiber.Map, 0, len(detail.Replies))
+	for _, msg := range detail.Replies {
+		replies = append(replies, messageView(msg))
+	}
+	data["replies"] = replies
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// ReplayExecution godoc
+// @Summary Replay a Langchain execution
+// @Description Re-run an execution's user message against the session's current Langchain config and store the result as a new execution. Nothing is sent on WhatsApp.
+// @Tags langchain
+// @Produce json
+// @Param id path int true "Execution ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /langchain/executions/{id}/replay [post]
+func (h *LangchainHandler) ReplayExecution(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid execution id",
+		})
+	}
+
+	exec, err := h.uc.ReplayExecution(c.Context(), currentCaller(c), id)
+	if exec == nil && err != nil {
+		return c.Status(executionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -65,6 +225,17 @@ func (h *LangchainHandler) Execute(c *fiber.Ctx) error {
 	})
 }
 
+func executionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound), errors.Is(err, usecase.ErrExecutionNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrExecutionNotReplayable):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
 func (h *LangchainHandler) presentExecution(exec *entity.LangchainExecution) fiber.Map {
 	if exec == nil {
 		return nil
@@ -73,16 +244,24 @@ func (h *LangchainHandler) presentExecution(exec *entity.LangchainExecution) fib
 	if len(exec.LangchainResponse) > 0 {
 		_ = json.Unmarshal(exec.LangchainResponse, &parsed)
 	}
-	return fiber.Map{
+	view := fiber.Map{
 		"id":                exec.ID,
 		"agentId":           exec.AgentID,
 		"sessionId":         exec.SessionID,
 		"status":            exec.Status.String,
 		"error":             exec.ErrorMessage.String,
 		"userMessage":       exec.UserMessage.String,
+		"sender":            exec.Sender.String,
 		"langchainResponse": parsed,
 		"rawResponse":       string(exec.LangchainResponse),
 		"executionTimeMs":   exec.ExecutionTimeMs.Int64,
 		"createdAt":         exec.CreatedAt,
 	}
+	if exec.MessageID.Valid {
+		view["messageRowId"] = exec.MessageID.Int64
+	}
+	if exec.ReplayOf.Valid {
+		view["replayOf"] = exec.ReplayOf.Int64
+	}
+	return view
 }
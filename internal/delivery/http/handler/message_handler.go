@@ -0,0 +1,539 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MessageHandler struct {
+	messageUC *usecase.MessageUseCase
+}
+
+func NewMessageHandler(messageUC *usecase.MessageUseCase) *MessageHandler {
+	return &MessageHandler{messageUC: messageUC}
+}
+
+type SendMessageRequest struct {
+	AgentID         string `json:"agentId"`
+	To              string `json:"to"`
+	Message         string `json:"message"`
+	Type            string `json:"type,omitempty"`
+	QuotedMessageID string `json:"quotedMessageId,omitempty"`
+	// Bulk queues the message behind regular sends.
+	Bulk bool `json:"bulk,omitempty"`
+}
+
+// SendMessage godoc
+// @Summary Send a WhatsApp message
+// @Description Queue a text message from a connected session to a phone number or group JID. The session's outbound queue sends it at a throttled pace; message.sent or message.failed events report the outcome.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param request body SendMessageRequest true "Send Message Request"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /messages/send [post]
+func (h *MessageHandler) SendMessage(c *fiber.Ctx) error {
+	var req SendMessageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+	if req.AgentID == "" || req.To == "" || req.Message == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId, to and message are required",
+		})
+	}
+	if req.Type != "" && req.Type != "text" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Unsupported message type",
+		})
+	}
+
+	msg, err := h.messageUC.SendText(c.Context(), usecase.SendTextInput{
+		Caller:          currentCaller(c),
+		AgentID:         req.AgentID,
+		To:              req.To,
+		Text:            req.Message,
+		QuotedMessageID: req.QuotedMessageID,
+		Bulk:            req.Bulk,
+	})
+	if err != nil {
+		return c.Status(sendErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "Message queued",
+		"data":    outboundView(msg),
+	})
+}
+
+type SendMediaRequest struct {
+	AgentID         string `json:"agentId" form:"agentId"`
+	To              string `json:"to" form:"to"`
+	Type            string `json:"type" form:"type"`
+	Caption         string `json:"caption,omitempty" form:"caption"`
+	FileName        string `json:"fileName,omitempty" form:"fileName"`
+	MimeType        string `json:"mimetype,omitempty" form:"mimetype"`
+	URL             string `json:"url,omitempty" form:"url"`
+	Base64          string `json:"base64,omitempty" form:"base64"`
+	QuotedMessageID string `json:"quotedMessageId,omitempty" form:"quotedMessageId"`
+	VoiceNote       bool   `json:"voiceNote,omitempty" form:"voiceNote"`
+	Bulk            bool   `json:"bulk,omitempty" form:"bulk"`
+}
+
+// SendMedia godoc
+// @Summary Send a media message
+// @Description Upload and queue an image, document, audio, video or sticker. The file can be a multipart upload (field "file"), a URL, or base64 content.
+// @Tags messages
+// @Accept json,mpfd
+// @Produce json
+// @Param request body SendMediaRequest true "Send Media Request"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /messages/send-media [post]
+func (h *MessageHandler) SendMedia(c *fiber.Ctx) error {
+	var req SendMediaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+	if req.AgentID == "" || req.To == "" || req.Type == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId, to and type are required",
+		})
+	}
+
+	in := usecase.SendMediaInput{
+		Caller:          currentCaller(c),
+		AgentID:         req.AgentID,
+		To:              req.To,
+		Type:            req.Type,
+		URL:             req.URL,
+		MimeType:        req.MimeType,
+		FileName:        req.FileName,
+		Caption:         req.Caption,
+		QuotedMessageID: req.QuotedMessageID,
+		VoiceNote:       req.VoiceNote,
+		Bulk:            req.Bulk,
+	}
+
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to read uploaded file",
+			})
+		}
+		in.Data, err = io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to read uploaded file",
+			})
+		}
+		if in.FileName == "" {
+			in.FileName = fh.Filename
+		}
+		if in.MimeType == "" {
+			in.MimeType = fh.Header.Get("Content-Type")
+		}
+	} else if req.Base64 != "" {
+		data, mimetype, err := decodeBase64Media(req.Base64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid base64 content",
+			})
+		}
+		in.Data = data
+		if in.MimeType == "" {
+			in.MimeType = mimetype
+		}
+	} else if req.URL == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "One of file, url or base64 is required",
+		})
+	}
+
+	msg, err := h.messageUC.SendMedia(c.Context(), in)
+	if err != nil {
+		return c.Status(sendErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "Message queued",
+		"data":    outboundView(msg),
+	})
+}
+
+// ListQueue godoc
+// @Summary List queued messages
+// @Description List a session's outbound queue, newest first, optionally filtered by status (queued, sent, failed). Sent items carry the message's delivery state (messageStatus, deliveredAt, readAt, playedAt).
+// @Tags messages
+// @Produce json
+// @Param agentId query string true "Agent ID"
+// @Param status query string false "Status filter"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /messages/queue [get]
+func (h *MessageHandler) ListQueue(c *fiber.Ctx) error {
+	agentID := c.Query("agentId")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId is required",
+		})
+	}
+	status := c.Query("status")
+	if status != "" && status != "queued" && status != "sent" && status != "failed" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "status must be queued, sent or failed",
+		})
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	items, err := h.messageUC.ListQueue(c.Context(), currentCaller(c), agentID, status, limit, offset)
+	if err != nil {
+		return c.Status(sendErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(items))
+	for _, item := range items {
+		data = append(data, outboundView(item))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// ListMessages godoc
+// @Summary List message history
+// @Description Page through a session's stored messages, newest first. Pass nextCursor from the previous page as cursor to continue.
+// @Tags messages
+// @Produce json
+// @Param agentId path string true "Agent ID"
+// @Param direction query string false "incoming or outgoing"
+// @Param type query string false "Message type, e.g. text or image"
+// @Param contact query string false "Phone number or JID on either side"
+// @Param chat query string false "Chat JID, e.g. a group"
+// @Param status query string false "received, sent, delivered, read or played"
+// @Param since query string false "Start time (RFC 3339, inclusive)"
+// @Param until query string false "End time (RFC 3339, exclusive)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /sessions/{agentId}/messages [get]
+func (h *MessageHandler) ListMessages(c *fiber.Ctx) error {
+	direction := c.Query("direction")
+	if direction != "" && direction != "incoming" && direction != "outgoing" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "direction must be incoming or outgoing",
+		})
+	}
+	since, err := timeQuery(c, "since")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	until, err := timeQuery(c, "until")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	messages, next, err := h.messageUC.ListMessages(c.Context(), usecase.ListMessagesInput{
+		Caller:    currentCaller(c),
+		AgentID:   c.Params("agentId"),
+		Direction: direction,
+		Type:      c.Query("type"),
+		Contact:   c.Query("contact"),
+		Chat:      c.Query("chat"),
+		Status:    c.Query("status"),
+		Since:     since,
+		Until:     until,
+		Cursor:    c.Query("cursor"),
+		Limit:     limit,
+	})
+	if err != nil {
+		return c.Status(historyErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(messages))
+	for _, msg := range messages {
+		data = append(data, messageView(msg))
+	}
+	resp := fiber.Map{
+		"success": true,
+		"data":    data,
+	}
+	if next != "" {
+		resp["nextCursor"] = next
+	}
+	return c.JSON(resp)
+}
+
+// GetMessage godoc
+// @Summary Get a message
+// @Description Get one stored message with its delivery state, reply link and Langchain execution
+// @Tags messages
+// @Produce json
+// @Param id path int true "Message row ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /messages/{id} [get]
+func (h *MessageHandler) GetMessage(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid message ID",
+		})
+	}
+
+	msg, err := h.messageUC.GetMessage(c.Context(), currentCaller(c), id)
+	if err != nil {
+		return c.Status(historyErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    messageView(msg),
+	})
+}
+
+// DownloadMedia godoc
+// @Summary Download message media
+// @Description Stream the stored attachment of an incoming media message
+// @Tags messages
+// @Produce octet-stream
+// @Param id path int true "Message row ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /messages/{id}/media [get]
+func (h *MessageHandler) DownloadMedia(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid message ID",
+		})
+	}
+
+	media, err := h.messageUC.OpenMedia(c.Context(), currentCaller(c), id)
+	if err != nil {
+		if errors.Is(err, usecase.ErrMediaNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Media not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if media.MimeType != "" {
+		c.Set(fiber.HeaderContentType, media.MimeType)
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", media.FileName))
+	// Fiber closes the stream once the response has been written.
+	return c.SendStream(media.Body)
+}
+
+// timeQuery reads an optional RFC 3339 query parameter; absent means zero.
+func timeQuery(c *fiber.Ctx, name string) (time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
+
+func messageView(msg *entity.Message) fiber.Map {
+	view := fiber.Map{
+		"id":        msg.ID,
+		"agentId":   msg.AgentID,
+		"messageId": msg.MessageID.String,
+		"from":      msg.FromNumber.String,
+		"to":        msg.ToNumber.String,
+		"text":      msg.MessageText.String,
+		"type":      msg.MessageType.String,
+		"direction": msg.Direction.String,
+		"status":    msg.Status.String,
+		"timestamp": msg.CreatedAt,
+	}
+	if len(msg.Metadata) > 0 {
+		view["metadata"] = json.RawMessage(msg.Metadata)
+	}
+	if msg.ReplyToID.Valid {
+		view["replyToId"] = msg.ReplyToID.Int64
+	}
+	if msg.LangchainExecutionID.Valid {
+		view["langchainExecutionId"] = msg.LangchainExecutionID.Int64
+	}
+	if msg.DeliveredAt.Valid {
+		view["deliveredAt"] = msg.DeliveredAt.Time
+	}
+	if msg.ReadAt.Valid {
+		view["readAt"] = msg.ReadAt.Time
+	}
+	if msg.PlayedAt.Valid {
+		view["playedAt"] = msg.PlayedAt.Time
+	}
+	return view
+}
+
+func outboundView(item *entity.OutboundMessage) fiber.Map {
+	view := fiber.Map{
+		"queueId":   item.ID,
+		"agentId":   item.AgentID,
+		"to":        item.Recipient,
+		"type":      item.MessageType,
+		"priority":  item.Priority,
+		"status":    item.Status,
+		"attempts":  item.Attempts,
+		"timestamp": item.CreatedAt,
+	}
+	if item.ErrorMessage.Valid {
+		view["error"] = item.ErrorMessage.String
+	}
+	if item.MessageRowID.Valid {
+		view["id"] = item.MessageRowID.Int64
+	}
+	if item.SentAt.Valid {
+		view["sentAt"] = item.SentAt.Time
+	}
+	if item.MessageStatus.Valid {
+		view["messageStatus"] = item.MessageStatus.String
+	}
+	if item.DeliveredAt.Valid {
+		view["deliveredAt"] = item.DeliveredAt.Time
+	}
+	if item.ReadAt.Valid {
+		view["readAt"] = item.ReadAt.Time
+	}
+	if item.PlayedAt.Valid {
+		view["playedAt"] = item.PlayedAt.Time
+	}
+	return view
+}
+
+// decodeBase64Media accepts raw base64 or a data URL and returns the decoded
+// bytes plus the mimetype declared in the data URL, if any.
+func decodeBase64Media(raw string) ([]byte, string, error) {
+	mimetype := ""
+	if strings.HasPrefix(raw, "data:") {
+		header, payload, ok := strings.Cut(raw, ",")
+		if !ok {
+			return nil, "", errors.New("malformed data URL")
+		}
+		mimetype = strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+		raw = payload
+	}
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, mimetype, nil
+}
+
+func historyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound), errors.Is(err, usecase.ErrMessageNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidFilter):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func sendErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrSessionNotConnected):
+		return fiber.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidRecipient), errors.Is(err, usecase.ErrInvalidMedia):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"whatsapp-api/internal/delivery/http/middleware"
+	"whatsapp-api/internal/infrastructure/eventbus"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	wsPingInterval = 30 * time.Second
+	wsReadTimeout  = 2 * wsPingInterval
+	wsWriteTimeout = 10 * time.Second
+)
+
+type RealtimeHandler struct {
+	sessionUC *usecase.SessionUseCase
+	messageUC *usecase.MessageUseCase
+}
+
+func NewRealtimeHandler(sessionUC *usecase.SessionUseCase, messageUC *usecase.MessageUseCase) *RealtimeHandler {
+	return &RealtimeHandler{sessionUC: sessionUC, messageUC: messageUC}
+}
+
+// RealtimeRequest is a frame sent by the client. ID is echoed back in the
+// matching ack or error so clients can correlate replies.
+type RealtimeRequest struct {
+	Type            string   `json:"type"`
+	ID              string   `json:"id,omitempty"`
+	AgentIDs        []string `json:"agentIds,omitempty"`
+	AgentID         string   `json:"agentId,omitempty"`
+	To              string   `json:"to,omitempty"`
+	Message         string   `json:"message,omitempty"`
+	QuotedMessageID string   `json:"quotedMessageId,omitempty"`
+	Typing          bool     `json:"typing,omitempty"`
+}
+
+type realtimeEvent struct {
+	Type string `json:"type"`
+	eventbus.Event
+}
+
+type realtimeReply struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id,omitempty"`
+	Success bool        `json:"success"`
+	Error   string      `json:"error,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Upgrade rejects plain HTTP requests to the WebSocket endpoint.
+func (h *RealtimeHandler) Upgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+			"success": false,
+			"error":   "WebSocket upgrade required",
+		})
+	}
+	return c.Next()
+}
+
+// Serve godoc
+// @Summary Realtime WebSocket
+// @Description Bidirectional socket for session events and messaging. Authenticate with the Authorization header or the apiKey query parameter. Requires sessions:read; message, receipt and presence events are only sent to keys with messages:read, and send and typing frames need messages:send. Client frames: subscribe, unsubscribe, send, typing, ping.
+// @Tags realtime
+// @Param apiKey query string false "API key when the Authorization header cannot be set"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 401 {object} map[string]interface{}
+// @Failure 426 {object} map[string]interface{}
+// @Router /ws [get]
+func (h *RealtimeHandler) Serve(conn *websocket.Conn) {
+	caller, _ := conn.Locals(middleware.CallerLocalsKey).(*usecase.Caller)
+	if caller == nil {
+		conn.Close()
+		return
+	}
+
+	sub := h.sessionUC.SubscribeEvents()
+	defer h.sessionUC.UnsubscribeEvents(sub)
+
+	var writeMu sync.Mutex
+	write := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(v)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case evt, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if !caller.HasScope(usecase.EventScope(evt.Type)) {
+					continue
+				}
+				if err := write(realtimeEvent{Type: "event", Event: evt}); err != nil {
+					conn.Close()
+					return
+				}
+			case <-ticker.C:
+				writeMu.Lock()
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+				writeMu.Unlock()
+				if err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	})
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("realtime: read error: %v", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+
+		var req RealtimeRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			write(realtimeReply{Type: "error", Error: "Invalid frame"})
+			continue
+		}
+		if err := write(h.handleFrame(*caller, sub, req)); err != nil {
+			return
+		}
+	}
+}
+
+func (h *RealtimeHandler) handleFrame(caller usecase.Caller, sub *eventbus.Subscription, req RealtimeRequest) realtimeReply {
+	ctx := context.Background()
+	fail := func(msg string) realtimeReply {
+		return realtimeReply{Type: "error", ID: req.ID, Error: msg}
+	}
+	ack := func(data interface{}) realtimeReply {
+		return realtimeReply{Type: "ack", ID: req.ID, Success: true, Data: data}
+	}
+
+	switch req.Type {
+	case "ping":
+		return realtimeReply{Type: "pong", ID: req.ID, Success: true}
+
+	case "subscribe":
+		if len(req.AgentIDs) == 0 {
+			return fail("agentIds is required")
+		}
+		// Reply with each session's current status so clients start in sync.
+		statuses := make(map[string]string, len(req.AgentIDs))
+		for _, agentID := range req.AgentIDs {
+			session, err := h.sessionUC.GetSession(ctx, caller, agentID)
+			if err != nil || session == nil {
+				return fail("Session not found: " + agentID)
+			}
+			statuses[agentID] = session.Status
+		}
+		sub.Add(req.AgentIDs...)
+		return ack(fiber.Map{"agentIds": sub.AgentIDs(), "status": statuses})
+
+	case "unsubscribe":
+		sub.Remove(req.AgentIDs...)
+		return ack(fiber.Map{"agentIds": sub.AgentIDs()})
+
+	case "send":
+		if !caller.HasScope(usecase.ScopeMessagesSend) {
+			return fail("API key lacks the " + usecase.ScopeMessagesSend + " scope")
+		}
+		if req.AgentID == "" || req.To == "" || req.Message == "" {
+			return fail("agentId, to and message are required")
+		}
+		msg, err := h.messageUC.SendText(ctx, usecase.SendTextInput{
+			Caller:          caller,
+			AgentID:         req.AgentID,
+			To:              req.To,
+			Text:            req.Message,
+			QuotedMessageID: req.QuotedMessageID,
+		})
+		if err != nil {
+			return fail(err.Error())
+		}
+		return ack(outboundView(msg))
+
+	case "typing":
+		if !caller.HasScope(usecase.ScopeMessagesSend) {
+			return fail("API key lacks the " + usecase.ScopeMessagesSend + " scope")
+		}
+		if req.AgentID == "" || req.To == "" {
+			return fail("agentId and to are required")
+		}
+		if err := h.messageUC.SendTyping(ctx, caller, req.AgentID, req.To, req.Typing); err != nil {
+			return fail(err.Error())
+		}
+		return ack(nil)
+
+	default:
+		return fail("Unknown frame type")
+	}
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"errors"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RetentionHandler struct {
+	retentionUC *usecase.RetentionUseCase
+}
+
+func NewRetentionHandler(retentionUC *usecase.RetentionUseCase) *RetentionHandler {
+	return &RetentionHandler{retentionUC: retentionUC}
+}
+
+type SetRetentionRequest struct {
+	// AgentID sets a session override; empty sets your default.
+	AgentID string `json:"agentId,omitempty"`
+	// Days to keep each kind of data. 0 keeps it forever; omitted inherits
+	// the default (or keeps forever, for the default itself).
+	MessageDays   *int `json:"messageDays,omitempty"`
+	ExecutionDays *int `json:"executionDays,omitempty"`
+	MediaDays     *int `json:"mediaDays,omitempty"`
+}
+
+type EraseContactRequest struct {
+	Phone string `json:"phone"`
+	// AgentID limits the erasure to one session; empty erases from all of
+	// yours.
+	AgentID string `json:"agentId,omitempty"`
+}
+
+// ListRetention godoc
+// @Summary List retention policies
+// @Description List your default retention policy and per-session overrides
+// @Tags retention
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /retention [get]
+func (h *RetentionHandler) ListRetention(c *fiber.Ctx) error {
+	policies, err := h.retentionUC.List(c.Context(), currentCaller(c))
+	if err != nil {
+		return c.Status(retentionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(policies))
+	for _, policy := range policies {
+		data = append(data, retentionPolicyView(policy))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// SetRetention godoc
+// @Summary Set a retention policy
+// @Description Set how many days messages, Langchain executions and downloaded media are kept, as your default or for one session. The purge worker deletes older data.
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param request body SetRetentionRequest true "Set Retention Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /retention [put]
+func (h *RetentionHandler) SetRetention(c *fiber.Ctx) error {
+	var req SetRetentionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	policy, err := h.retentionUC.Set(c.Context(), usecase.SetRetentionInput{
+		Caller:        currentCaller(c),
+		AgentID:       req.AgentID,
+		MessageDays:   req.MessageDays,
+		ExecutionDays: req.ExecutionDays,
+		MediaDays:     req.MediaDays,
+	})
+	if err != nil {
+		return c.Status(retentionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Retention policy saved successfully",
+		"data":    retentionPolicyView(policy),
+	})
+}
+
+// DeleteRetention godoc
+// @Summary Delete a retention policy
+// @Description Delete your default retention policy, or a session's override when agentId is given
+// @Tags retention
+// @Produce json
+// @Param agentId query string false "Agent ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /retention [delete]
+func (h *RetentionHandler) DeleteRetention(c *fiber.Ctx) error {
+	if err := h.retentionUC.Delete(c.Context(), currentCaller(c), c.Query("agentId")); err != nil {
+		return c.Status(retentionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Retention policy deleted successfully",
+	})
+}
+
+// PreviewRetention godoc
+// @Summary Preview a purge
+// @Description Dry run: count what the purge worker would delete right now from each of your sessions, without deleting anything
+// @Tags retention
+// @Produce json
+// @Param agentId query string false "Only this session"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /retention/preview [get]
+func (h *RetentionHandler) PreviewRetention(c *fiber.Ctx) error {
+	previews, err := h.retentionUC.Preview(c.Context(), currentCaller(c), c.Query("agentId"))
+	if err != nil {
+		return c.Status(retentionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(previews))
+	for _, p := range previews {
+		cutoffs := fiber.Map{}
+		if !p.Cutoffs.Messages.IsZero() {
+			cutoffs["messages"] = p.Cutoffs.Messages
+		}
+		if !p.Cutoffs.Executions.IsZero() {
+			cutoffs["executions"] = p.Cutoffs.Executions
+		}
+		if !p.Cutoffs.Media.IsZero() {
+			cutoffs["media"] = p.Cutoffs.Media
+		}
+		data = append(data, fiber.Map{
+			"agentId":       p.Retention.AgentID,
+			"messageDays":   p.Retention.MessageDays,
+			"executionDays": p.Retention.ExecutionDays,
+			"mediaDays":     p.Retention.MediaDays,
+			"deleteBefore":  cutoffs,
+			"wouldDelete":   p.Counts,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// EraseContact godoc
+// @Summary Erase a contact
+// @Description Permanently delete everything stored about one phone number: messages and media, Langchain executions, chats, campaign recipients, scheduled and queued messages, and webhook deliveries. Safe to repeat.
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param request body EraseContactRequest true "Erase Contact Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /contacts/erase [post]
+func (h *RetentionHandler) EraseContact(c *fiber.Ctx) error {
+	var req EraseContactRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+	if req.Phone == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "phone is required",
+		})
+	}
+
+	result, err := h.retentionUC.EraseContact(c.Context(), usecase.EraseContactInput{
+		Caller:  currentCaller(c),
+		Phone:   req.Phone,
+		AgentID: req.AgentID,
+	})
+	if err != nil {
+		resp := fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		}
+		if result != nil {
+			resp["data"] = result
+		}
+		return c.Status(retentionErrorStatus(err)).JSON(resp)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Contact erased successfully",
+		"data":    result,
+	})
+}
+
+func retentionPolicyView(policy *entity.RetentionPolicy) fiber.Map {
+	view := fiber.Map{
+		"id":        policy.ID,
+		"createdAt": policy.CreatedAt,
+		"updatedAt": policy.UpdatedAt,
+	}
+	if policy.AgentID.Valid {
+		view["agentId"] = policy.AgentID.String
+	}
+	if policy.MessageDays.Valid {
+		view["messageDays"] = policy.MessageDays.Int64
+	}
+	if policy.ExecutionDays.Valid {
+		view["executionDays"] = policy.ExecutionDays.Int64
+	}
+	if policy.MediaDays.Valid {
+		view["mediaDays"] = policy.MediaDays.Int64
+	}
+	return view
+}
+
+func retentionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound), errors.Is(err, usecase.ErrRetentionNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidRetention), errors.Is(err, usecase.ErrInvalidRecipient):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
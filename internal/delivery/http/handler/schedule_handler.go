@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ScheduleHandler struct {
+	scheduleUC *usecase.ScheduleUseCase
+}
+
+func NewScheduleHandler(scheduleUC *usecase.ScheduleUseCase) *ScheduleHandler {
+	return &ScheduleHandler{scheduleUC: scheduleUC}
+}
+
+type CreateScheduleRequest struct {
+	AgentID string `json:"agentId"`
+	To      string `json:"to"`
+	Message string `json:"message"`
+	// SendAt schedules a one-off send (RFC 3339).
+	SendAt *time.Time `json:"sendAt,omitempty"`
+	// Cron schedules a recurring send, e.g. "0 9 * * mon-fri".
+	Cron     string `json:"cron,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+type UpdateScheduleRequest struct {
+	To       *string    `json:"to,omitempty"`
+	Message  *string    `json:"message,omitempty"`
+	SendAt   *time.Time `json:"sendAt,omitempty"`
+	Cron     *string    `json:"cron,omitempty"`
+	Timezone *string    `json:"timezone,omitempty"`
+	Paused   *bool      `json:"paused,omitempty"`
+}
+
+// CreateSchedule godoc
+// @Summary Schedule a message
+// @Description Schedule a text for a future time (sendAt) or on a recurring cron expression evaluated in timezone. Due messages go through the session's outbound queue.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param request body CreateScheduleRequest true "Create Schedule Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /schedules [post]
+func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
+	var req CreateScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+	if req.AgentID == "" || req.To == "" || req.Message == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId, to and message are required",
+		})
+	}
+
+	schedule, err := h.scheduleUC.Create(c.Context(), usecase.CreateScheduleInput{
+		Caller:   currentCaller(c),
+		AgentID:  req.AgentID,
+		To:       req.To,
+		Text:     req.Message,
+		SendAt:   req.SendAt,
+		Cron:     req.Cron,
+		Timezone: req.Timezone,
+	})
+	if err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Message scheduled successfully",
+		"data":    scheduleView(schedule),
+	})
+}
+
+// ListSchedules godoc
+// @Summary List scheduled messages
+// @Description List a session's scheduled messages, newest first
+// @Tags schedules
+// @Produce json
+// @Param agentId query string true "Agent ID"
+// @Param status query string false "active, paused or completed"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /schedules [get]
+func (h *ScheduleHandler) ListSchedules(c *fiber.Ctx) error {
+	agentID := c.Query("agentId")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId is required",
+		})
+	}
+	limit, offset := pageParams(c)
+
+	schedules, err := h.scheduleUC.List(c.Context(), currentCaller(c), agentID, c.Query("status"), limit, offset)
+	if err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(schedules))
+	for _, schedule := range schedules {
+		data = append(data, scheduleView(schedule))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetSchedule godoc
+// @Summary Get a scheduled message
+// @Tags schedules
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /schedules/{id} [get]
+func (h *ScheduleHandler) GetSchedule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid schedule id",
+		})
+	}
+
+	schedule, err := h.scheduleUC.Get(c.Context(), currentCaller(c), id)
+	if err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    scheduleView(schedule),
+	})
+}
+
+// UpdateSchedule godoc
+// @Summary Update a scheduled message
+// @Description Change the recipient, text or timing of a schedule, or pause and resume it. Only the fields sent are changed.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body UpdateScheduleRequest true "Update Schedule Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /schedules/{id} [patch]
+func (h *ScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid schedule id",
+		})
+	}
+	var req UpdateScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	schedule, err := h.scheduleUC.Update(c.Context(), usecase.UpdateScheduleInput{
+		Caller:   currentCaller(c),
+		ID:       id,
+		To:       req.To,
+		Text:     req.Message,
+		SendAt:   req.SendAt,
+		Cron:     req.Cron,
+		Timezone: req.Timezone,
+		Paused:   req.Paused,
+	})
+	if err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Scheduled message updated successfully",
+		"data":    scheduleView(schedule),
+	})
+}
+
+// DeleteSchedule godoc
+// @Summary Delete a scheduled message
+// @Description Delete a schedule and its run history. Messages already queued are still sent.
+// @Tags schedules
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /schedules/{id} [delete]
+func (h *ScheduleHandler) DeleteSchedule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid schedule id",
+		})
+	}
+
+	if err := h.scheduleUC.Delete(c.Context(), currentCaller(c), id); err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Scheduled message deleted successfully",
+	})
+}
+
+// ListScheduleRuns godoc
+// @Summary List schedule runs
+// @Description List the times a schedule fired, newest first, each with its queue item and sent message
+// @Tags schedules
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /schedules/{id}/runs [get]
+func (h *ScheduleHandler) ListScheduleRuns(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid schedule id",
+		})
+	}
+	limit, offset := pageParams(c)
+
+	runs, err := h.scheduleUC.ListRuns(c.Context(), currentCaller(c), id, limit, offset)
+	if err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(runs))
+	for _, run := range runs {
+		data = append(data, scheduleRunView(run))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func scheduleView(s *entity.ScheduledMessage) fiber.Map {
+	view := fiber.Map{
+		"id":        s.ID,
+		"agentId":   s.AgentID,
+		"to":        s.Recipient,
+		"message":   s.MessageText,
+		"timezone":  s.Timezone,
+		"status":    s.Status,
+		"runCount":  s.RunCount,
+		"createdAt": s.CreatedAt,
+		"updatedAt": s.UpdatedAt,
+	}
+	if s.CronExpr.Valid {
+		view["cron"] = s.CronExpr.String
+	}
+	if s.NextRunAt.Valid {
+		view["nextRunAt"] = s.NextRunAt.Time
+	}
+	if s.LastRunAt.Valid {
+		view["lastRunAt"] = s.LastRunAt.Time
+	}
+	return view
+}
+
+func scheduleRunView(run *entity.ScheduledMessageRun) fiber.Map {
+	view := fiber.Map{
+		"id":           run.ID,
+		"scheduleId":   run.ScheduleID,
+		"scheduledFor": run.ScheduledFor,
+		"status":       run.Status,
+		"createdAt":    run.CreatedAt,
+		"updatedAt":    run.UpdatedAt,
+	}
+	if run.OutboundID.Valid {
+		view["queueId"] = run.OutboundID.Int64
+	}
+	if run.MessageRowID.Valid {
+		view["messageRowId"] = run.MessageRowID.Int64
+	}
+	if run.ErrorMessage.Valid {
+		view["error"] = run.ErrorMessage.String
+	}
+	return view
+}
+
+func scheduleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound), errors.Is(err, usecase.ErrScheduleNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidSchedule), errors.Is(err, usecase.ErrInvalidRecipient):
+		return fiber.StatusBadRequest
+	case errors.Is(err, usecase.ErrScheduleChanged):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SearchHandler struct {
+	searchUC *usecase.SearchUseCase
+}
+
+func NewSearchHandler(searchUC *usecase.SearchUseCase) *SearchHandler {
+	return &SearchHandler{searchUC: searchUC}
+}
+
+// Search godoc
+// @Summary Search messages
+// @Description Full-text search over the caller's stored messages and Langchain executions, best matches first. q accepts web-search syntax: "quoted phrases", or, and -excluded words.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param agentId query string false "Only this session"
+// @Param since query string false "Start time (RFC 3339, inclusive)"
+// @Param until query string false "End time (RFC 3339, exclusive)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /search [get]
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	since, err := timeQuery(c, "since")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	until, err := timeQuery(c, "until")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	limit, offset := pageParams(c)
+
+	hits, err := h.searchUC.Search(c.Context(), usecase.SearchInput{
+		Caller:  currentCaller(c),
+		Query:   c.Query("q"),
+		AgentID: c.Query("agentId"),
+		Since:   since,
+		Until:   until,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		return c.Status(searchErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(hits))
+	for _, hit := range hits {
+		data = append(data, searchHitView(hit))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func searchHitView(hit *entity.SearchHit) fiber.Map {
+	view := fiber.Map{
+		"kind":      hit.Kind,
+		"id":        hit.ID,
+		"agentId":   hit.AgentID,
+		"rank":      hit.Rank,
+		"snippet":   hit.Snippet,
+		"timestamp": hit.CreatedAt,
+	}
+	if hit.Direction.Valid {
+		view["direction"] = hit.Direction.String
+	}
+	if hit.Sender.Valid {
+		view["from"] = hit.Sender.String
+	}
+	if hit.Recipient.Valid {
+		view["to"] = hit.Recipient.String
+	}
+	if hit.Chat.Valid {
+		view["chat"] = hit.Chat.String
+	}
+	return view
+}
+
+func searchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidSearch):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"bufio"
 	"database/sql"
+	"encoding/json"
 	"errors"
-	"strings"
+	"fmt"
+	"time"
+	"whatsapp-api/internal/delivery/http/middleware"
+	"whatsapp-api/internal/infrastructure/eventbus"
 	"whatsapp-api/internal/usecase"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,6 +38,8 @@ type CreateSessionRequest struct {
 // @Param request body CreateSessionRequest true "Session Creation Request"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /sessions/create [post]
 func (h *SessionHandler) CreateSession(c *fiber.Ctx) error {
@@ -51,14 +58,20 @@ func (h *SessionHandler) CreateSession(c *fiber.Ctx) error {
 		})
 	}
 
-	session, err := h.sessionUC.CreateSession(c.Context(), req.AgentID, req.AgentName, req.APIKey, req.LangchainURL)
+	session, err := h.sessionUC.CreateSession(c.Context(), currentCaller(c), req.AgentID, req.AgentName, req.APIKey, req.LangchainURL)
 	if err != nil {
-		if strings.Contains(err.Error(), "session already exists") {
+		if errors.Is(err, usecase.ErrAgentIDUnavailable) {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"success": false,
 				"error":   err.Error(),
 			})
 		}
+		if errors.Is(err, usecase.ErrAgentNotAllowed) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
@@ -108,7 +121,7 @@ func (h *SessionHandler) GetSessionStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	session, err := h.sessionUC.GetSession(c.Context(), req.AgentID)
+	session, err := h.sessionUC.GetSession(c.Context(), currentCaller(c), req.AgentID)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
@@ -136,6 +149,14 @@ func (h *SessionHandler) GetSessionStatus(c *fiber.Ctx) error {
 	})
 }
 
+// currentCaller returns the API key identity authenticated for the request.
+func currentCaller(c *fiber.Ctx) usecase.Caller {
+	if caller := middleware.CurrentCaller(c); caller != nil {
+		return *caller
+	}
+	return usecase.Caller{}
+}
+
 func stripDataURLPrefix(raw string) string {
 	const prefix = "data:image/png;base64,"
 	if len(raw) >= len(prefix) && raw[:len(prefix)] == prefix {
@@ -180,7 +201,7 @@ func (h *SessionHandler) DeleteSession(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.sessionUC.DeleteSession(c.Context(), req.AgentID); err != nil {
+	if err := h.sessionUC.DeleteSession(c.Context(), currentCaller(c), req.AgentID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"success": false,
@@ -215,7 +236,7 @@ func (h *SessionHandler) GetSessionDetail(c *fiber.Ctx) error {
 		req.AgentID = c.Query("agentId")
 	}
 
-	session, err := h.sessionUC.GetSession(c.Context(), req.AgentID)
+	session, err := h.sessionUC.GetSession(c.Context(), currentCaller(c), req.AgentID)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
@@ -235,6 +256,8 @@ func (h *SessionHandler) GetSessionDetail(c *fiber.Ctx) error {
 		"success": true,
 		"data": fiber.Map{
 			"session": session,
+			// The key itself is never returned.
+			"langchainApiKeySet": session.LangchainAPIKey.Valid && session.LangchainAPIKey.String != "",
 			"stats": fiber.Map{
 				"incoming":  stats.Incoming,
 				"responded": stats.Responded,
@@ -270,7 +293,13 @@ func (h *SessionHandler) ReconnectSession(c *fiber.Ctx) error {
 		})
 	}
 
-	session, err := h.sessionUC.ReconnectSession(c.Context(), req.AgentID)
+	session, err := h.sessionUC.ReconnectSession(c.Context(), currentCaller(c), req.AgentID)
+	if errors.Is(err, usecase.ErrSessionNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Session not found",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -291,3 +320,94 @@ func (h *SessionHandler) ReconnectSession(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// sseKeepAlive is how often a comment line is written to idle SSE streams so
+// proxies don't close them.
+const sseKeepAlive = 15 * time.Second
+
+// StreamSessionEvents godoc
+// @Summary Stream session events
+// @Description Server-Sent Events stream of a session's QR codes, status transitions, pairing and logout. The first event is the current status. Message, receipt and presence events are only sent to keys with messages:read.
+// @Tags sessions
+// @Produce text/event-stream
+// @Param agentId path string true "Agent ID"
+// @Success 200 {string} string "event stream"
+// @Failure 404 {object} map[string]interface{}
+// @Router /sessions/{agentId}/events [get]
+func (h *SessionHandler) StreamSessionEvents(c *fiber.Ctx) error {
+	agentID := c.Params("agentId")
+	caller := currentCaller(c)
+
+	// Subscribe before taking the snapshot so no transition falls in between.
+	sub := h.sessionUC.SubscribeEvents(agentID)
+	session, err := h.sessionUC.GetSession(c.Context(), caller, agentID)
+	if err != nil || session == nil {
+		h.sessionUC.UnsubscribeEvents(sub)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Session not found",
+		})
+	}
+	snapshot := eventbus.Event{
+		Type:      usecase.EventSessionStatus,
+		AgentID:   agentID,
+		Timestamp: time.Now().UTC(),
+		Data: fiber.Map{
+			"status":       session.Status,
+			"phoneNumber":  session.PhoneNumber.String,
+			"qrCode":       session.QRCode.String,
+			"qrCodeBase64": stripDataURLPrefix(session.QRCodeBase64.String),
+			"qrImage":      dataURLFromBase64(session.QRCodeBase64.String),
+		},
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.sessionUC.UnsubscribeEvents(sub)
+
+		if writeSSE(w, snapshot) != nil {
+			return
+		}
+
+		ticker := time.NewTicker(sseKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case evt, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if !caller.HasScope(usecase.EventScope(evt.Type)) {
+					continue
+				}
+				if writeSSE(w, evt) != nil {
+					return
+				}
+			case <-ticker.C:
+				// A failed flush means the client went away.
+				if _, err := w.WriteString(": keep-alive\n\n"); err != nil {
+					return
+				}
+				if w.Flush() != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+func writeSSE(w *bufio.Writer, evt eventbus.Event) error {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Type, payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler struct {
+	webhookUC *usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUC *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{webhookUC: webhookUC}
+}
+
+type RegisterWebhookRequest struct {
+	AgentID string   `json:"agentId"`
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"`
+	Events  []string `json:"events,omitempty"`
+}
+
+// RegisterWebhook godoc
+// @Summary Register a webhook
+// @Description Register a URL that receives signed POSTs for a session's events. The secret is only returned here.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body RegisterWebhookRequest true "Register Webhook Request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /webhooks [post]
+func (h *WebhookHandler) RegisterWebhook(c *fiber.Ctx) error {
+	var req RegisterWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+	if req.AgentID == "" || req.URL == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId and url are required",
+		})
+	}
+
+	wh, err := h.webhookUC.Register(c.Context(), usecase.RegisterWebhookInput{
+		Caller:  currentCaller(c),
+		AgentID: req.AgentID,
+		URL:     req.URL,
+		Secret:  req.Secret,
+		Events:  req.Events,
+	})
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := webhookView(wh)
+	data["secret"] = wh.Secret
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Webhook registered successfully",
+		"data":    data,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Description List the webhooks registered for a session
+// @Tags webhooks
+// @Produce json
+// @Param agentId query string true "Agent ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	agentID := c.Query("agentId")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "agentId is required",
+		})
+	}
+
+	webhooks, err := h.webhookUC.List(c.Context(), currentCaller(c), agentID)
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(webhooks))
+	for _, wh := range webhooks {
+		data = append(data, webhookView(wh))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook and its delivery log
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid webhook id",
+		})
+	}
+
+	if err := h.webhookUC.Delete(c.Context(), currentCaller(c), id); err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// ListDeliveries godoc
+// @Summary List webhook deliveries
+// @Description List recent delivery attempts of a webhook, newest first
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid webhook id",
+		})
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	deliveries, err := h.webhookUC.ListDeliveries(c.Context(), currentCaller(c), id, limit, offset)
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(deliveries))
+	for _, d := range deliveries {
+		data = append(data, deliveryView(d))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// ReplayDelivery godoc
+// @Summary Replay a webhook delivery
+// @Description Send the payload of a past delivery again as a new delivery
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /webhooks/deliveries/{id}/replay [post]
+func (h *WebhookHandler) ReplayDelivery(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid delivery id",
+		})
+	}
+
+	delivery, err := h.webhookUC.Replay(c.Context(), currentCaller(c), id)
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Delivery replayed",
+		"data":    deliveryView(delivery),
+	})
+}
+
+func webhookView(wh *entity.Webhook) fiber.Map {
+	return fiber.Map{
+		"id":        wh.ID,
+		"agentId":   wh.AgentID,
+		"url":       wh.URL,
+		"events":    json.RawMessage(wh.Events),
+		"active":    wh.Active,
+		"createdAt": wh.CreatedAt,
+		"updatedAt": wh.UpdatedAt,
+	}
+}
+
+func deliveryView(d *entity.WebhookDelivery) fiber.Map {
+	view := fiber.Map{
+		"id":        d.ID,
+		"webhookId": d.WebhookID,
+		"event":     d.Event,
+		"payload":   json.RawMessage(d.Payload),
+		"status":    d.Status,
+		"attempts":  d.Attempts,
+		"createdAt": d.CreatedAt,
+	}
+	if d.ResponseStatus.Valid {
+		view["responseStatus"] = d.ResponseStatus.Int64
+	}
+	if d.ResponseBody.Valid {
+		view["responseBody"] = d.ResponseBody.String
+	}
+	if d.ErrorMessage.Valid {
+		view["error"] = d.ErrorMessage.String
+	}
+	if d.NextAttemptAt.Valid {
+		view["nextAttemptAt"] = d.NextAttemptAt.Time
+	}
+	if d.DeliveredAt.Valid {
+		view["deliveredAt"] = d.DeliveredAt.Time
+	}
+	return view
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound), errors.Is(err, usecase.ErrWebhookNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidWebhook):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -1,13 +1,26 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
-	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/usecase"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 )
 
-func AuthMiddleware(userRepo repository.UserRepository) fiber.Handler {
+const (
+	// UserLocalsKey is the Locals key AuthMiddleware stores the *entity.User under.
+	UserLocalsKey = "user"
+	// CallerLocalsKey is the Locals key AuthMiddleware stores the
+	// *usecase.Caller, the key's permissions, under.
+	CallerLocalsKey = "caller"
+)
+
+// AuthMiddleware authenticates the API key and stores its user and
+// permissions for RequireScope and the handlers.
+func AuthMiddleware(userUC *usecase.UserUseCase) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -25,25 +38,84 @@ func AuthMiddleware(userRepo repository.UserRepository) fiber.Handler {
 			})
 		}
 
-		apiKey := parts[1]
-		// Debug log
-		println("Received API Key:", apiKey)
-
-		user, err := userRepo.GetByAPIKey(c.Context(), apiKey)
+		user, caller, err := userUC.Authenticate(c.Context(), parts[1])
+		if errors.Is(err, usecase.ErrInvalidAPIKey) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid API Key",
+			})
+		}
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
 				"error":   "Internal Server Error",
 			})
 		}
-		if user == nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+
+		c.Locals(UserLocalsKey, user)
+		c.Locals(CallerLocalsKey, caller)
+		return c.Next()
+	}
+}
+
+// RequireScope only lets API keys holding every given scope through. It must
+// run after AuthMiddleware.
+func RequireScope(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		caller := CurrentCaller(c)
+		for _, scope := range scopes {
+			if caller == nil || !caller.HasScope(scope) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"success": false,
+					"error":   "API key lacks the " + scope + " scope",
+				})
+			}
+		}
+		return c.Next()
+	}
+}
+
+// RequireAdmin only lets admin users through. It must run after AuthMiddleware.
+func RequireAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if caller := CurrentCaller(c); caller == nil || !caller.IsAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"success": false,
-				"error":   "Invalid API Key",
+				"error":   "Admin access required",
 			})
 		}
+		return c.Next()
+	}
+}
 
-		c.Locals("user", user)
+// CurrentUser returns the user authenticated by AuthMiddleware.
+func CurrentUser(c *fiber.Ctx) *entity.User {
+	user, _ := c.Locals(UserLocalsKey).(*entity.User)
+	return user
+}
+
+// CurrentCaller returns the API key permissions authenticated by AuthMiddleware.
+func CurrentCaller(c *fiber.Ctx) *usecase.Caller {
+	caller, _ := c.Locals(CallerLocalsKey).(*usecase.Caller)
+	return caller
+}
+
+// APIKeyFromQuery lets WebSocket handshakes and EventSource streams, which
+// browsers cannot add headers to, pass the API key as a query parameter. It
+// must run before AuthMiddleware.
+func APIKeyFromQuery(param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" && (websocket.IsWebSocketUpgrade(c) || acceptsEventStream(c)) {
+			if apiKey := c.Query(param); apiKey != "" {
+				c.Request().Header.Set("Authorization", "Bearer "+apiKey)
+			}
+		}
 		return c.Next()
 	}
 }
+
+// acceptsEventStream reports whether the request comes from an EventSource,
+// which always asks for text/event-stream.
+func acceptsEventStream(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodGet && strings.Contains(c.Get(fiber.HeaderAccept), "text/event-stream")
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAPIKeyFromQuery(t *testing.T) {
+	app := fiber.New()
+	app.Use(APIKeyFromQuery("apiKey"))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(c.Get("Authorization")) })
+
+	tests := []struct {
+		name    string
+		url     string
+		headers map[string]string
+		want    string
+	}{
+		{"event stream", "/?apiKey=k1", map[string]string{"Accept": "text/event-stream"}, "Bearer k1"},
+		{"websocket", "/?apiKey=k1", map[string]string{"Connection": "Upgrade", "Upgrade": "websocket"}, "Bearer k1"},
+		{"header wins", "/?apiKey=k1", map[string]string{"Accept": "text/event-stream", "Authorization": "Bearer k2"}, "Bearer k2"},
+		// Plain requests must send the header, so keys stay out of ordinary URLs.
+		{"plain request", "/?apiKey=k1", map[string]string{"Accept": "application/json"}, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if got := string(body); got != tt.want {
+			t.Errorf("%s: Authorization = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"whatsapp-api/internal/infrastructure/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimitRoute applies Rule instead of the default to requests whose path
+// starts with PathPrefix and, when set, whose method is Method. A rule with
+// no requests leaves matching routes unlimited.
+type RateLimitRoute struct {
+	Method     string
+	PathPrefix string
+	Rule       ratelimit.Rule
+}
+
+// RateLimit limits requests per API key, or per client IP when the request
+// is not authenticated, and reports the limit in RateLimit-* headers. A nil
+// limiter disables it. When it runs after AuthMiddleware the key is known.
+func RateLimit(limiter *ratelimit.Limiter, rule ratelimit.Rule, routes []RateLimitRoute) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if limiter == nil {
+			return c.Next()
+		}
+
+		key := "ip:" + c.IP()
+		if caller := CurrentCaller(c); caller != nil {
+			key = fmt.Sprintf("key:%d", caller.KeyID)
+		}
+		applied := rule
+		for _, route := range routes {
+			if strings.HasPrefix(c.Path(), route.PathPrefix) && (route.Method == "" || strings.EqualFold(route.Method, c.Method())) {
+				applied = route.Rule
+				// Overridden routes get their own bucket.
+				key += "|" + route.Method + " " + route.PathPrefix
+				break
+			}
+		}
+		if applied.Requests <= 0 {
+			return c.Next()
+		}
+
+		return limit(c, limiter, key, applied)
+	}
+}
+
+// IPRateLimit limits requests per client IP before they are authenticated,
+// so invalid API keys cannot be tried without bound. A nil limiter or a rule
+// with no requests disables it.
+func IPRateLimit(limiter *ratelimit.Limiter, rule ratelimit.Rule) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if limiter == nil || rule.Requests <= 0 {
+			return c.Next()
+		}
+		// Separate from the per-key limiter's "ip:" bucket, which may use
+		// another rule.
+		return limit(c, limiter, "preauth-ip:"+c.IP(), rule)
+	}
+}
+
+func limit(c *fiber.Ctx, limiter *ratelimit.Limiter, key string, rule ratelimit.Rule) error {
+	res, err := limiter.Allow(c.Context(), key, rule)
+	if err != nil {
+		// Fail open: a store outage should not take the API down.
+		log.Printf("ratelimit: %v", err)
+		return c.Next()
+	}
+
+	reset := strconv.Itoa(int(math.Ceil(res.Reset.Seconds())))
+	c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Set("RateLimit-Reset", reset)
+	if !res.Allowed {
+		c.Set(fiber.HeaderRetryAfter, reset)
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"success": false,
+			"error":   "Rate limit exceeded",
+		})
+	}
+	return c.Next()
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"whatsapp-api/internal/infrastructure/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIPRateLimitRunsBeforeAuth(t *testing.T) {
+	app := fiber.New()
+	limiter := ratelimit.New(ratelimit.NewMemoryStore())
+	app.Use(IPRateLimit(limiter, ratelimit.Rule{Requests: 2, Window: time.Hour}))
+	app.Use(func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusUnauthorized) })
+
+	want := []int{fiber.StatusUnauthorized, fiber.StatusUnauthorized, fiber.StatusTooManyRequests}
+	for i, status := range want {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/sessions/status", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != status {
+			t.Fatalf("request %d: status %d, want %d", i+1, resp.StatusCode, status)
+		}
+	}
+}
+
+func TestIPRateLimitDisabled(t *testing.T) {
+	app := fiber.New()
+	app.Use(IPRateLimit(nil, ratelimit.Rule{Requests: 1, Window: time.Hour}))
+	app.Use(IPRateLimit(ratelimit.New(ratelimit.NewMemoryStore()), ratelimit.Rule{}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	for i := 0; i < 3; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusNoContent {
+			t.Fatalf("request %d: status %d, want %d", i+1, resp.StatusCode, fiber.StatusNoContent)
+		}
+	}
+}
@@ -2,25 +2,110 @@ package http
 
 import (
 	"whatsapp-api/internal/delivery/http/handler"
+	"whatsapp-api/internal/delivery/http/middleware"
+	"whatsapp-api/internal/usecase"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	fiberSwagger "github.com/gofiber/swagger"
 )
 
-func NewRouter(app *fiber.App, sessionHandler *handler.SessionHandler, langchainHandler *handler.LangchainHandler) {
-	api := app.Group("/api/v1")
+func NewRouter(app *fiber.App, sessionHandler *handler.SessionHandler, messageHandler *handler.MessageHandler, langchainHandler *handler.LangchainHandler, webhookHandler *handler.WebhookHandler, realtimeHandler *handler.RealtimeHandler, adminHandler *handler.AdminHandler, campaignHandler *handler.CampaignHandler, scheduleHandler *handler.ScheduleHandler, chatHandler *handler.ChatHandler, searchHandler *handler.SearchHandler, retentionHandler *handler.RetentionHandler, exportHandler *handler.ExportHandler, analyticsHandler *handler.AnalyticsHandler, healthHandler *handler.HealthHandler, userUC *usecase.UserUseCase, ipRateLimit, rateLimit fiber.Handler) {
+	// Probes for systemd, nginx and orchestrators; no API key.
+	app.Get("/healthz", healthHandler.Healthz)
+	app.Get("/readyz", healthHandler.Readyz)
 
+	// Every API route requires an API key. WebSocket handshakes and
+	// EventSource streams may pass it as ?apiKey= since browsers cannot set
+	// headers on them. Requests are
+	// rate limited per client IP before authentication, and per key once it
+	// is known.
+	api := app.Group("/api/v1",
+		ipRateLimit,
+		middleware.APIKeyFromQuery("apiKey"),
+		middleware.AuthMiddleware(userUC),
+		rateLimit,
+	)
+
+	// Each route also requires its scope on the API key.
 	sessions := api.Group("/sessions")
-	sessions.Post("/create", sessionHandler.CreateSession)
-	sessions.Get("/status", sessionHandler.GetSessionStatus)
-	sessions.Delete("/delete", sessionHandler.DeleteSession)
-	sessions.Get("/detail", sessionHandler.GetSessionDetail)
-	sessions.Post("/reconnect", sessionHandler.ReconnectSession)
+	sessions.Post("/create", middleware.RequireScope(usecase.ScopeSessionsWrite), sessionHandler.CreateSession)
+	sessions.Get("/status", middleware.RequireScope(usecase.ScopeSessionsRead), sessionHandler.GetSessionStatus)
+	sessions.Delete("/delete", middleware.RequireScope(usecase.ScopeSessionsWrite), sessionHandler.DeleteSession)
+	sessions.Get("/detail", middleware.RequireScope(usecase.ScopeSessionsRead), sessionHandler.GetSessionDetail)
+	sessions.Post("/reconnect", middleware.RequireScope(usecase.ScopeSessionsWrite), sessionHandler.ReconnectSession)
+	sessions.Get("/:agentId/events", middleware.RequireScope(usecase.ScopeSessionsRead), sessionHandler.StreamSessionEvents)
+	sessions.Get("/:agentId/messages", middleware.RequireScope(usecase.ScopeMessagesRead), messageHandler.ListMessages)
+	sessions.Get("/:agentId/chats", middleware.RequireScope(usecase.ScopeMessagesRead), chatHandler.ListChats)
+	sessions.Get("/:agentId/export", middleware.RequireScope(usecase.ScopeMessagesRead), exportHandler.ExportMessages)
+	sessions.Get("/:agentId/analytics", middleware.RequireScope(usecase.ScopeMessagesRead), analyticsHandler.GetAnalytics)
 	// Add other routes here
 
-	langchain := api.Group("/langchain")
+	messages := api.Group("/messages")
+	messages.Post("/send", middleware.RequireScope(usecase.ScopeMessagesSend), messageHandler.SendMessage)
+	messages.Post("/send-media", middleware.RequireScope(usecase.ScopeMessagesSend), messageHandler.SendMedia)
+	messages.Get("/queue", middleware.RequireScope(usecase.ScopeMessagesRead), messageHandler.ListQueue)
+	messages.Get("/:id", middleware.RequireScope(usecase.ScopeMessagesRead), messageHandler.GetMessage)
+	messages.Get("/:id/media", middleware.RequireScope(usecase.ScopeMessagesRead), messageHandler.DownloadMedia)
+
+	campaigns := api.Group("/campaigns")
+	campaigns.Post("/", middleware.RequireScope(usecase.ScopeMessagesSend), campaignHandler.CreateCampaign)
+	campaigns.Get("/", middleware.RequireScope(usecase.ScopeMessagesRead), campaignHandler.ListCampaigns)
+	campaigns.Get("/:id", middleware.RequireScope(usecase.ScopeMessagesRead), campaignHandler.GetCampaign)
+	campaigns.Get("/:id/recipients", middleware.RequireScope(usecase.ScopeMessagesRead), campaignHandler.ListCampaignRecipients)
+	campaigns.Post("/:id/start", middleware.RequireScope(usecase.ScopeMessagesSend), campaignHandler.StartCampaign)
+	campaigns.Post("/:id/pause", middleware.RequireScope(usecase.ScopeMessagesSend), campaignHandler.PauseCampaign)
+	campaigns.Post("/:id/resume", middleware.RequireScope(usecase.ScopeMessagesSend), campaignHandler.ResumeCampaign)
+	campaigns.Post("/:id/cancel", middleware.RequireScope(usecase.ScopeMessagesSend), campaignHandler.CancelCampaign)
+
+	schedules := api.Group("/schedules")
+	schedules.Post("/", middleware.RequireScope(usecase.ScopeMessagesSend), scheduleHandler.CreateSchedule)
+	schedules.Get("/", middleware.RequireScope(usecase.ScopeMessagesRead), scheduleHandler.ListSchedules)
+	schedules.Get("/:id", middleware.RequireScope(usecase.ScopeMessagesRead), scheduleHandler.GetSchedule)
+	schedules.Patch("/:id", middleware.RequireScope(usecase.ScopeMessagesSend), scheduleHandler.UpdateSchedule)
+	schedules.Delete("/:id", middleware.RequireScope(usecase.ScopeMessagesSend), scheduleHandler.DeleteSchedule)
+	schedules.Get("/:id/runs", middleware.RequireScope(usecase.ScopeMessagesRead), scheduleHandler.ListScheduleRuns)
+
+	chats := api.Group("/chats")
+	chats.Get("/:id", middleware.RequireScope(usecase.ScopeMessagesRead), chatHandler.GetChat)
+	chats.Patch("/:id", middleware.RequireScope(usecase.ScopeMessagesSend), chatHandler.UpdateChat)
+
+	api.Get("/health/sessions", middleware.RequireScope(usecase.ScopeSessionsRead), healthHandler.SessionsHealth)
+
+	api.Get("/search", middleware.RequireScope(usecase.ScopeMessagesRead), searchHandler.Search)
+
+	retention := api.Group("/retention")
+	retention.Get("/", middleware.RequireScope(usecase.ScopeSessionsRead), retentionHandler.ListRetention)
+	retention.Put("/", middleware.RequireScope(usecase.ScopeSessionsWrite), retentionHandler.SetRetention)
+	retention.Delete("/", middleware.RequireScope(usecase.ScopeSessionsWrite), retentionHandler.DeleteRetention)
+	retention.Get("/preview", middleware.RequireScope(usecase.ScopeSessionsRead), retentionHandler.PreviewRetention)
+	api.Post("/contacts/erase", middleware.RequireScope(usecase.ScopeSessionsWrite), retentionHandler.EraseContact)
+
+	webhooks := api.Group("/webhooks", middleware.RequireScope(usecase.ScopeWebhooksManage))
+	webhooks.Post("/", webhookHandler.RegisterWebhook)
+	webhooks.Get("/", webhookHandler.ListWebhooks)
+	webhooks.Delete("/:id", webhookHandler.DeleteWebhook)
+	webhooks.Get("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooks.Post("/deliveries/:id/replay", webhookHandler.ReplayDelivery)
+
+	// The socket only forwards message events to keys with messages:read and
+	// checks messages:send per send or typing frame.
+	api.Get("/ws", middleware.RequireScope(usecase.ScopeSessionsRead), realtimeHandler.Upgrade, websocket.New(realtimeHandler.Serve))
+
+	admin := api.Group("/admin", middleware.RequireScope(usecase.ScopeAdmin), middleware.RequireAdmin())
+	admin.Post("/users", adminHandler.CreateUser)
+	admin.Get("/users", adminHandler.ListUsers)
+	admin.Post("/users/:userId/keys", adminHandler.IssueAPIKey)
+	admin.Get("/users/:userId/keys", adminHandler.ListAPIKeys)
+	admin.Post("/keys/:id/rotate", adminHandler.RotateAPIKey)
+	admin.Delete("/keys/:id", adminHandler.RevokeAPIKey)
+
+	langchain := api.Group("/langchain", middleware.RequireScope(usecase.ScopeLangchainExecute))
 	langchain.Post("/execute", langchainHandler.Execute)
+	langchain.Get("/executions", langchainHandler.ListExecutions)
+	langchain.Get("/executions/:id", langchainHandler.GetExecution)
+	langchain.Post("/executions/:id/replay", langchainHandler.ReplayExecution)
 
 	// Swagger
-	app.Get("/swagger/*", fiberSwagger.HandlerDefault)
+	app.Get("/swagger/*", rateLimit, fiberSwagger.HandlerDefault)
 }
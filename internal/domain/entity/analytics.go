@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AnalyticsBucket counts a session's messages in one hour, day, week or
+// month.
+type AnalyticsBucket struct {
+	Start          time.Time `json:"start" db:"bucket_start"`
+	Incoming       int       `json:"incoming" db:"incoming"`
+	Outgoing       int       `json:"outgoing" db:"outgoing"`
+	UniqueContacts int       `json:"uniqueContacts" db:"unique_contacts"`
+}
+
+// AnalyticsTotals summarises a session's messages over a period.
+type AnalyticsTotals struct {
+	Incoming       int `json:"incoming" db:"incoming"`
+	Outgoing       int `json:"outgoing" db:"outgoing"`
+	UniqueContacts int `json:"uniqueContacts" db:"unique_contacts"`
+	GroupMessages  int `json:"groupMessages" db:"group_messages"`
+	DirectMessages int `json:"directMessages" db:"direct_messages"`
+	// Answered counts incoming messages that got a Langchain reply.
+	Answered int `json:"answered" db:"answered"`
+}
+
+// LangchainAnalytics summarises a session's Langchain executions over a
+// period. Percentiles are NULL when nothing ran.
+type LangchainAnalytics struct {
+	Executions int             `json:"executions" db:"executions"`
+	Succeeded  int             `json:"succeeded" db:"succeeded"`
+	Failed     int             `json:"failed" db:"failed"`
+	P50Ms      sql.NullFloat64 `json:"p50Ms" db:"p50_ms"`
+	P95Ms      sql.NullFloat64 `json:"p95Ms" db:"p95_ms"`
+	P99Ms      sql.NullFloat64 `json:"p99Ms" db:"p99_ms"`
+}
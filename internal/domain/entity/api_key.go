@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+type APIKey struct {
+	ID         int          `json:"id" db:"id"`
+	UserID     string       `json:"userId" db:"user_id"`
+	Name       string       `json:"name" db:"name"`
+	Prefix     string       `json:"prefix" db:"prefix"`
+	KeyHash    string       `json:"-" db:"key_hash"`
+	Salt       string       `json:"-" db:"salt"`
+	Scopes     []byte       `json:"scopes" db:"scopes"`      // JSONB array, "*" = all
+	AgentIDs   []byte       `json:"agentIds" db:"agent_ids"` // JSONB array, empty = all
+	LastUsedAt sql.NullTime `json:"lastUsedAt" db:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revokedAt" db:"revoked_at"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time    `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Campaign sends one message template to a list of recipients from a session.
+type Campaign struct {
+	ID                  int            `json:"id" db:"id"`
+	SessionID           int            `json:"sessionId" db:"session_id"`
+	AgentID             string         `json:"agentId" db:"agent_id"`
+	Name                string         `json:"name" db:"name"`
+	Template            string         `json:"template" db:"template"`
+	Status              string         `json:"status" db:"status"` // draft, running, paused, stopped, completed, cancelled
+	ConsecutiveFailures int            `json:"consecutiveFailures" db:"consecutive_failures"`
+	StopReason          sql.NullString `json:"stopReason" db:"stop_reason"`
+	NextSendAt          sql.NullTime   `json:"nextSendAt" db:"next_send_at"`
+	StartedAt           sql.NullTime   `json:"startedAt" db:"started_at"`
+	FinishedAt          sql.NullTime   `json:"finishedAt" db:"finished_at"`
+	CreatedAt           time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type CampaignRecipient struct {
+	ID           int            `json:"id" db:"id"`
+	CampaignID   int            `json:"campaignId" db:"campaign_id"`
+	AgentID      string         `json:"agentId" db:"agent_id"`
+	Phone        string         `json:"phone" db:"phone"`
+	Name         sql.NullString `json:"name" db:"name"`
+	Variables    []byte         `json:"variables" db:"variables"` // JSONB object
+	Status       string         `json:"status" db:"status"`       // pending, queued, sent, delivered, read, failed
+	OutboundID   sql.NullInt64  `json:"outboundId" db:"outbound_id"`
+	MessageID    sql.NullString `json:"messageId" db:"message_id"`
+	ErrorMessage sql.NullString `json:"errorMessage" db:"error_message"`
+	SentAt       sql.NullTime   `json:"sentAt" db:"sent_at"`
+	DeliveredAt  sql.NullTime   `json:"deliveredAt" db:"delivered_at"`
+	ReadAt       sql.NullTime   `json:"readAt" db:"read_at"`
+	CreatedAt    time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time      `json:"updatedAt" db:"updated_at"`
+}
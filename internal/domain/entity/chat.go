@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Chat is one conversation of a session, direct or group, with a summary of
+// its latest message.
+type Chat struct {
+	ID                   int            `json:"id" db:"id"`
+	SessionID            int            `json:"sessionId" db:"session_id"`
+	AgentID              string         `json:"agentId" db:"agent_id"`
+	ChatJID              string         `json:"chatJid" db:"chat_jid"`
+	ChatType             string         `json:"chatType" db:"chat_type"` // direct, group
+	DisplayName          sql.NullString `json:"displayName" db:"display_name"`
+	LastMessageID        sql.NullInt64  `json:"lastMessageId" db:"last_message_id"`
+	LastMessageText      sql.NullString `json:"lastMessageText" db:"last_message_text"`
+	LastMessageType      sql.NullString `json:"lastMessageType" db:"last_message_type"`
+	LastMessageDirection sql.NullString `json:"lastMessageDirection" db:"last_message_direction"`
+	LastMessageAt        sql.NullTime   `json:"lastMessageAt" db:"last_message_at"`
+	UnreadCount          int            `json:"unreadCount" db:"unread_count"`
+	Archived             bool           `json:"archived" db:"archived"`
+	Muted                bool           `json:"muted" db:"muted"`
+	CreatedAt            time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt            time.Time      `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "database/sql"
+
+// ExportedMessage is a stored message with the name of its chat and the
+// outcome of the Langchain execution that produced it, if any.
+type ExportedMessage struct {
+	Message
+	ChatName          sql.NullString `json:"chatName" db:"chat_name"`
+	ExecutionStatus   sql.NullString `json:"executionStatus" db:"execution_status"`
+	ExecutionTimeMs   sql.NullInt64  `json:"executionTimeMs" db:"execution_time_ms"`
+	ExecutionError    sql.NullString `json:"executionError" db:"execution_error"`
+	ExecutionReplayOf sql.NullInt64  `json:"executionReplayOf" db:"execution_replay_of"`
+}
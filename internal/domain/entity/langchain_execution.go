@@ -10,6 +10,9 @@ type LangchainExecution struct {
 	SessionID         int            `json:"sessionId" db:"session_id"`
 	AgentID           string         `json:"agentId" db:"agent_id"`
 	UserMessage       sql.NullString `json:"userMessage" db:"user_message"`
+	Sender            sql.NullString `json:"sender" db:"sender"`
+	MessageID         sql.NullInt64  `json:"messageId" db:"message_id"`                 // incoming message that triggered it
+	ReplayOf          sql.NullInt64  `json:"replayOf" db:"replay_of"`                   // execution this one re-ran
 	LangchainResponse []byte         `json:"langchainResponse" db:"langchain_response"` // JSONB
 	ExecutionTimeMs   sql.NullInt64  `json:"executionTimeMs" db:"execution_time_ms"`
 	Status            sql.NullString `json:"status" db:"status"`
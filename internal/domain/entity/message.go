@@ -6,16 +6,21 @@ import (
 )
 
 type Message struct {
-	ID          int            `json:"id" db:"id"`
-	SessionID   int            `json:"sessionId" db:"session_id"`
-	AgentID     string         `json:"agentId" db:"agent_id"`
-	MessageID   sql.NullString `json:"messageId" db:"message_id"`
-	FromNumber  sql.NullString `json:"fromNumber" db:"from_number"`
-	ToNumber    sql.NullString `json:"toNumber" db:"to_number"`
-	MessageText sql.NullString `json:"messageText" db:"message_text"`
-	MessageType sql.NullString `json:"messageType" db:"message_type"`
-	Direction   sql.NullString `json:"direction" db:"direction"`
-	Status      sql.NullString `json:"status" db:"status"`
-	Metadata    []byte         `json:"metadata" db:"metadata"` // JSONB
-	CreatedAt   time.Time      `json:"createdAt" db:"created_at"`
+	ID                   int            `json:"id" db:"id"`
+	SessionID            int            `json:"sessionId" db:"session_id"`
+	AgentID              string         `json:"agentId" db:"agent_id"`
+	MessageID            sql.NullString `json:"messageId" db:"message_id"`
+	FromNumber           sql.NullString `json:"fromNumber" db:"from_number"`
+	ToNumber             sql.NullString `json:"toNumber" db:"to_number"`
+	MessageText          sql.NullString `json:"messageText" db:"message_text"`
+	MessageType          sql.NullString `json:"messageType" db:"message_type"`
+	Direction            sql.NullString `json:"direction" db:"direction"`
+	Status               sql.NullString `json:"status" db:"status"`     // received; outgoing: sent, delivered, read, played
+	Metadata             []byte         `json:"metadata" db:"metadata"` // JSONB
+	ReplyToID            sql.NullInt64  `json:"replyToId" db:"reply_to_id"`
+	LangchainExecutionID sql.NullInt64  `json:"langchainExecutionId" db:"langchain_execution_id"`
+	DeliveredAt          sql.NullTime   `json:"deliveredAt" db:"delivered_at"`
+	ReadAt               sql.NullTime   `json:"readAt" db:"read_at"`
+	PlayedAt             sql.NullTime   `json:"playedAt" db:"played_at"`
+	CreatedAt            time.Time      `json:"createdAt" db:"created_at"`
 }
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OutboundMessage is a message waiting in a session's send queue. Once sent,
+// MessageRowID points at the stored outgoing row in messages.
+type OutboundMessage struct {
+	ID                   int            `json:"id" db:"id"`
+	SessionID            int            `json:"sessionId" db:"session_id"`
+	AgentID              string         `json:"agentId" db:"agent_id"`
+	Priority             int            `json:"priority" db:"priority"`
+	Recipient            string         `json:"recipient" db:"recipient"` // JID
+	Payload              []byte         `json:"-" db:"payload"`           // protobuf-encoded waProto.Message
+	MessageText          sql.NullString `json:"messageText" db:"message_text"`
+	MessageType          string         `json:"messageType" db:"message_type"`
+	Metadata             []byte         `json:"metadata" db:"metadata"` // JSONB
+	ReplyToID            sql.NullInt64  `json:"replyToId" db:"reply_to_id"`
+	LangchainExecutionID sql.NullInt64  `json:"langchainExecutionId" db:"langchain_execution_id"`
+	Status               string         `json:"status" db:"status"` // queued, sent, failed
+	Attempts             int            `json:"attempts" db:"attempts"`
+	ErrorMessage         sql.NullString `json:"errorMessage" db:"error_message"`
+	MessageRowID         sql.NullInt64  `json:"messageRowId" db:"message_row_id"`
+	SendAfter            time.Time      `json:"sendAfter" db:"send_after"`
+	SentAt               sql.NullTime   `json:"sentAt" db:"sent_at"`
+	CreatedAt            time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt            time.Time      `json:"updatedAt" db:"updated_at"`
+
+	// Receipt state of the sent messages row; only listings fill these.
+	MessageStatus sql.NullString `json:"messageStatus" db:"message_status"`
+	DeliveredAt   sql.NullTime   `json:"deliveredAt" db:"delivered_at"`
+	ReadAt        sql.NullTime   `json:"readAt" db:"read_at"`
+	PlayedAt      sql.NullTime   `json:"playedAt" db:"played_at"`
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RetentionPolicy is a user's default retention when SessionID is NULL and
+// a session override otherwise. A NULL day count inherits the user's
+// default and 0 keeps data forever.
+type RetentionPolicy struct {
+	ID            int            `json:"id" db:"id"`
+	UserID        string         `json:"userId" db:"user_id"`
+	SessionID     sql.NullInt64  `json:"sessionId" db:"session_id"`
+	AgentID       sql.NullString `json:"agentId" db:"agent_id"` // joined from sessions
+	MessageDays   sql.NullInt64  `json:"messageDays" db:"message_days"`
+	ExecutionDays sql.NullInt64  `json:"executionDays" db:"execution_days"`
+	MediaDays     sql.NullInt64  `json:"mediaDays" db:"media_days"`
+	CreatedAt     time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+// SessionRetention is the retention in force for a session once its
+// override is applied to the user's default. 0 keeps data forever.
+type SessionRetention struct {
+	SessionID     int    `json:"sessionId" db:"session_id"`
+	AgentID       string `json:"agentId" db:"agent_id"`
+	UserID        string `json:"userId" db:"user_id"`
+	MessageDays   int    `json:"messageDays" db:"message_days"`
+	ExecutionDays int    `json:"executionDays" db:"execution_days"`
+	MediaDays     int    `json:"mediaDays" db:"media_days"`
+}
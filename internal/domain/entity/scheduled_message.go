@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ScheduledMessage sends a text at NextRunAt, once or, with a cron
+// expression, repeatedly.
+type ScheduledMessage struct {
+	ID          int            `json:"id" db:"id"`
+	SessionID   int            `json:"sessionId" db:"session_id"`
+	AgentID     string         `json:"agentId" db:"agent_id"`
+	Recipient   string         `json:"recipient" db:"recipient"` // JID
+	MessageText string         `json:"messageText" db:"message_text"`
+	CronExpr    sql.NullString `json:"cron" db:"cron_expr"`
+	Timezone    string         `json:"timezone" db:"timezone"`
+	Status      string         `json:"status" db:"status"` // active, paused, completed
+	NextRunAt   sql.NullTime   `json:"nextRunAt" db:"next_run_at"`
+	LastRunAt   sql.NullTime   `json:"lastRunAt" db:"last_run_at"`
+	RunCount    int            `json:"runCount" db:"run_count"`
+	CreatedAt   time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+// ScheduledMessageRun records one firing of a schedule. MessageRowID points
+// at the stored outgoing row in messages once it was sent.
+type ScheduledMessageRun struct {
+	ID           int            `json:"id" db:"id"`
+	ScheduleID   int            `json:"scheduleId" db:"schedule_id"`
+	AgentID      string         `json:"agentId" db:"agent_id"`
+	ScheduledFor time.Time      `json:"scheduledFor" db:"scheduled_for"`
+	Status       string         `json:"status" db:"status"` // pending, queued, sent, failed
+	OutboundID   sql.NullInt64  `json:"outboundId" db:"outbound_id"`
+	MessageRowID sql.NullInt64  `json:"messageRowId" db:"message_row_id"`
+	ErrorMessage sql.NullString `json:"errorMessage" db:"error_message"`
+	CreatedAt    time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time      `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SearchHit is a stored message or Langchain execution matching a full-text
+// search, with a snippet of the matched text.
+type SearchHit struct {
+	Kind      string         `json:"kind" db:"kind"` // message, langchain_execution
+	ID        int            `json:"id" db:"id"`
+	AgentID   string         `json:"agentId" db:"agent_id"`
+	Rank      float64        `json:"rank" db:"rank"`
+	Snippet   string         `json:"snippet" db:"snippet"`
+	Direction sql.NullString `json:"direction" db:"direction"`
+	Sender    sql.NullString `json:"sender" db:"sender"`
+	Recipient sql.NullString `json:"recipient" db:"recipient"`
+	Chat      sql.NullString `json:"chat" db:"chat"`
+	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
+}
@@ -16,7 +16,7 @@ type Session struct {
 	SessionData       []byte         `json:"sessionData" db:"session_data"` // JSONB stored as byte array
 	Status            string         `json:"status" db:"status"`
 	LangchainURL      sql.NullString `json:"langchainUrl" db:"langchain_url"`
-	LangchainAPIKey   sql.NullString `json:"langchainApiKey" db:"langchain_api_key"`
+	LangchainAPIKey   sql.NullString `json:"-" db:"langchain_api_key"` // secret: encrypted at rest, never serialized
 	LastQRGeneratedAt sql.NullTime   `json:"lastQrGeneratedAt" db:"last_qr_generated_at"`
 	ConnectedAt       sql.NullTime   `json:"connectedAt" db:"connected_at"`
 	DisconnectedAt    sql.NullTime   `json:"disconnectedAt" db:"disconnected_at"`
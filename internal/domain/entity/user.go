@@ -6,7 +6,7 @@ import (
 
 type User struct {
 	UserID    string    `json:"userId" db:"user_id"`
-	APIKey    string    `json:"apiKey" db:"api_key"`
+	IsAdmin   bool      `json:"isAdmin" db:"is_admin"`
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
 }
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Webhook struct {
+	ID        int       `json:"id" db:"id"`
+	SessionID int       `json:"sessionId" db:"session_id"`
+	AgentID   string    `json:"agentId" db:"agent_id"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"-" db:"secret"`
+	Events    []byte    `json:"events" db:"events"` // JSONB array of event names
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int            `json:"id" db:"id"`
+	WebhookID      int            `json:"webhookId" db:"webhook_id"`
+	AgentID        string         `json:"agentId" db:"agent_id"`
+	Event          string         `json:"event" db:"event"`
+	Payload        []byte         `json:"payload" db:"payload"` // JSONB
+	Status         string         `json:"status" db:"status"`
+	Attempts       int            `json:"attempts" db:"attempts"`
+	ResponseStatus sql.NullInt64  `json:"responseStatus" db:"response_status"`
+	ResponseBody   sql.NullString `json:"responseBody" db:"response_body"`
+	ErrorMessage   sql.NullString `json:"errorMessage" db:"error_message"`
+	NextAttemptAt  sql.NullTime   `json:"nextAttemptAt" db:"next_attempt_at"`
+	DeliveredAt    sql.NullTime   `json:"deliveredAt" db:"delivered_at"`
+	CreatedAt      time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updatedAt" db:"updated_at"`
+}
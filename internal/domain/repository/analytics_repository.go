@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+)
+
+// AnalyticsFilter selects a session's activity in [Since, Until). Bucket is
+// a Postgres date_trunc unit: hour, day, week or month.
+type AnalyticsFilter struct {
+	SessionID int
+	Bucket    string
+	Since     time.Time
+	Until     time.Time
+}
+
+type AnalyticsRepository interface {
+	// Buckets returns every bucket in the period, oldest first, including
+	// empty ones.
+	Buckets(ctx context.Context, filter AnalyticsFilter) ([]*entity.AnalyticsBucket, error)
+	Totals(ctx context.Context, filter AnalyticsFilter) (*entity.AnalyticsTotals, error)
+	Langchain(ctx context.Context, filter AnalyticsFilter) (*entity.LangchainAnalytics, error)
+}
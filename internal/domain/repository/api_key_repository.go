@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	GetByID(ctx context.Context, id int) (*entity.APIKey, error)
+	// GetActiveByPrefix returns the unrevoked keys whose prefix matches.
+	GetActiveByPrefix(ctx context.Context, prefix string) ([]*entity.APIKey, error)
+	GetByUserID(ctx context.Context, userID string) ([]*entity.APIKey, error)
+	CountActiveByUserID(ctx context.Context, userID string) (int, error)
+	Revoke(ctx context.Context, id int, at time.Time) error
+	// Rotate stores newKey and revokes oldID in one transaction. It returns
+	// sql.ErrNoRows, storing nothing, when oldID is not an active key.
+	Rotate(ctx context.Context, oldID int, newKey *entity.APIKey, at time.Time) error
+	UpdateLastUsed(ctx context.Context, id int, at time.Time) error
+}
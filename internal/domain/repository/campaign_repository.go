@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+)
+
+type CampaignRepository interface {
+	// Create stores the campaign and its recipients in one transaction.
+	Create(ctx context.Context, campaign *entity.Campaign, recipients []*entity.CampaignRecipient) error
+	// Transition stores the campaign's status fields if it is still in status
+	// from, so concurrent state changes cannot overwrite each other.
+	Transition(ctx context.Context, campaign *entity.Campaign, from string) (bool, error)
+	ScheduleNext(ctx context.Context, id int, at time.Time) error
+	// AddFailure increments the consecutive failure count and returns it.
+	AddFailure(ctx context.Context, id int) (int, error)
+	ResetFailures(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*entity.Campaign, error)
+	GetByAgentID(ctx context.Context, agentID string, limit, offset int) ([]*entity.Campaign, error)
+	// ClaimDue locks running campaigns whose next send is due and pushes it
+	// out by lease, so concurrent workers skip them.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*entity.Campaign, error)
+
+	CountRecipientsByStatus(ctx context.Context, campaignID int) (map[string]int, error)
+	GetRecipients(ctx context.Context, campaignID int, status string, limit, offset int) ([]*entity.CampaignRecipient, error)
+	GetRecipient(ctx context.Context, id int) (*entity.CampaignRecipient, error)
+	GetRecipientByOutboundID(ctx context.Context, outboundID int) (*entity.CampaignRecipient, error)
+	// ClaimNextRecipient moves the campaign's oldest pending recipient to
+	// queued and returns it.
+	ClaimNextRecipient(ctx context.Context, campaignID int) (*entity.CampaignRecipient, error)
+	UpdateRecipient(ctx context.Context, recipient *entity.CampaignRecipient) error
+	// LinkRecipientOutbound sets only the recipient's outbound item, so it
+	// cannot overwrite an outcome recorded in the meantime.
+	LinkRecipientOutbound(ctx context.Context, id, outboundID int) error
+	// ReleaseOrphanedRecipients moves the campaign's recipients that were
+	// claimed before the given time but never reached the outbound queue
+	// back to pending, and returns how many it moved.
+	ReleaseOrphanedRecipients(ctx context.Context, campaignID int, claimedBefore time.Time) (int, error)
+	// ApplyReceipt advances sent recipients to delivered or read; it never
+	// moves a recipient backwards.
+	ApplyReceipt(ctx context.Context, agentID string, messageIDs []string, status string, at time.Time) error
+}
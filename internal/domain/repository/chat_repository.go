@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"whatsapp-api/internal/domain/entity"
+)
+
+// ChatFilter selects a session's chats, most recently active first. Empty
+// fields do not filter.
+type ChatFilter struct {
+	SessionID int
+	ChatType  string
+	Archived  *bool
+	Limit     int
+	Offset    int
+}
+
+type ChatRepository interface {
+	// RecordMessage creates or updates the chat a message belongs to and
+	// returns it. An incoming message raises the unread count and takes the
+	// chat out of the archive unless it is muted. An empty display name
+	// keeps the stored one, and an older message does not replace a newer
+	// last message.
+	RecordMessage(ctx context.Context, chat *entity.Chat, msg *entity.Message) (*entity.Chat, error)
+	SetDisplayName(ctx context.Context, id int, name string) error
+	// SetDisplayNameByJID renames the agent's chat with chatJID, e.g. after
+	// a group name change.
+	SetDisplayNameByJID(ctx context.Context, agentID, chatJID, name string) error
+	Update(ctx context.Context, chat *entity.Chat) error
+	GetByID(ctx context.Context, id int) (*entity.Chat, error)
+	Find(ctx context.Context, filter ChatFilter) ([]*entity.Chat, error)
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	// MissingMigrations names the migrations whose tables, columns or
+	// indexes are not in the database, oldest first.
+	MissingMigrations(ctx context.Context) ([]string, error)
+}
@@ -2,10 +2,25 @@ package repository
 
 import (
 	"context"
+	"time"
 	"whatsapp-api/internal/domain/entity"
 )
 
+// LangchainExecutionFilter selects part of a session's Langchain executions,
+// newest first. Empty fields do not filter.
+type LangchainExecutionFilter struct {
+	SessionID int
+	Status    string
+	Sender    string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Offset    int
+}
+
 type LangchainRepository interface {
 	Create(ctx context.Context, execution *entity.LangchainExecution) error
+	GetByID(ctx context.Context, id int) (*entity.LangchainExecution, error)
 	GetBySessionID(ctx context.Context, sessionID int, limit, offset int) ([]*entity.LangchainExecution, error)
+	Find(ctx context.Context, filter LangchainExecutionFilter) ([]*entity.LangchainExecution, error)
 }
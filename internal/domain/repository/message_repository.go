@@ -2,11 +2,60 @@ package repository
 
 import (
 	"context"
+	"time"
 	"whatsapp-api/internal/domain/entity"
 )
 
+// MessageFilter selects part of a session's message history, newest first.
+// Empty fields do not filter.
+type MessageFilter struct {
+	SessionID   int
+	Direction   string
+	MessageType string
+	// Contact matches the phone number on either side of the message.
+	Contact string
+	// Chat matches the chat JID, e.g. a group.
+	Chat   string
+	Status string
+	Since  time.Time
+	Until  time.Time
+	// BeforeTime and BeforeID continue a listing after the last row of the
+	// previous page.
+	BeforeTime time.Time
+	BeforeID   int
+	Limit      int
+}
+
+// MessageExportFilter selects a session's messages oldest first, one batch
+// at a time. Empty fields do not filter.
+type MessageExportFilter struct {
+	SessionID int
+	Chat      string
+	Since     time.Time
+	Until     time.Time
+	// AfterTime and AfterID continue after the last row of the previous
+	// batch.
+	AfterTime time.Time
+	AfterID   int
+	Limit     int
+}
+
 type MessageRepository interface {
 	Create(ctx context.Context, message *entity.Message) error
+	GetByID(ctx context.Context, id int) (*entity.Message, error)
 	GetBySessionID(ctx context.Context, sessionID int, limit, offset int) ([]*entity.Message, error)
+	Find(ctx context.Context, filter MessageFilter) ([]*entity.Message, error)
+	GetByMessageID(ctx context.Context, agentID, messageID string) (*entity.Message, error)
+	// GetByLangchainExecutionID returns the replies sent for a Langchain
+	// execution, oldest first.
+	GetByLangchainExecutionID(ctx context.Context, executionID int) ([]*entity.Message, error)
+	// Export returns a batch of messages for a transcript, each with its
+	// chat name and Langchain execution outcome.
+	Export(ctx context.Context, filter MessageExportFilter) ([]*entity.ExportedMessage, error)
 	CountByAgentAndDirection(ctx context.Context, agentID string, direction string) (int, error)
+	// ApplyReceipt records a delivered, read or played receipt on the agent's
+	// outgoing messages and returns the rows whose status advanced. Status
+	// never moves backwards; earlier timestamps are filled in when receipts
+	// arrive out of order.
+	ApplyReceipt(ctx context.Context, agentID string, messageIDs []string, status string, at time.Time) ([]*entity.Message, error)
 }
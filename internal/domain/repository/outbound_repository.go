@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+)
+
+type OutboundRepository interface {
+	Create(ctx context.Context, item *entity.OutboundMessage) error
+	Update(ctx context.Context, item *entity.OutboundMessage) error
+	GetByAgentID(ctx context.Context, agentID, status string, limit, offset int) ([]*entity.OutboundMessage, error)
+	// ClaimNext locks the agent's most urgent due item (lowest priority
+	// value, then oldest), counts the attempt and pushes send_after out by
+	// lease so it is retried if the sender dies mid-send. Claims are paced
+	// per agent across instances: after a claim the next one waits gap, and
+	// until then ClaimNext claims nothing and returns when to try again.
+	ClaimNext(ctx context.Context, agentID string, lease, gap time.Duration) (*entity.OutboundMessage, time.Time, error)
+	// GetAgentsWithDue lists agents that have queued items ready to send.
+	GetAgentsWithDue(ctx context.Context) ([]string, error)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+)
+
+// RetentionCutoffs are the times before which a session's data expires. A
+// zero time keeps that kind of data.
+type RetentionCutoffs struct {
+	Messages   time.Time
+	Executions time.Time
+	Media      time.Time
+}
+
+// PurgeCounts counts rows that expired or were purged.
+type PurgeCounts struct {
+	Messages   int `json:"messages" db:"messages"`
+	Executions int `json:"executions" db:"executions"`
+	Media      int `json:"media" db:"media"`
+}
+
+// ContactErasure counts what erasing a contact removed besides messages.
+type ContactErasure struct {
+	Executions         int `json:"executions"`
+	Chats              int `json:"chats"`
+	CampaignRecipients int `json:"campaignRecipients"`
+	Schedules          int `json:"schedules"`
+	QueuedMessages     int `json:"queuedMessages"`
+	WebhookDeliveries  int `json:"webhookDeliveries"`
+}
+
+type RetentionRepository interface {
+	// Upsert stores the policy for its user and session, replacing any
+	// existing one.
+	Upsert(ctx context.Context, policy *entity.RetentionPolicy) error
+	Delete(ctx context.Context, userID string, sessionID sql.NullInt64) error
+	GetByUserID(ctx context.Context, userID string) ([]*entity.RetentionPolicy, error)
+	// GetEffective returns the retention in force for each of the user's
+	// sessions, or for every session when userID is empty.
+	GetEffective(ctx context.Context, userID string) ([]*entity.SessionRetention, error)
+	CountExpired(ctx context.Context, sessionID int, cutoffs RetentionCutoffs) (*PurgeCounts, error)
+
+	// ExpiredMessages and ExpiredMedia page through a session's messages
+	// created before a cutoff, by ascending id after afterID; ExpiredMedia
+	// only returns messages that still have a stored attachment.
+	ExpiredMessages(ctx context.Context, sessionID int, before time.Time, afterID, limit int) ([]*entity.Message, error)
+	ExpiredMedia(ctx context.Context, sessionID int, before time.Time, afterID, limit int) ([]*entity.Message, error)
+	DeleteMessages(ctx context.Context, ids []int) (int, error)
+	// ClearMedia drops the stored attachment path from messages whose blobs
+	// were deleted.
+	ClearMedia(ctx context.Context, ids []int) error
+	// ClearChatPreviews drops the last message text of chats whose last
+	// message expired.
+	ClearChatPreviews(ctx context.Context, sessionID int, before time.Time) error
+	DeleteExpiredExecutions(ctx context.Context, sessionID int, before time.Time, limit int) (int, error)
+
+	// ContactMessages returns the messages sent to or from any of the
+	// contact's numbers in the sessions.
+	ContactMessages(ctx context.Context, sessionIDs []int, numbers []string) ([]*entity.Message, error)
+	// EraseContact deletes everything else stored about the contact's
+	// numbers in the sessions, in one transaction.
+	EraseContact(ctx context.Context, sessionIDs []int, numbers []string) (*ContactErasure, error)
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+)
+
+type ScheduleRepository interface {
+	Create(ctx context.Context, schedule *entity.ScheduledMessage) error
+	// Update stores the schedule if its status and next run are still the
+	// ones it was read with, so an edit cannot undo a firing that happened
+	// in between. It reports whether it stored anything.
+	Update(ctx context.Context, schedule *entity.ScheduledMessage, fromStatus string, fromNextRunAt sql.NullTime) (bool, error)
+	Delete(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*entity.ScheduledMessage, error)
+	GetByAgentID(ctx context.Context, agentID, status string, limit, offset int) ([]*entity.ScheduledMessage, error)
+	// ClaimDue locks up to limit active schedules that are due, skipping rows
+	// other instances hold. fire is called for each one in the same
+	// transaction: it advances the schedule and returns the run to record.
+	// Both are stored before the locks are released, so a schedule fires
+	// once per due time however many instances run.
+	ClaimDue(ctx context.Context, limit int, fire func(schedule *entity.ScheduledMessage) *entity.ScheduledMessageRun) error
+
+	GetRun(ctx context.Context, id int) (*entity.ScheduledMessageRun, error)
+	GetRuns(ctx context.Context, scheduleID, limit, offset int) ([]*entity.ScheduledMessageRun, error)
+	UpdateRun(ctx context.Context, run *entity.ScheduledMessageRun) error
+	// ClaimOrphanedRuns locks up to limit pending runs last updated before
+	// the given time that never reached the outbound queue, e.g. because the
+	// instance stopped between firing and queueing them. Their updated_at
+	// moves to now so other instances skip them while they are queued.
+	ClaimOrphanedRuns(ctx context.Context, updatedBefore time.Time, limit int) ([]*entity.ScheduledMessageRun, error)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+)
+
+// Snippets mark matched words with these control characters so callers can
+// escape the text before turning them into markup.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchStop  = "\x03"
+)
+
+// SearchFilter selects full-text matches in the given sessions, best first.
+type SearchFilter struct {
+	Query      string
+	SessionIDs []int
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+	Offset     int
+}
+
+type SearchRepository interface {
+	// EnsureLanguage switches the text search configuration, e.g.
+	// "indonesian", and rebuilds the search vectors batchSize rows at a
+	// time. It reports false when the language was already in use.
+	EnsureLanguage(ctx context.Context, language string, batchSize int) (bool, error)
+	Search(ctx context.Context, filter SearchFilter) ([]*entity.SearchHit, error)
+}
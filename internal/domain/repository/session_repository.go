@@ -2,14 +2,23 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"whatsapp-api/internal/domain/entity"
 )
 
+// ErrAgentIDTaken is returned by Create when a session, of any user,
+// already has the agent ID.
+var ErrAgentIDTaken = errors.New("agent id already in use")
+
 type SessionRepository interface {
 	Create(ctx context.Context, session *entity.Session) error
 	Update(ctx context.Context, session *entity.Session) error
 	Delete(ctx context.Context, agentID string) error
 	GetByAgentID(ctx context.Context, agentID string) (*entity.Session, error)
 	GetByUserIDAndAgentID(ctx context.Context, userID, agentID string) (*entity.Session, error)
+	GetByUserID(ctx context.Context, userID string) ([]*entity.Session, error)
 	GetAllSessions(ctx context.Context) ([]*entity.Session, error)
+	// RotateSecrets re-encrypts secret columns still in plaintext or sealed
+	// with a retired master key and returns how many sessions it updated.
+	RotateSecrets(ctx context.Context) (int, error)
 }
@@ -7,6 +7,6 @@ import (
 
 type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
-	GetByAPIKey(ctx context.Context, apiKey string) (*entity.User, error)
 	GetByID(ctx context.Context, id string) (*entity.User, error)
+	GetAll(ctx context.Context) ([]*entity.User, error)
 }
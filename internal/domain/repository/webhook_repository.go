@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *entity.Webhook) error
+	Delete(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*entity.Webhook, error)
+	GetByAgentID(ctx context.Context, agentID string) ([]*entity.Webhook, error)
+	// RotateSecrets re-encrypts secrets still in plaintext or sealed with a
+	// retired master key and returns how many webhooks it updated.
+	RotateSecrets(ctx context.Context) (int, error)
+
+	CreateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+	GetDeliveryByID(ctx context.Context, id int) (*entity.WebhookDelivery, error)
+	GetDeliveriesByWebhookID(ctx context.Context, webhookID int, limit, offset int) ([]*entity.WebhookDelivery, error)
+	// ClaimDueDeliveries locks pending deliveries whose retry time has passed
+	// and pushes their next attempt out by lease, so concurrent instances skip them.
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error)
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type analyticsRepository struct {
+	db *sqlx.DB
+}
+
+func NewAnalyticsRepository(db *sqlx.DB) repository.AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+func (r *analyticsRepository) Buckets(ctx context.Context, filter repository.AnalyticsFilter) ([]*entity.AnalyticsBucket, error) {
+	var buckets []*entity.AnalyticsBucket
+	// The contact of a message is the other side: the sender of incoming
+	// messages and the recipient of outgoing ones.
+	query := `WITH buckets AS (
+                SELECT generate_series(
+                         date_trunc($2::text, $3::timestamp),
+                         $4::timestamp - interval '1 microsecond',
+                         ('1 ' || $2::text)::interval) AS bucket_start
+              ), counts AS (
+                SELECT date_trunc($2::text, created_at) AS bucket_start,
+                       COUNT(*) FILTER (WHERE direction = 'incoming') AS incoming,
+                       COUNT(*) FILTER (WHERE direction = 'outgoing') AS outgoing,
+                       COUNT(DISTINCT CASE WHEN direction = 'incoming' THEN from_number ELSE to_number END) AS unique_contacts
+                FROM messages
+                WHERE session_id = $1 AND created_at >= $3 AND created_at < $4
+                GROUP BY 1
+              )
+              SELECT b.bucket_start,
+                     COALESCE(c.incoming, 0) AS incoming,
+                     COALESCE(c.outgoing, 0) AS outgoing,
+                     COALESCE(c.unique_contacts, 0) AS unique_contacts
+              FROM buckets b
+              LEFT JOIN counts c ON c.bucket_start = b.bucket_start
+              ORDER BY b.bucket_start`
+
+	err := r.db.SelectContext(ctx, &buckets, query, filter.SessionID, filter.Bucket, filter.Since, filter.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+func (r *analyticsRepository) Totals(ctx context.Context, filter repository.AnalyticsFilter) (*entity.AnalyticsTotals, error) {
+	var totals entity.AnalyticsTotals
+	// Messages stored before chats were recorded have no chat and count as
+	// direct.
+	query := `SELECT COUNT(*) FILTER (WHERE direction = 'incoming') AS incoming,
+                     COUNT(*) FILTER (WHERE direction = 'outgoing') AS outgoing,
+                     COUNT(DISTINCT CASE WHEN direction = 'incoming' THEN from_number ELSE to_number END) AS unique_contacts,
+                     COUNT(*) FILTER (WHERE metadata->>'chat' LIKE '%@g.us') AS group_messages,
+                     COUNT(*) FILTER (WHERE COALESCE(metadata->>'chat', '') NOT LIKE '%@g.us') AS direct_messages,
+                     COUNT(*) FILTER (WHERE direction = 'incoming' AND EXISTS (
+                       SELECT 1 FROM langchain_executions e
+                       JOIN messages r ON r.langchain_execution_id = e.id
+                       WHERE e.message_id = m.id)) AS answered
+              FROM messages m
+              WHERE session_id = $1 AND created_at >= $2 AND created_at < $3`
+
+	err := r.db.GetContext(ctx, &totals, query, filter.SessionID, filter.Since, filter.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	return &totals, nil
+}
+
+func (r *analyticsRepository) Langchain(ctx context.Context, filter repository.AnalyticsFilter) (*entity.LangchainAnalytics, error) {
+	var stats entity.LangchainAnalytics
+	query := `SELECT COUNT(*) AS executions,
+                     COUNT(*) FILTER (WHERE status = 'success') AS succeeded,
+                     COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+                     percentile_cont(0.5) WITHIN GROUP (ORDER BY execution_time_ms) AS p50_ms,
+                     percentile_cont(0.95) WITHIN GROUP (ORDER BY execution_time_ms) AS p95_ms,
+                     percentile_cont(0.99) WITHIN GROUP (ORDER BY execution_time_ms) AS p99_ms
+              FROM langchain_executions
+              WHERE session_id = $1 AND created_at >= $2 AND created_at < $3`
+
+	err := r.db.GetContext(ctx, &stats, query, filter.SessionID, filter.Since, filter.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
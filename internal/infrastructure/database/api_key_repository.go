@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) repository.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+const insertAPIKeyQuery = `INSERT INTO api_keys (user_id, name, prefix, key_hash, salt, scopes, agent_ids, created_at, updated_at)
+              VALUES (:user_id, :name, :prefix, :key_hash, :salt, :scopes, :agent_ids, :created_at, :updated_at)
+			  RETURNING id`
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	rows, err := r.db.NamedQueryContext(ctx, insertAPIKeyQuery, key)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return rows.Scan(&key.ID)
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id int) (*entity.APIKey, error) {
+	var key entity.APIKey
+	query := `SELECT * FROM api_keys WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &key, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetActiveByPrefix(ctx context.Context, prefix string) ([]*entity.APIKey, error) {
+	var keys []*entity.APIKey
+	query := `SELECT * FROM api_keys WHERE prefix = $1 AND revoked_at IS NULL`
+
+	err := r.db.SelectContext(ctx, &keys, query, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.APIKey, error) {
+	var keys []*entity.APIKey
+	query := `SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	err := r.db.SelectContext(ctx, &keys, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) CountActiveByUserID(ctx context.Context, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if err := r.db.GetContext(ctx, &count, query, userID); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $2, updated_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id, at)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) Rotate(ctx context.Context, oldID int, newKey *entity.APIKey, at time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE api_keys SET revoked_at = $2, updated_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+	result, err := tx.ExecContext(ctx, query, oldID, at)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	rows, err := tx.NamedQuery(insertAPIKeyQuery, newKey)
+	if err != nil {
+		return err
+	}
+	if rows.Next() {
+		err = rows.Scan(&newKey.ID)
+	}
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, at)
+	return err
+}
@@ -0,0 +1,291 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// recipientInsertBatch keeps bulk inserts well under Postgres' limit of
+// 65535 bind parameters.
+const recipientInsertBatch = 1000
+
+type campaignRepository struct {
+	db *sqlx.DB
+}
+
+func NewCampaignRepository(db *sqlx.DB) repository.CampaignRepository {
+	return &campaignRepository{db: db}
+}
+
+func (r *campaignRepository) Create(ctx context.Context, campaign *entity.Campaign, recipients []*entity.CampaignRecipient) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO campaigns (session_id, agent_id, name, template, status, consecutive_failures, created_at, updated_at)
+              VALUES (:session_id, :agent_id, :name, :template, :status, :consecutive_failures, :created_at, :updated_at)
+			  RETURNING id`
+	rows, err := tx.NamedQuery(query, campaign)
+	if err != nil {
+		return err
+	}
+	if rows.Next() {
+		err = rows.Scan(&campaign.ID)
+	}
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		recipient.CampaignID = campaign.ID
+	}
+	query = `INSERT INTO campaign_recipients (campaign_id, agent_id, phone, name, variables, status, created_at, updated_at)
+             VALUES (:campaign_id, :agent_id, :phone, :name, :variables, :status, :created_at, :updated_at)`
+	for start := 0; start < len(recipients); start += recipientInsertBatch {
+		end := start + recipientInsertBatch
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+		if _, err := tx.NamedExecContext(ctx, query, recipients[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *campaignRepository) Transition(ctx context.Context, campaign *entity.Campaign, from string) (bool, error) {
+	query := `UPDATE campaigns SET
+              status=:status, consecutive_failures=:consecutive_failures, stop_reason=:stop_reason, next_send_at=:next_send_at,
+              started_at=:started_at, finished_at=:finished_at, updated_at=:updated_at
+              WHERE id=:id AND status=:from`
+
+	arg := struct {
+		*entity.Campaign
+		From string `db:"from"`
+	}{campaign, from}
+	result, err := r.db.NamedExecContext(ctx, query, arg)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *campaignRepository) ScheduleNext(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE campaigns SET next_send_at = $2 WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, at)
+	return err
+}
+
+func (r *campaignRepository) AddFailure(ctx context.Context, id int) (int, error) {
+	var failures int
+	query := `UPDATE campaigns SET consecutive_failures = consecutive_failures + 1, updated_at = $2
+              WHERE id = $1 RETURNING consecutive_failures`
+
+	err := r.db.GetContext(ctx, &failures, query, id, time.Now())
+	return failures, err
+}
+
+func (r *campaignRepository) ResetFailures(ctx context.Context, id int) error {
+	query := `UPDATE campaigns SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures > 0`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *campaignRepository) GetByID(ctx context.Context, id int) (*entity.Campaign, error) {
+	var campaign entity.Campaign
+	query := `SELECT * FROM campaigns WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &campaign, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &campaign, nil
+}
+
+func (r *campaignRepository) GetByAgentID(ctx context.Context, agentID string, limit, offset int) ([]*entity.Campaign, error) {
+	var campaigns []*entity.Campaign
+	query := `SELECT * FROM campaigns WHERE agent_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	err := r.db.SelectContext(ctx, &campaigns, query, agentID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+func (r *campaignRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*entity.Campaign, error) {
+	var campaigns []*entity.Campaign
+	query := `UPDATE campaigns SET next_send_at = $2
+              WHERE id IN (
+                  SELECT id FROM campaigns
+                  WHERE status = 'running' AND (next_send_at IS NULL OR next_send_at <= $3)
+                  ORDER BY next_send_at NULLS FIRST
+                  LIMIT $1
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING *`
+
+	now := time.Now()
+	err := r.db.SelectContext(ctx, &campaigns, query, limit, now.Add(lease), now)
+	if err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+func (r *campaignRepository) CountRecipientsByStatus(ctx context.Context, campaignID int) (map[string]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	query := `SELECT status, COUNT(*) AS count FROM campaign_recipients WHERE campaign_id = $1 GROUP BY status`
+
+	if err := r.db.SelectContext(ctx, &rows, query, campaignID); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *campaignRepository) GetRecipients(ctx context.Context, campaignID int, status string, limit, offset int) ([]*entity.CampaignRecipient, error) {
+	var recipients []*entity.CampaignRecipient
+	query := `SELECT * FROM campaign_recipients WHERE campaign_id = $1 AND ($2 = '' OR status = $2)
+              ORDER BY id LIMIT $3 OFFSET $4`
+
+	err := r.db.SelectContext(ctx, &recipients, query, campaignID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+func (r *campaignRepository) GetRecipient(ctx context.Context, id int) (*entity.CampaignRecipient, error) {
+	var recipient entity.CampaignRecipient
+	query := `SELECT * FROM campaign_recipients WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &recipient, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &recipient, nil
+}
+
+func (r *campaignRepository) GetRecipientByOutboundID(ctx context.Context, outboundID int) (*entity.CampaignRecipient, error) {
+	var recipient entity.CampaignRecipient
+	query := `SELECT * FROM campaign_recipients WHERE outbound_id = $1`
+
+	err := r.db.GetContext(ctx, &recipient, query, outboundID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &recipient, nil
+}
+
+func (r *campaignRepository) ClaimNextRecipient(ctx context.Context, campaignID int) (*entity.CampaignRecipient, error) {
+	var recipient entity.CampaignRecipient
+	query := `UPDATE campaign_recipients SET status = 'queued', updated_at = $2
+              WHERE id = (
+                  SELECT id FROM campaign_recipients
+                  WHERE campaign_id = $1 AND status = 'pending'
+                  ORDER BY id
+                  LIMIT 1
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING *`
+
+	err := r.db.GetContext(ctx, &recipient, query, campaignID, time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &recipient, nil
+}
+
+func (r *campaignRepository) UpdateRecipient(ctx context.Context, recipient *entity.CampaignRecipient) error {
+	query := `UPDATE campaign_recipients SET
+              status=:status, outbound_id=:outbound_id, message_id=:message_id, error_message=:error_message,
+              sent_at=:sent_at, delivered_at=:delivered_at, read_at=:read_at, updated_at=:updated_at
+              WHERE id=:id`
+
+	_, err := r.db.NamedExecContext(ctx, query, recipient)
+	return err
+}
+
+func (r *campaignRepository) LinkRecipientOutbound(ctx context.Context, id, outboundID int) error {
+	query := `UPDATE campaign_recipients SET outbound_id = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, outboundID)
+	return err
+}
+
+func (r *campaignRepository) ReleaseOrphanedRecipients(ctx context.Context, campaignID int, claimedBefore time.Time) (int, error) {
+	// Outbound items name their recipient in metadata, which also finds
+	// items queued just before a crash that never got linked.
+	query := `UPDATE campaign_recipients r SET status = 'pending', updated_at = $3
+              WHERE r.campaign_id = $1 AND r.status = 'queued' AND r.outbound_id IS NULL AND r.updated_at < $2
+                AND NOT EXISTS (
+                    SELECT 1 FROM outbound_queue q
+                    WHERE q.agent_id = r.agent_id AND q.metadata->>'campaignRecipientId' = r.id::text
+                )`
+	result, err := r.db.ExecContext(ctx, query, campaignID, claimedBefore, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	released, err := result.RowsAffected()
+	return int(released), err
+}
+
+func (r *campaignRepository) ApplyReceipt(ctx context.Context, agentID string, messageIDs []string, status string, at time.Time) error {
+	var query string
+	switch status {
+	case "delivered":
+		query = `UPDATE campaign_recipients SET status = 'delivered', delivered_at = $3, updated_at = $3
+                 WHERE agent_id = $1 AND message_id = ANY($2) AND status = 'sent'`
+	case "read":
+		query = `UPDATE campaign_recipients SET status = 'read', read_at = $3,
+                 delivered_at = COALESCE(delivered_at, $3), updated_at = $3
+                 WHERE agent_id = $1 AND message_id = ANY($2) AND status IN ('sent', 'delivered')`
+	default:
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, query, agentID, messageIDs, at)
+	return err
+}
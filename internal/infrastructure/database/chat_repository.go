@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type chatRepository struct {
+	db *sqlx.DB
+}
+
+func NewChatRepository(db *sqlx.DB) repository.ChatRepository {
+	return &chatRepository{db: db}
+}
+
+func (r *chatRepository) RecordMessage(ctx context.Context, chat *entity.Chat, msg *entity.Message) (*entity.Chat, error) {
+	unread := 0
+	if msg.Direction.String == "incoming" {
+		unread = 1
+	}
+
+	var stored entity.Chat
+	query := `INSERT INTO chats (session_id, agent_id, chat_jid, chat_type, display_name, last_message_id, last_message_text,
+                                 last_message_type, last_message_direction, last_message_at, unread_count, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $12)
+              ON CONFLICT (session_id, chat_jid) DO UPDATE SET
+              display_name = COALESCE(EXCLUDED.display_name, chats.display_name),
+              last_message_id = CASE WHEN chats.last_message_at > EXCLUDED.last_message_at THEN chats.last_message_id ELSE EXCLUDED.last_message_id END,
+              last_message_text = CASE WHEN chats.last_message_at > EXCLUDED.last_message_at THEN chats.last_message_text ELSE EXCLUDED.last_message_text END,
+              last_message_type = CASE WHEN chats.last_message_at > EXCLUDED.last_message_at THEN chats.last_message_type ELSE EXCLUDED.last_message_type END,
+              last_message_direction = CASE WHEN chats.last_message_at > EXCLUDED.last_message_at THEN chats.last_message_direction ELSE EXCLUDED.last_message_direction END,
+              last_message_at = GREATEST(chats.last_message_at, EXCLUDED.last_message_at),
+              unread_count = chats.unread_count + EXCLUDED.unread_count,
+              archived = chats.archived AND (EXCLUDED.unread_count = 0 OR chats.muted),
+              updated_at = EXCLUDED.updated_at
+              RETURNING *`
+
+	err := r.db.GetContext(ctx, &stored, query,
+		chat.SessionID, chat.AgentID, chat.ChatJID, chat.ChatType, chat.DisplayName.String,
+		sql.NullInt64{Int64: int64(msg.ID), Valid: msg.ID != 0}, msg.MessageText, msg.MessageType, msg.Direction,
+		msg.CreatedAt, unread, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+func (r *chatRepository) SetDisplayName(ctx context.Context, id int, name string) error {
+	query := `UPDATE chats SET display_name = $2, updated_at = $3 WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, name, time.Now())
+	return err
+}
+
+func (r *chatRepository) SetDisplayNameByJID(ctx context.Context, agentID, chatJID, name string) error {
+	query := `UPDATE chats SET display_name = $3, updated_at = $4 WHERE agent_id = $1 AND chat_jid = $2`
+
+	_, err := r.db.ExecContext(ctx, query, agentID, chatJID, name, time.Now())
+	return err
+}
+
+func (r *chatRepository) Update(ctx context.Context, chat *entity.Chat) error {
+	query := `UPDATE chats SET unread_count=:unread_count, archived=:archived, muted=:muted, updated_at=:updated_at
+              WHERE id=:id`
+
+	_, err := r.db.NamedExecContext(ctx, query, chat)
+	return err
+}
+
+func (r *chatRepository) GetByID(ctx context.Context, id int) (*entity.Chat, error) {
+	var chat entity.Chat
+	query := `SELECT * FROM chats WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &chat, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &chat, nil
+}
+
+func (r *chatRepository) Find(ctx context.Context, filter repository.ChatFilter) ([]*entity.Chat, error) {
+	var chats []*entity.Chat
+	query := `SELECT * FROM chats
+              WHERE session_id = $1
+                AND ($2 = '' OR chat_type = $2)
+                AND ($3::boolean IS NULL OR archived = $3)
+              ORDER BY last_message_at DESC NULLS LAST, id DESC
+              LIMIT $4 OFFSET $5`
+
+	var archived sql.NullBool
+	if filter.Archived != nil {
+		archived = sql.NullBool{Bool: *filter.Archived, Valid: true}
+	}
+	err := r.db.SelectContext(ctx, &chats, query, filter.SessionID, filter.ChatType, archived, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return chats, nil
+}
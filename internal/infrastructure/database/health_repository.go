@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// schemaObject is something a migration creates, normally its last
+// statement, so a migration that stopped halfway also shows as missing.
+// Column is empty for tables and indexes; DataType, when set, is the type
+// the migration changes Column to.
+type schemaObject struct {
+	Migration string
+	Relation  string
+	Column    string
+	DataType  string
+}
+
+// schemaObjects has one entry per migration, in order; add one with every
+// migration.
+var schemaObjects = []schemaObject{
+	{Migration: "001_create_users_table", Relation: "users"},
+	{Migration: "002_create_sessions_table", Relation: "idx_sessions_status"},
+	{Migration: "003_create_messages_table", Relation: "idx_messages_created"},
+	{Migration: "004_create_langchain_executions_table", Relation: "idx_langchain_created"},
+	{Migration: "005_add_langchain_api_key_to_sessions", Relation: "sessions", Column: "langchain_api_key"},
+	{Migration: "006_link_outgoing_messages", Relation: "idx_messages_langchain_execution"},
+	{Migration: "007_create_webhooks_tables", Relation: "idx_webhook_deliveries_due"},
+	{Migration: "008_create_api_keys_table", Relation: "idx_api_keys_user"},
+	{Migration: "009_add_api_key_scopes", Relation: "api_keys", Column: "agent_ids"},
+	{Migration: "010_create_rate_limit_counters_table", Relation: "idx_rate_limit_counters_expires_at"},
+	{Migration: "011_create_outbound_queue_table", Relation: "idx_outbound_queue_agent"},
+	{Migration: "012_create_campaigns_tables", Relation: "idx_campaign_recipients_message"},
+	{Migration: "013_create_scheduled_messages_tables", Relation: "idx_scheduled_message_runs_schedule"},
+	{Migration: "014_add_message_receipts", Relation: "idx_messages_message_id"},
+	{Migration: "015_add_message_history_indexes", Relation: "idx_messages_session_chat"},
+	{Migration: "016_add_langchain_execution_links", Relation: "idx_langchain_message"},
+	{Migration: "017_create_chats_table", Relation: "idx_chats_session_recent"},
+	{Migration: "018_add_full_text_search", Relation: "idx_langchain_execution_search"},
+	{Migration: "019_create_retention_policies_table", Relation: "idx_messages_to_number"},
+	{Migration: "020_unique_session_agent_id", Relation: "idx_sessions_agent_id_unique"},
+	{Migration: "021_widen_webhook_secret", Relation: "webhooks", Column: "secret", DataType: "text"},
+	{Migration: "022_create_outbound_pacing_table", Relation: "outbound_pacing"},
+	{Migration: "023_add_pending_schedule_runs_index", Relation: "idx_scheduled_message_runs_pending"},
+}
+
+type healthRepository struct {
+	db *sqlx.DB
+}
+
+func NewHealthRepository(db *sqlx.DB) repository.HealthRepository {
+	return &healthRepository{db: db}
+}
+
+func (r *healthRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *healthRepository) MissingMigrations(ctx context.Context) ([]string, error) {
+	var missing []string
+	for _, obj := range schemaObjects {
+		var exists bool
+		var err error
+		if obj.Column == "" {
+			err = r.db.GetContext(ctx, &exists, `SELECT to_regclass($1) IS NOT NULL`, obj.Relation)
+		} else {
+			err = r.db.GetContext(ctx, &exists, `SELECT EXISTS (
+                SELECT 1 FROM information_schema.columns
+                WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+                  AND ($3 = '' OR data_type = $3))`,
+				obj.Relation, obj.Column, obj.DataType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, obj.Migration)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Every migration needs a readiness check, and the object it names must
+// really be created by that migration.
+func TestSchemaObjectsCoverMigrations(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	if len(files) != len(schemaObjects) {
+		t.Fatalf("%d migrations but %d schema objects", len(files), len(schemaObjects))
+	}
+
+	for i, file := range files {
+		obj := schemaObjects[i]
+		if name := strings.TrimSuffix(filepath.Base(file), ".up.sql"); name != obj.Migration {
+			t.Errorf("schema object %d is for %s, want %s", i, obj.Migration, name)
+			continue
+		}
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := obj.Relation
+		if obj.Column != "" {
+			want = obj.Column
+		}
+		if !strings.Contains(string(sql), want) {
+			t.Errorf("%s does not mention %s", obj.Migration, want)
+		}
+	}
+}
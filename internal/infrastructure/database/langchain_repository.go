@@ -2,6 +2,8 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"whatsapp-api/internal/domain/entity"
 	"whatsapp-api/internal/domain/repository"
 
@@ -17,8 +19,8 @@ func NewLangchainRepository(db *sqlx.DB) repository.LangchainRepository {
 }
 
 func (r *langchainRepository) Create(ctx context.Context, execution *entity.LangchainExecution) error {
-	query := `INSERT INTO langchain_executions (session_id, agent_id, user_message, langchain_response, execution_time_ms, status, error_message, created_at) 
-              VALUES (:session_id, :agent_id, :user_message, :langchain_response, :execution_time_ms, :status, :error_message, :created_at)
+	query := `INSERT INTO langchain_executions (session_id, agent_id, user_message, sender, message_id, replay_of, langchain_response, execution_time_ms, status, error_message, created_at) 
+              VALUES (:session_id, :agent_id, :user_message, :sender, :message_id, :replay_of, :langchain_response, :execution_time_ms, :status, :error_message, :created_at)
 			  RETURNING id`
 
 	rows, err := r.db.NamedQueryContext(ctx, query, execution)
@@ -33,6 +35,21 @@ func (r *langchainRepository) Create(ctx context.Context, execution *entity.Lang
 	return nil
 }
 
+func (r *langchainRepository) GetByID(ctx context.Context, id int) (*entity.LangchainExecution, error) {
+	var execution entity.LangchainExecution
+	query := `SELECT * FROM langchain_executions WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &execution, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &execution, nil
+}
+
 func (r *langchainRepository) GetBySessionID(ctx context.Context, sessionID int, limit, offset int) ([]*entity.LangchainExecution, error) {
 	var executions []*entity.LangchainExecution
 	query := `SELECT * FROM langchain_executions WHERE session_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
@@ -44,3 +61,23 @@ func (r *langchainRepository) GetBySessionID(ctx context.Context, sessionID int,
 
 	return executions, nil
 }
+
+func (r *langchainRepository) Find(ctx context.Context, filter repository.LangchainExecutionFilter) ([]*entity.LangchainExecution, error) {
+	var executions []*entity.LangchainExecution
+	query := `SELECT * FROM langchain_executions
+              WHERE session_id = $1
+                AND ($2 = '' OR status = $2)
+                AND ($3 = '' OR sender = $3)
+                AND ($4::timestamp IS NULL OR created_at >= $4)
+                AND ($5::timestamp IS NULL OR created_at < $5)
+              ORDER BY created_at DESC, id DESC
+              LIMIT $6 OFFSET $7`
+
+	err := r.db.SelectContext(ctx, &executions, query,
+		filter.SessionID, filter.Status, filter.Sender, nullTime(filter.Since), nullTime(filter.Until), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return executions, nil
+}
@@ -2,6 +2,9 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 	"whatsapp-api/internal/domain/entity"
 	"whatsapp-api/internal/domain/repository"
 
@@ -17,8 +20,8 @@ func NewMessageRepository(db *sqlx.DB) repository.MessageRepository {
 }
 
 func (r *messageRepository) Create(ctx context.Context, message *entity.Message) error {
-	query := `INSERT INTO messages (session_id, agent_id, message_id, from_number, to_number, message_text, message_type, direction, status, metadata, created_at) 
-              VALUES (:session_id, :agent_id, :message_id, :from_number, :to_number, :message_text, :message_type, :direction, :status, :metadata, :created_at)
+	query := `INSERT INTO messages (session_id, agent_id, message_id, from_number, to_number, message_text, message_type, direction, status, metadata, reply_to_id, langchain_execution_id, created_at) 
+              VALUES (:session_id, :agent_id, :message_id, :from_number, :to_number, :message_text, :message_type, :direction, :status, :metadata, :reply_to_id, :langchain_execution_id, :created_at)
 			  RETURNING id`
 
 	rows, err := r.db.NamedQueryContext(ctx, query, message)
@@ -33,6 +36,21 @@ func (r *messageRepository) Create(ctx context.Context, message *entity.Message)
 	return nil
 }
 
+func (r *messageRepository) GetByID(ctx context.Context, id int) (*entity.Message, error) {
+	var message entity.Message
+	query := `SELECT * FROM messages WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &message, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &message, nil
+}
+
 func (r *messageRepository) GetBySessionID(ctx context.Context, sessionID int, limit, offset int) ([]*entity.Message, error) {
 	var messages []*entity.Message
 	query := `SELECT * FROM messages WHERE session_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
@@ -45,9 +63,123 @@ func (r *messageRepository) GetBySessionID(ctx context.Context, sessionID int, l
 	return messages, nil
 }
 
+func (r *messageRepository) Find(ctx context.Context, filter repository.MessageFilter) ([]*entity.Message, error) {
+	var messages []*entity.Message
+	query := `SELECT * FROM messages
+              WHERE session_id = $1
+                AND ($2 = '' OR direction = $2)
+                AND ($3 = '' OR message_type = $3)
+                AND ($4 = '' OR from_number = $4 OR to_number = $4)
+                AND ($5 = '' OR metadata->>'chat' = $5)
+                AND ($6 = '' OR status = $6)
+                AND ($7::timestamp IS NULL OR created_at >= $7)
+                AND ($8::timestamp IS NULL OR created_at < $8)
+                AND ($9::timestamp IS NULL OR (created_at, id) < ($9, $10))
+              ORDER BY created_at DESC, id DESC
+              LIMIT $11`
+
+	err := r.db.SelectContext(ctx, &messages, query,
+		filter.SessionID, filter.Direction, filter.MessageType, filter.Contact, filter.Chat, filter.Status,
+		nullTime(filter.Since), nullTime(filter.Until), nullTime(filter.BeforeTime), filter.BeforeID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *messageRepository) GetByMessageID(ctx context.Context, agentID, messageID string) (*entity.Message, error) {
+	var message entity.Message
+	query := `SELECT * FROM messages WHERE agent_id = $1 AND message_id = $2 ORDER BY created_at DESC LIMIT 1`
+
+	err := r.db.GetContext(ctx, &message, query, agentID, messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (r *messageRepository) GetByLangchainExecutionID(ctx context.Context, executionID int) ([]*entity.Message, error) {
+	var messages []*entity.Message
+	query := `SELECT * FROM messages WHERE langchain_execution_id = $1 ORDER BY created_at, id`
+
+	err := r.db.SelectContext(ctx, &messages, query, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *messageRepository) Export(ctx context.Context, filter repository.MessageExportFilter) ([]*entity.ExportedMessage, error) {
+	var messages []*entity.ExportedMessage
+	query := `SELECT m.*,
+                     c.display_name AS chat_name,
+                     e.status AS execution_status,
+                     e.execution_time_ms,
+                     e.error_message AS execution_error,
+                     e.replay_of AS execution_replay_of
+              FROM messages m
+              LEFT JOIN chats c ON c.session_id = m.session_id AND c.chat_jid = m.metadata->>'chat'
+              LEFT JOIN langchain_executions e ON e.id = m.langchain_execution_id
+              WHERE m.session_id = $1
+                AND ($2 = '' OR m.metadata->>'chat' = $2)
+                AND ($3::timestamp IS NULL OR m.created_at >= $3)
+                AND ($4::timestamp IS NULL OR m.created_at < $4)
+                AND ($5::timestamp IS NULL OR (m.created_at, m.id) > ($5, $6))
+              ORDER BY m.created_at, m.id
+              LIMIT $7`
+
+	err := r.db.SelectContext(ctx, &messages, query,
+		filter.SessionID, filter.Chat, nullTime(filter.Since), nullTime(filter.Until),
+		nullTime(filter.AfterTime), filter.AfterID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
 func (r *messageRepository) CountByAgentAndDirection(ctx context.Context, agentID string, direction string) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM messages WHERE agent_id = $1 AND direction = $2`
 	err := r.db.GetContext(ctx, &count, query, agentID, direction)
 	return count, err
 }
+
+// receiptRanks orders outgoing message states; a receipt only moves a message
+// to a higher rank.
+var receiptRanks = map[string]int{"sent": 1, "delivered": 2, "read": 3, "played": 4}
+
+func (r *messageRepository) ApplyReceipt(ctx context.Context, agentID string, messageIDs []string, status string, at time.Time) ([]*entity.Message, error) {
+	rank, ok := receiptRanks[status]
+	if !ok || len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	var messages []*entity.Message
+	query := `UPDATE messages SET
+              status = $3,
+              delivered_at = COALESCE(delivered_at, $5),
+              read_at = CASE WHEN $4 >= 3 THEN COALESCE(read_at, $5) ELSE read_at END,
+              played_at = CASE WHEN $4 >= 4 THEN COALESCE(played_at, $5) ELSE played_at END
+              WHERE agent_id = $1 AND message_id = ANY($2) AND direction = 'outgoing'
+                AND CASE status WHEN 'sent' THEN 1 WHEN 'delivered' THEN 2 WHEN 'read' THEN 3 WHEN 'played' THEN 4 ELSE 0 END < $4
+              RETURNING *`
+
+	err := r.db.SelectContext(ctx, &messages, query, agentID, messageIDs, status, rank, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// nullTime maps the zero time to NULL for optional query bounds.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type outboundRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboundRepository(db *sqlx.DB) repository.OutboundRepository {
+	return &outboundRepository{db: db}
+}
+
+func (r *outboundRepository) Create(ctx context.Context, item *entity.OutboundMessage) error {
+	query := `INSERT INTO outbound_queue (session_id, agent_id, priority, recipient, payload, message_text, message_type, metadata, reply_to_id, langchain_execution_id, status, attempts, send_after, created_at, updated_at)
+              VALUES (:session_id, :agent_id, :priority, :recipient, :payload, :message_text, :message_type, :metadata, :reply_to_id, :langchain_execution_id, :status, :attempts, :send_after, :created_at, :updated_at)
+			  RETURNING id`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, item)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return rows.Scan(&item.ID)
+	}
+	return nil
+}
+
+func (r *outboundRepository) Update(ctx context.Context, item *entity.OutboundMessage) error {
+	query := `UPDATE outbound_queue SET
+              status=:status, attempts=:attempts, error_message=:error_message, message_row_id=:message_row_id,
+              send_after=:send_after, sent_at=:sent_at, updated_at=:updated_at
+              WHERE id=:id`
+
+	_, err := r.db.NamedExecContext(ctx, query, item)
+	return err
+}
+
+func (r *outboundRepository) GetByAgentID(ctx context.Context, agentID, status string, limit, offset int) ([]*entity.OutboundMessage, error) {
+	var items []*entity.OutboundMessage
+	query := `SELECT q.*, m.status AS message_status, m.delivered_at, m.read_at, m.played_at
+              FROM outbound_queue q
+              LEFT JOIN messages m ON m.id = q.message_row_id
+              WHERE q.agent_id = $1 AND ($2 = '' OR q.status = $2)
+              ORDER BY q.created_at DESC LIMIT $3 OFFSET $4`
+
+	err := r.db.SelectContext(ctx, &items, query, agentID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *outboundRepository) ClaimNext(ctx context.Context, agentID string, lease, gap time.Duration) (*entity.OutboundMessage, time.Time, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	// The pacing row lock serializes claims for the agent until commit.
+	now := time.Now()
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbound_pacing (agent_id, next_send_at) VALUES ($1, $2) ON CONFLICT (agent_id) DO NOTHING`,
+		agentID, now)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	// next_send_at holds the server's local wall clock, which pgx reads back
+	// labelled UTC, so the wait is worked out in SQL against now written the
+	// same way.
+	var waitMs int64
+	err = tx.GetContext(ctx, &waitMs,
+		`SELECT (EXTRACT(EPOCH FROM next_send_at - $2::TIMESTAMP) * 1000)::BIGINT FROM outbound_pacing WHERE agent_id = $1 FOR UPDATE`,
+		agentID, now)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if waitMs > 0 {
+		return nil, now.Add(time.Duration(waitMs) * time.Millisecond), nil
+	}
+
+	var item entity.OutboundMessage
+	query := `UPDATE outbound_queue SET send_after = $2, attempts = attempts + 1
+              WHERE id = (
+                  SELECT id FROM outbound_queue
+                  WHERE agent_id = $1 AND status = 'queued' AND send_after <= $3
+                  ORDER BY priority, id
+                  LIMIT 1
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING *`
+	err = tx.GetContext(ctx, &item, query, agentID, now.Add(lease), now)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, time.Time{}, nil
+		}
+		return nil, time.Time{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE outbound_pacing SET next_send_at = $2 WHERE agent_id = $1`, agentID, now.Add(gap))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, time.Time{}, err
+	}
+	return &item, time.Time{}, nil
+}
+
+func (r *outboundRepository) GetAgentsWithDue(ctx context.Context) ([]string, error) {
+	var agentIDs []string
+	query := `SELECT DISTINCT agent_id FROM outbound_queue WHERE status = 'queued' AND send_after <= $1`
+
+	err := r.db.SelectContext(ctx, &agentIDs, query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return agentIDs, nil
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// storedTimestamp returns the microseconds Postgres stores when pgx writes t
+// to a TIMESTAMP column or parameter.
+func storedTimestamp(t *testing.T, m *pgtype.Map, at time.Time) int64 {
+	t.Helper()
+	buf, err := m.Encode(pgtype.TimestampOID, pgtype.BinaryFormatCode, at, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int64(binary.BigEndian.Uint64(buf))
+}
+
+// ClaimNext compares next_send_at with now in SQL. Both sides are written by
+// pgx in the server's local wall clock, so their difference is the real gap
+// whatever time.Local is, while the column read back into Go is not.
+func TestPacingWaitIgnoresLocalZone(t *testing.T) {
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+
+	const gap = 3 * time.Second
+	for _, name := range []string{"UTC", "Asia/Jakarta", "America/New_York"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Skipf("time zone %s not available: %v", name, err)
+		}
+		time.Local = loc
+		m := pgtype.NewMap()
+
+		now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+		next := now.Add(gap)
+		wait := time.Duration(storedTimestamp(t, m, next)-storedTimestamp(t, m, now)) * time.Microsecond
+		if wait != gap {
+			t.Errorf("%s: next_send_at - now = %s, want %s", name, wait, gap)
+		}
+
+		// Reading the column back and comparing in Go is off by the offset.
+		buf, _ := m.Encode(pgtype.TimestampOID, pgtype.BinaryFormatCode, next, nil)
+		var read time.Time
+		if err := m.Scan(pgtype.TimestampOID, pgtype.BinaryFormatCode, buf, &read); err != nil {
+			t.Fatal(err)
+		}
+		_, offset := now.Zone()
+		if got, want := read.Sub(now), gap+time.Duration(offset)*time.Second; got != want {
+			t.Errorf("%s: next_send_at read back is %s after now, want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type retentionRepository struct {
+	db *sqlx.DB
+}
+
+func NewRetentionRepository(db *sqlx.DB) repository.RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+func (r *retentionRepository) Upsert(ctx context.Context, policy *entity.RetentionPolicy) error {
+	query := `INSERT INTO retention_policies (user_id, session_id, message_days, execution_days, media_days, created_at, updated_at)
+              VALUES (:user_id, :session_id, :message_days, :execution_days, :media_days, :created_at, :updated_at)
+              ON CONFLICT (user_id, (COALESCE(session_id, 0))) DO UPDATE SET
+              message_days = EXCLUDED.message_days,
+              execution_days = EXCLUDED.execution_days,
+              media_days = EXCLUDED.media_days,
+              updated_at = EXCLUDED.updated_at
+              RETURNING id, created_at`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, policy)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return rows.Scan(&policy.ID, &policy.CreatedAt)
+	}
+	return nil
+}
+
+func (r *retentionRepository) Delete(ctx context.Context, userID string, sessionID sql.NullInt64) error {
+	query := `DELETE FROM retention_policies WHERE user_id = $1 AND COALESCE(session_id, 0) = $2`
+
+	result, err := r.db.ExecContext(ctx, query, userID, sessionID.Int64)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *retentionRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.RetentionPolicy, error) {
+	var policies []*entity.RetentionPolicy
+	query := `SELECT p.*, s.agent_id FROM retention_policies p
+              LEFT JOIN sessions s ON s.id = p.session_id
+              WHERE p.user_id = $1
+              ORDER BY p.session_id NULLS FIRST, s.agent_id`
+
+	err := r.db.SelectContext(ctx, &policies, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (r *retentionRepository) GetEffective(ctx context.Context, userID string) ([]*entity.SessionRetention, error) {
+	var retention []*entity.SessionRetention
+	query := `SELECT s.id AS session_id, s.agent_id, s.user_id,
+                     COALESCE(sp.message_days, up.message_days, 0) AS message_days,
+                     COALESCE(sp.execution_days, up.execution_days, 0) AS execution_days,
+                     COALESCE(sp.media_days, up.media_days, 0) AS media_days
+              FROM sessions s
+              LEFT JOIN retention_policies up ON up.user_id = s.user_id AND up.session_id IS NULL
+              LEFT JOIN retention_policies sp ON sp.session_id = s.id
+              WHERE $1 = '' OR s.user_id = $1
+              ORDER BY s.id`
+
+	err := r.db.SelectContext(ctx, &retention, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return retention, nil
+}
+
+func (r *retentionRepository) CountExpired(ctx context.Context, sessionID int, cutoffs repository.RetentionCutoffs) (*repository.PurgeCounts, error) {
+	var counts repository.PurgeCounts
+	query := `SELECT
+              (SELECT COUNT(*) FROM messages WHERE session_id = $1 AND $2::timestamp IS NOT NULL AND created_at < $2) AS messages,
+              (SELECT COUNT(*) FROM langchain_executions WHERE session_id = $1 AND $3::timestamp IS NOT NULL AND created_at < $3) AS executions,
+              (SELECT COUNT(*) FROM messages WHERE session_id = $1 AND $4::timestamp IS NOT NULL AND created_at < $4
+                  AND metadata ? 'path') AS media`
+
+	err := r.db.GetContext(ctx, &counts, query, sessionID,
+		nullTime(cutoffs.Messages), nullTime(cutoffs.Executions), nullTime(cutoffs.Media))
+	if err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+func (r *retentionRepository) ExpiredMessages(ctx context.Context, sessionID int, before time.Time, afterID, limit int) ([]*entity.Message, error) {
+	var messages []*entity.Message
+	query := `SELECT * FROM messages WHERE session_id = $1 AND created_at < $2 AND id > $3 ORDER BY id LIMIT $4`
+
+	err := r.db.SelectContext(ctx, &messages, query, sessionID, before, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *retentionRepository) ExpiredMedia(ctx context.Context, sessionID int, before time.Time, afterID, limit int) ([]*entity.Message, error) {
+	var messages []*entity.Message
+	query := `SELECT * FROM messages
+              WHERE session_id = $1 AND created_at < $2 AND id > $3 AND metadata ? 'path'
+              ORDER BY id LIMIT $4`
+
+	err := r.db.SelectContext(ctx, &messages, query, sessionID, before, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *retentionRepository) DeleteMessages(ctx context.Context, ids []int) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	query := `DELETE FROM messages WHERE id = ANY($1)`
+
+	result, err := r.db.ExecContext(ctx, query, ids)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
+
+func (r *retentionRepository) ClearMedia(ctx context.Context, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `UPDATE messages SET metadata = metadata - 'path' || '{"mediaPurged": true}' WHERE id = ANY($1)`
+
+	_, err := r.db.ExecContext(ctx, query, ids)
+	return err
+}
+
+func (r *retentionRepository) ClearChatPreviews(ctx context.Context, sessionID int, before time.Time) error {
+	query := `UPDATE chats SET last_message_text = NULL
+              WHERE session_id = $1 AND last_message_at < $2 AND last_message_text IS NOT NULL`
+
+	_, err := r.db.ExecContext(ctx, query, sessionID, before)
+	return err
+}
+
+func (r *retentionRepository) DeleteExpiredExecutions(ctx context.Context, sessionID int, before time.Time, limit int) (int, error) {
+	query := `DELETE FROM langchain_executions WHERE id IN (
+                  SELECT id FROM langchain_executions WHERE session_id = $1 AND created_at < $2 ORDER BY id LIMIT $3
+              )`
+
+	result, err := r.db.ExecContext(ctx, query, sessionID, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
+
+func (r *retentionRepository) ContactMessages(ctx context.Context, sessionIDs []int, numbers []string) ([]*entity.Message, error) {
+	var messages []*entity.Message
+	query := `SELECT * FROM messages
+              WHERE session_id = ANY($1) AND (from_number = ANY($2) OR to_number = ANY($2))
+              ORDER BY id`
+
+	err := r.db.SelectContext(ctx, &messages, query, sessionIDs, numbers)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *retentionRepository) EraseContact(ctx context.Context, sessionIDs []int, numbers []string) (*repository.ContactErasure, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var erased repository.ContactErasure
+	steps := []struct {
+		count *int
+		query string
+	}{
+		{&erased.Executions, `DELETE FROM langchain_executions WHERE session_id = ANY($1) AND sender = ANY($2)`},
+		{&erased.Chats, `DELETE FROM chats
+                         WHERE session_id = ANY($1) AND chat_type = 'direct' AND split_part(chat_jid, '@', 1) = ANY($2)`},
+		{&erased.CampaignRecipients, `DELETE FROM campaign_recipients cr USING campaigns c
+                                      WHERE c.id = cr.campaign_id AND c.session_id = ANY($1)
+                                        AND regexp_replace(split_part(cr.phone, '@', 1), '\D', '', 'g') = ANY($2)`},
+		{&erased.Schedules, `DELETE FROM scheduled_messages
+                             WHERE session_id = ANY($1) AND split_part(recipient, '@', 1) = ANY($2)`},
+		{&erased.QueuedMessages, `DELETE FROM outbound_queue
+                                  WHERE session_id = ANY($1) AND split_part(recipient, '@', 1) = ANY($2)`},
+		{&erased.WebhookDeliveries, `DELETE FROM webhook_deliveries d USING webhooks w
+                                     WHERE w.id = d.webhook_id AND w.session_id = ANY($1)
+                                       AND (d.payload->'data'->>'from' = ANY($2) OR d.payload->'data'->>'to' = ANY($2)
+                                            OR d.payload->'data'->>'sender' = ANY($2))`},
+	}
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.query, sessionIDs, numbers)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		*step.count = int(rows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &erased, nil
+}
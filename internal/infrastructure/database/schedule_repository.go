@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type scheduleRepository struct {
+	db *sqlx.DB
+}
+
+func NewScheduleRepository(db *sqlx.DB) repository.ScheduleRepository {
+	return &scheduleRepository{db: db}
+}
+
+func (r *scheduleRepository) Create(ctx context.Context, schedule *entity.ScheduledMessage) error {
+	query := `INSERT INTO scheduled_messages (session_id, agent_id, recipient, message_text, cron_expr, timezone, status, next_run_at, run_count, created_at, updated_at)
+              VALUES (:session_id, :agent_id, :recipient, :message_text, :cron_expr, :timezone, :status, :next_run_at, :run_count, :created_at, :updated_at)
+			  RETURNING id`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, schedule)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return rows.Scan(&schedule.ID)
+	}
+	return nil
+}
+
+func (r *scheduleRepository) Update(ctx context.Context, schedule *entity.ScheduledMessage, fromStatus string, fromNextRunAt sql.NullTime) (bool, error) {
+	query := `UPDATE scheduled_messages SET
+              recipient=$2, message_text=$3, cron_expr=$4, timezone=$5, status=$6,
+              next_run_at=$7, last_run_at=$8, run_count=$9, updated_at=$10
+              WHERE id=$1 AND status=$11 AND next_run_at IS NOT DISTINCT FROM $12`
+
+	result, err := r.db.ExecContext(ctx, query,
+		schedule.ID, schedule.Recipient, schedule.MessageText, schedule.CronExpr, schedule.Timezone, schedule.Status,
+		schedule.NextRunAt, schedule.LastRunAt, schedule.RunCount, schedule.UpdatedAt,
+		fromStatus, fromNextRunAt)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *scheduleRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM scheduled_messages WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *scheduleRepository) GetByID(ctx context.Context, id int) (*entity.ScheduledMessage, error) {
+	var schedule entity.ScheduledMessage
+	query := `SELECT * FROM scheduled_messages WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &schedule, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+func (r *scheduleRepository) GetByAgentID(ctx context.Context, agentID, status string, limit, offset int) ([]*entity.ScheduledMessage, error) {
+	var schedules []*entity.ScheduledMessage
+	query := `SELECT * FROM scheduled_messages WHERE agent_id = $1 AND ($2 = '' OR status = $2)
+              ORDER BY created_at DESC LIMIT $3 OFFSET $4`
+
+	err := r.db.SelectContext(ctx, &schedules, query, agentID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (r *scheduleRepository) ClaimDue(ctx context.Context, limit int, fire func(schedule *entity.ScheduledMessage) *entity.ScheduledMessageRun) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var schedules []*entity.ScheduledMessage
+	query := `SELECT * FROM scheduled_messages
+              WHERE status = 'active' AND next_run_at <= $1
+              ORDER BY next_run_at
+              LIMIT $2
+              FOR UPDATE SKIP LOCKED`
+	if err := tx.SelectContext(ctx, &schedules, query, time.Now(), limit); err != nil {
+		return err
+	}
+
+	insertRun := `INSERT INTO scheduled_message_runs (schedule_id, agent_id, scheduled_for, status, created_at, updated_at)
+                  VALUES (:schedule_id, :agent_id, :scheduled_for, :status, :created_at, :updated_at)
+                  RETURNING id`
+	updateSchedule := `UPDATE scheduled_messages SET
+                       status=:status, next_run_at=:next_run_at, last_run_at=:last_run_at, run_count=:run_count, updated_at=:updated_at
+                       WHERE id=:id`
+	for _, schedule := range schedules {
+		run := fire(schedule)
+		if run != nil {
+			rows, err := tx.NamedQuery(insertRun, run)
+			if err != nil {
+				return err
+			}
+			if rows.Next() {
+				err = rows.Scan(&run.ID)
+			}
+			rows.Close()
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := tx.NamedExecContext(ctx, updateSchedule, schedule); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *scheduleRepository) GetRun(ctx context.Context, id int) (*entity.ScheduledMessageRun, error) {
+	var run entity.ScheduledMessageRun
+	query := `SELECT * FROM scheduled_message_runs WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &run, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+func (r *scheduleRepository) GetRuns(ctx context.Context, scheduleID, limit, offset int) ([]*entity.ScheduledMessageRun, error) {
+	var runs []*entity.ScheduledMessageRun
+	query := `SELECT * FROM scheduled_message_runs WHERE schedule_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+
+	err := r.db.SelectContext(ctx, &runs, query, scheduleID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (r *scheduleRepository) UpdateRun(ctx context.Context, run *entity.ScheduledMessageRun) error {
+	query := `UPDATE scheduled_message_runs SET
+              status=:status, outbound_id=:outbound_id, message_row_id=:message_row_id, error_message=:error_message, updated_at=:updated_at
+              WHERE id=:id`
+
+	_, err := r.db.NamedExecContext(ctx, query, run)
+	return err
+}
+
+func (r *scheduleRepository) ClaimOrphanedRuns(ctx context.Context, updatedBefore time.Time, limit int) ([]*entity.ScheduledMessageRun, error) {
+	var runs []*entity.ScheduledMessageRun
+	// Outbound items name their run in metadata, which also finds items
+	// queued just before a crash whose run was never updated.
+	query := `UPDATE scheduled_message_runs SET updated_at = $3
+              WHERE id IN (
+                  SELECT r.id FROM scheduled_message_runs r
+                  WHERE r.status = 'pending' AND r.outbound_id IS NULL AND r.updated_at < $1
+                    AND NOT EXISTS (
+                        SELECT 1 FROM outbound_queue q
+                        WHERE q.agent_id = r.agent_id AND q.metadata->>'scheduleRunId' = r.id::text
+                    )
+                  ORDER BY r.id
+                  LIMIT $2
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING *`
+
+	err := r.db.SelectContext(ctx, &runs, query, updatedBefore, limit, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// headlineOptions shapes ts_headline snippets: up to two fragments around
+// the matches, with matches wrapped in the repository markers.
+const headlineOptions = "StartSel=" + repository.SnippetMatchStart + ", StopSel=" + repository.SnippetMatchStop +
+	`, MaxFragments=2, MaxWords=25, MinWords=8, FragmentDelimiter=" … "`
+
+type searchRepository struct {
+	db *sqlx.DB
+}
+
+func NewSearchRepository(db *sqlx.DB) repository.SearchRepository {
+	return &searchRepository{db: db}
+}
+
+func (r *searchRepository) EnsureLanguage(ctx context.Context, language string, batchSize int) (bool, error) {
+	var same bool
+	// The cast rejects unknown configurations.
+	if err := r.db.GetContext(ctx, &same, `SELECT language = $1::regconfig FROM search_settings WHERE id`, language); err != nil {
+		return false, err
+	}
+	if same {
+		return false, nil
+	}
+	if _, err := r.db.ExecContext(ctx, `UPDATE search_settings SET language = $1::regconfig WHERE id`, language); err != nil {
+		return false, err
+	}
+
+	reindex := []struct{ maxQuery, updateQuery string }{
+		{
+			`SELECT COALESCE(MAX(message_id), 0) FROM message_search`,
+			`UPDATE message_search s SET search_vector = to_tsvector(search_language(), COALESCE(m.message_text, ''))
+             FROM messages m
+             WHERE m.id = s.message_id AND s.message_id > $1 AND s.message_id <= $2`,
+		},
+		{
+			`SELECT COALESCE(MAX(execution_id), 0) FROM langchain_execution_search`,
+			`UPDATE langchain_execution_search s SET search_vector = to_tsvector(search_language(), COALESCE(e.user_message, ''))
+             FROM langchain_executions e
+             WHERE e.id = s.execution_id AND s.execution_id > $1 AND s.execution_id <= $2`,
+		},
+	}
+	for _, table := range reindex {
+		var maxID int
+		if err := r.db.GetContext(ctx, &maxID, table.maxQuery); err != nil {
+			return true, err
+		}
+		for from := 0; from < maxID; from += batchSize {
+			if _, err := r.db.ExecContext(ctx, table.updateQuery, from, from+batchSize); err != nil {
+				return true, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func (r *searchRepository) Search(ctx context.Context, filter repository.SearchFilter) ([]*entity.SearchHit, error) {
+	var hits []*entity.SearchHit
+	query := `WITH q AS (SELECT search_language() AS language, websearch_to_tsquery(search_language(), $1) AS query)
+              SELECT * FROM (
+                  SELECT 'message' AS kind, m.id, m.agent_id, ts_rank(s.search_vector, q.query) AS rank,
+                         ts_headline(q.language, COALESCE(m.message_text, ''), q.query, $7) AS snippet,
+                         m.direction, m.from_number AS sender, m.to_number AS recipient, m.metadata->>'chat' AS chat, m.created_at
+                  FROM q
+                  JOIN message_search s ON s.search_vector @@ q.query
+                  JOIN messages m ON m.id = s.message_id
+                  WHERE m.session_id = ANY($2)
+                    AND ($3::timestamp IS NULL OR m.created_at >= $3)
+                    AND ($4::timestamp IS NULL OR m.created_at < $4)
+                  UNION ALL
+                  SELECT 'langchain_execution', e.id, e.agent_id, ts_rank(s.search_vector, q.query),
+                         ts_headline(q.language, COALESCE(e.user_message, ''), q.query, $7),
+                         NULL, e.sender, NULL, NULL, e.created_at
+                  FROM q
+                  JOIN langchain_execution_search s ON s.search_vector @@ q.query
+                  JOIN langchain_executions e ON e.id = s.execution_id
+                  WHERE e.session_id = ANY($2)
+                    AND ($3::timestamp IS NULL OR e.created_at >= $3)
+                    AND ($4::timestamp IS NULL OR e.created_at < $4)
+              ) hits
+              ORDER BY rank DESC, created_at DESC, id DESC
+              LIMIT $5 OFFSET $6`
+
+	err := r.db.SelectContext(ctx, &hits, query,
+		filter.Query, filter.SessionIDs, nullTime(filter.Since), nullTime(filter.Until), filter.Limit, filter.Offset, headlineOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
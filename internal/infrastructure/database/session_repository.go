@@ -4,18 +4,25 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"log"
 	"whatsapp-api/internal/domain/entity"
 	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/internal/infrastructure/secret"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 )
 
+// sessionRepository seals secret columns (langchain_api_key) with the
+// envelope on write and opens them on read, so callers only see plaintext.
 type sessionRepository struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	secrets *secret.Envelope
 }
 
-func NewSessionRepository(db *sqlx.DB) repository.SessionRepository {
-	return &sessionRepository{db: db}
+func NewSessionRepository(db *sqlx.DB, secrets *secret.Envelope) repository.SessionRepository {
+	return &sessionRepository{db: db, secrets: secrets}
 }
 
 func (r *sessionRepository) Create(ctx context.Context, session *entity.Session) error {
@@ -23,18 +30,37 @@ func (r *sessionRepository) Create(ctx context.Context, session *entity.Session)
               VALUES (:user_id, :agent_id, :agent_name, :phone_number, :qr_code, :qr_code_base64, :session_data, :status, :langchain_url, :langchain_api_key, :last_qr_generated_at, :connected_at, :disconnected_at, :created_at, :updated_at)
 			  RETURNING id`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, session)
+	sealed, err := r.seal(session)
 	if err != nil {
 		return err
 	}
+	rows, err := r.db.NamedQueryContext(ctx, query, sealed)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return repository.ErrAgentIDTaken
+		}
+		return err
+	}
 	defer rows.Close()
 
 	if rows.Next() {
 		return rows.Scan(&session.ID)
 	}
+	if err := rows.Err(); isUniqueViolation(err) {
+		return repository.ErrAgentIDTaken
+	} else if err != nil {
+		return err
+	}
 	return nil
 }
 
+// isUniqueViolation reports whether err is a Postgres unique constraint
+// violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 func (r *sessionRepository) Update(ctx context.Context, session *entity.Session) error {
 	query := `UPDATE sessions SET 
               agent_name=:agent_name, phone_number=:phone_number, qr_code=:qr_code, qr_code_base64=:qr_code_base64, 
@@ -43,7 +69,11 @@ func (r *sessionRepository) Update(ctx context.Context, session *entity.Session)
               updated_at=:updated_at
               WHERE id=:id`
 
-	_, err := r.db.NamedExecContext(ctx, query, session)
+	sealed, err := r.seal(session)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.NamedExecContext(ctx, query, sealed)
 	return err
 }
 
@@ -71,7 +101,7 @@ func (r *sessionRepository) GetByAgentID(ctx context.Context, agentID string) (*
 		return nil, err
 	}
 
-	return &session, nil
+	return &session, r.open(&session)
 }
 
 func (r *sessionRepository) GetByUserIDAndAgentID(ctx context.Context, userID, agentID string) (*entity.Session, error) {
@@ -86,7 +116,19 @@ func (r *sessionRepository) GetByUserIDAndAgentID(ctx context.Context, userID, a
 		return nil, err
 	}
 
-	return &session, nil
+	return &session, r.open(&session)
+}
+
+func (r *sessionRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.Session, error) {
+	var sessions []*entity.Session
+	query := `SELECT * FROM sessions WHERE user_id = $1 ORDER BY created_at`
+
+	err := r.db.SelectContext(ctx, &sessions, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.openAll(sessions), nil
 }
 
 func (r *sessionRepository) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
@@ -98,5 +140,84 @@ func (r *sessionRepository) GetAllSessions(ctx context.Context) ([]*entity.Sessi
 		return nil, err
 	}
 
-	return sessions, nil
+	return r.openAll(sessions), nil
+}
+
+func (r *sessionRepository) RotateSecrets(ctx context.Context) (int, error) {
+	var rows []struct {
+		ID              int    `db:"id"`
+		LangchainAPIKey string `db:"langchain_api_key"`
+	}
+	query := `SELECT id, langchain_api_key FROM sessions WHERE langchain_api_key IS NOT NULL AND langchain_api_key <> ''`
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, row := range rows {
+		if !r.secrets.NeedsRotation(row.LangchainAPIKey) {
+			continue
+		}
+		plaintext, err := r.secrets.Decrypt(row.LangchainAPIKey)
+		if err != nil {
+			return rotated, fmt.Errorf("session %d: %w", row.ID, err)
+		}
+		sealed, err := r.secrets.Encrypt(plaintext)
+		if err != nil {
+			return rotated, fmt.Errorf("session %d: %w", row.ID, err)
+		}
+		// Skip rows changed since they were read; a rerun picks them up.
+		result, err := r.db.ExecContext(ctx,
+			`UPDATE sessions SET langchain_api_key = $1 WHERE id = $2 AND langchain_api_key = $3`,
+			sealed, row.ID, row.LangchainAPIKey)
+		if err != nil {
+			return rotated, err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+			rotated++
+		}
+	}
+	return rotated, nil
+}
+
+// seal returns a copy of session with its secret columns encrypted.
+func (r *sessionRepository) seal(session *entity.Session) (*entity.Session, error) {
+	sealed := *session
+	if session.LangchainAPIKey.Valid && session.LangchainAPIKey.String != "" {
+		value, err := r.secrets.Encrypt(session.LangchainAPIKey.String)
+		if err != nil {
+			return nil, err
+		}
+		sealed.LangchainAPIKey.String = value
+	}
+	return &sealed, nil
+}
+
+// openAll opens every session and drops, with a log line, the ones whose
+// secrets cannot be decrypted, so one bad row does not stop the others from
+// loading. They are dropped rather than returned without the key because
+// Update would then overwrite the stored ciphertext.
+func (r *sessionRepository) openAll(sessions []*entity.Session) []*entity.Session {
+	opened := sessions[:0]
+	for _, session := range sessions {
+		if err := r.open(session); err != nil {
+			log.Printf("sessions: skipping session %d: %v", session.ID, err)
+			continue
+		}
+		opened = append(opened, session)
+	}
+	return opened
+}
+
+// open decrypts the secret columns of session in place.
+func (r *sessionRepository) open(session *entity.Session) error {
+	if !session.LangchainAPIKey.Valid {
+		return nil
+	}
+	value, err := r.secrets.Decrypt(session.LangchainAPIKey.String)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt langchain API key of agent %s: %w", session.AgentID, err)
+	}
+	session.LangchainAPIKey.String = value
+	return nil
 }
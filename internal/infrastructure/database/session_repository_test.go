@@ -0,0 +1,42 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/infrastructure/secret"
+)
+
+// A session sealed under a key that is no longer configured is skipped
+// instead of failing the others.
+func TestOpenAllSkipsUndecryptableSessions(t *testing.T) {
+	old, err := secret.NewEnvelope("old", map[string][]byte{"old": bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := secret.NewEnvelope("new", map[string][]byte{"new": bytes.Repeat([]byte{2}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lost, _ := old.Encrypt("sk-lost")
+	kept, _ := current.Encrypt("sk-kept")
+
+	key := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	sessions := []*entity.Session{
+		{ID: 1, AgentID: "a1", LangchainAPIKey: key(kept)},
+		{ID: 2, AgentID: "a2", LangchainAPIKey: key(lost)},
+		{ID: 3, AgentID: "a3"},
+		{ID: 4, AgentID: "a4", LangchainAPIKey: key(kept + "tampered")},
+	}
+
+	r := &sessionRepository{secrets: current}
+	got := r.openAll(sessions)
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Fatalf("openAll kept %+v, want sessions 1 and 3", got)
+	}
+	if got[0].LangchainAPIKey.String != "sk-kept" {
+		t.Errorf("session 1 key = %q, want it decrypted", got[0].LangchainAPIKey.String)
+	}
+}
@@ -19,18 +19,18 @@ func NewUserRepository(db *sqlx.DB) repository.UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
-	query := `INSERT INTO users (user_id, api_key, created_at, updated_at) 
-              VALUES (:user_id, :api_key, :created_at, :updated_at)`
+	query := `INSERT INTO users (user_id, is_admin, created_at, updated_at) 
+              VALUES (:user_id, :is_admin, :created_at, :updated_at)`
 
 	_, err := r.db.NamedExecContext(ctx, query, user)
 	return err
 }
 
-func (r *userRepository) GetByAPIKey(ctx context.Context, apiKey string) (*entity.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
 	var user entity.User
-	query := `SELECT * FROM users WHERE api_key = $1`
+	query := `SELECT * FROM users WHERE user_id = $1`
 
-	err := r.db.GetContext(ctx, &user, query, apiKey)
+	err := r.db.GetContext(ctx, &user, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -41,17 +41,14 @@ func (r *userRepository) GetByAPIKey(ctx context.Context, apiKey string) (*entit
 	return &user, nil
 }
 
-func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
-	var user entity.User
-	query := `SELECT * FROM users WHERE user_id = $1`
+func (r *userRepository) GetAll(ctx context.Context) ([]*entity.User, error) {
+	var users []*entity.User
+	query := `SELECT * FROM users ORDER BY created_at`
 
-	err := r.db.GetContext(ctx, &user, query, id)
+	err := r.db.SelectContext(ctx, &users, query)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	return &user, nil
+	return users, nil
 }
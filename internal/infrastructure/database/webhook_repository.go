@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/internal/infrastructure/secret"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// webhookRepository seals webhook secrets with the envelope on write and
+// opens them on read, so callers only see plaintext.
+type webhookRepository struct {
+	db      *sqlx.DB
+	secrets *secret.Envelope
+}
+
+func NewWebhookRepository(db *sqlx.DB, secrets *secret.Envelope) repository.WebhookRepository {
+	return &webhookRepository{db: db, secrets: secrets}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *entity.Webhook) error {
+	query := `INSERT INTO webhooks (session_id, agent_id, url, secret, events, active, created_at, updated_at)
+              VALUES (:session_id, :agent_id, :url, :secret, :events, :active, :created_at, :updated_at)
+			  RETURNING id`
+
+	sealed := *webhook
+	value, err := r.secrets.Encrypt(webhook.Secret)
+	if err != nil {
+		return err
+	}
+	sealed.Secret = value
+	rows, err := r.db.NamedQueryContext(ctx, query, &sealed)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return rows.Scan(&webhook.ID)
+	}
+	return nil
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM webhooks WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id int) (*entity.Webhook, error) {
+	var webhook entity.Webhook
+	query := `SELECT * FROM webhooks WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &webhook, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.open(&webhook); err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (r *webhookRepository) GetByAgentID(ctx context.Context, agentID string) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	query := `SELECT * FROM webhooks WHERE agent_id = $1 ORDER BY id`
+
+	err := r.db.SelectContext(ctx, &webhooks, query, agentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		if err := r.open(webhook); err != nil {
+			return nil, err
+		}
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) RotateSecrets(ctx context.Context) (int, error) {
+	var rows []struct {
+		ID     int    `db:"id"`
+		Secret string `db:"secret"`
+	}
+	query := `SELECT id, secret FROM webhooks WHERE secret <> ''`
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, row := range rows {
+		if !r.secrets.NeedsRotation(row.Secret) {
+			continue
+		}
+		plaintext, err := r.secrets.Decrypt(row.Secret)
+		if err != nil {
+			return rotated, fmt.Errorf("webhook %d: %w", row.ID, err)
+		}
+		sealed, err := r.secrets.Encrypt(plaintext)
+		if err != nil {
+			return rotated, fmt.Errorf("webhook %d: %w", row.ID, err)
+		}
+		// Skip rows changed since they were read; a rerun picks them up.
+		result, err := r.db.ExecContext(ctx,
+			`UPDATE webhooks SET secret = $1 WHERE id = $2 AND secret = $3`,
+			sealed, row.ID, row.Secret)
+		if err != nil {
+			return rotated, err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+			rotated++
+		}
+	}
+	return rotated, nil
+}
+
+// open decrypts the secret of webhook in place.
+func (r *webhookRepository) open(webhook *entity.Webhook) error {
+	value, err := r.secrets.Decrypt(webhook.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret of webhook %d: %w", webhook.ID, err)
+	}
+	webhook.Secret = value
+	return nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (webhook_id, agent_id, event, payload, status, attempts, next_attempt_at, created_at, updated_at)
+              VALUES (:webhook_id, :agent_id, :event, :payload, :status, :attempts, :next_attempt_at, :created_at, :updated_at)
+			  RETURNING id`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, delivery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return rows.Scan(&delivery.ID)
+	}
+	return nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries SET
+              status=:status, attempts=:attempts, response_status=:response_status, response_body=:response_body,
+              error_message=:error_message, next_attempt_at=:next_attempt_at, delivered_at=:delivered_at, updated_at=:updated_at
+              WHERE id=:id`
+
+	_, err := r.db.NamedExecContext(ctx, query, delivery)
+	return err
+}
+
+func (r *webhookRepository) GetDeliveryByID(ctx context.Context, id int) (*entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	query := `SELECT * FROM webhook_deliveries WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &delivery, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetDeliveriesByWebhookID(ctx context.Context, webhookID int, limit, offset int) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+	query := `SELECT * FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	err := r.db.SelectContext(ctx, &deliveries, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $2
+              WHERE id IN (
+                  SELECT id FROM webhook_deliveries
+                  WHERE status = 'pending' AND next_attempt_at <= $3
+                  ORDER BY next_attempt_at
+                  LIMIT $1
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING *`
+
+	now := time.Now()
+	err := r.db.SelectContext(ctx, &deliveries, query, limit, now.Add(lease), now)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
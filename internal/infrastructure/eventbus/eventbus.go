@@ -0,0 +1,119 @@
+package eventbus
+
+import (
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before new events are dropped for it.
+const subscriberBuffer = 64
+
+type Event struct {
+	Type      string      `json:"event"`
+	AgentID   string      `json:"agentId"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// Bus fans session events out to in-process subscribers such as SSE and
+// WebSocket streams. Publishing never blocks on a subscriber.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+func New() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+type Subscription struct {
+	ch     chan Event
+	mu     sync.RWMutex
+	agents map[string]bool
+}
+
+// Events returns the channel the subscription's events are delivered on.
+// It is closed by Unsubscribe.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Add starts delivering events of the given agents.
+func (s *Subscription) Add(agentIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range agentIDs {
+		s.agents[id] = true
+	}
+}
+
+// Remove stops delivering events of the given agents.
+func (s *Subscription) Remove(agentIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range agentIDs {
+		delete(s.agents, id)
+	}
+}
+
+// AgentIDs lists the agents the subscription currently follows.
+func (s *Subscription) AgentIDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.agents))
+	for id := range s.agents {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *Subscription) follows(agentID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.agents[agentID]
+}
+
+// Subscribe registers a subscriber for the given agents. More agents can be
+// followed later with Add.
+func (b *Bus) Subscribe(agentIDs ...string) *Subscription {
+	sub := &Subscription{
+		ch:     make(chan Event, subscriberBuffer),
+		agents: make(map[string]bool, len(agentIDs)),
+	}
+	sub.Add(agentIDs...)
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
+
+func (b *Bus) Publish(evt Event) {
+	if evt.Timestamp.IsZero() {
+		evt.Timestamp = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.follows(evt.AgentID) {
+			continue
+		}
+		select {
+		case sub.ch <- evt:
+		default:
+			// Subscriber is not keeping up; drop rather than stall the session.
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+// cleanupInterval is how often expired counters are dropped from the store.
+const cleanupInterval = time.Minute
+
+// Rule allows Requests hits per Window.
+type Rule struct {
+	Requests int
+	Window   time.Duration
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the current window ends.
+	Reset time.Duration
+}
+
+// Limiter applies rules using a sliding window counter: hits of the previous
+// window are weighted by how much of it still overlaps the sliding window.
+// Rejected hits are counted too, so clients that keep retrying stay limited.
+type Limiter struct {
+	store Store
+}
+
+func New(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+func (l *Limiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	now := time.Now()
+	windowStart := now.Truncate(rule.Window)
+	current, previous, err := l.store.Incr(ctx, key, windowStart, rule.Window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	elapsed := now.Sub(windowStart)
+	weight := 1 - float64(elapsed)/float64(rule.Window)
+	count := current + int(math.Floor(float64(previous)*weight))
+
+	remaining := rule.Requests - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{
+		Allowed:   count <= rule.Requests,
+		Limit:     rule.Requests,
+		Remaining: remaining,
+		Reset:     rule.Window - elapsed,
+	}, nil
+}
+
+// StartCleanup periodically drops expired counters until ctx is done.
+func (l *Limiter) StartCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.store.DeleteExpired(ctx, time.Now()); err != nil {
+				log.Printf("ratelimit: failed to delete expired counters: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryCounter struct {
+	start    time.Time
+	window   time.Duration
+	current  int
+	previous int
+}
+
+// MemoryStore keeps counters in process; limits are per instance.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*memoryCounter
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*memoryCounter)}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	switch {
+	case !ok:
+		c = &memoryCounter{start: windowStart, window: window}
+		s.counters[key] = c
+	case c.start.Equal(windowStart):
+	case c.start.Add(window).Equal(windowStart):
+		c.previous, c.current = c.current, 0
+		c.start = windowStart
+	default:
+		c.previous, c.current = 0, 0
+		c.start = windowStart
+	}
+	c.window = window
+	c.current++
+	return c.current, c.previous, nil
+}
+
+func (s *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, c := range s.counters {
+		if now.Sub(c.start) >= 2*c.window {
+			delete(s.counters, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresStore keeps counters in the rate_limit_counters table so every
+// instance enforces the same limits.
+type PostgresStore struct {
+	db *sqlx.DB
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Incr(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, int, error) {
+	query := `WITH hit AS (
+                  INSERT INTO rate_limit_counters (key, window_start, count, expires_at)
+                  VALUES ($1, $2, 1, $3)
+                  ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limit_counters.count + 1
+                  RETURNING count
+              )
+              SELECT (SELECT count FROM hit),
+                     COALESCE((SELECT count FROM rate_limit_counters WHERE key = $1 AND window_start = $4), 0)`
+	var current, previous int
+	err := s.db.QueryRowxContext(ctx, query, key, windowStart, windowStart.Add(2*window), windowStart.Add(-window)).
+		Scan(&current, &previous)
+	if err != nil {
+		return 0, 0, err
+	}
+	return current, previous, nil
+}
+
+func (s *PostgresStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM rate_limit_counters WHERE expires_at <= $1`, now)
+	return err
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store counts hits per key in fixed windows. The Limiter combines the
+// current and previous window into a sliding window estimate.
+type Store interface {
+	// Incr records a hit for key in the window starting at windowStart and
+	// returns the hit counts of that window and of the one before it.
+	Incr(ctx context.Context, key string, windowStart time.Time, window time.Duration) (current, previous int, err error)
+	// DeleteExpired drops counters that can no longer affect a decision.
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// NewStore builds the store selected by security.rate_limit_store,
+// defaulting to memory. Only the postgres store shares limits between
+// instances.
+func NewStore(driver string, db *sqlx.DB) (Store, error) {
+	switch driver {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "postgres":
+		return NewPostgresStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", driver)
+	}
+}
@@ -0,0 +1,195 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"whatsapp-api/pkg/config"
+)
+
+// sealedPrefix marks values written by Envelope. Anything else is legacy
+// plaintext and is returned as is until it is rotated.
+const sealedPrefix = "enc:v1:"
+
+var ErrUnknownKey = errors.New("value is sealed with an unknown master key")
+
+// Envelope encrypts each value with its own random data key (AES-256-GCM)
+// and stores that data key wrapped by a master key. Sealed values name their
+// master key, so retired master keys can still decrypt while values are
+// rotated to the active one:
+//
+//	enc:v1:<key id>:<wrapped data key>:<ciphertext>
+//
+// An Envelope without an active key stores values in plaintext.
+type Envelope struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+// NewEnvelope builds an Envelope sealing with keys[activeID]. Every key must
+// be 32 bytes.
+func NewEnvelope(activeID string, keys map[string][]byte) (*Envelope, error) {
+	e := &Envelope{activeID: activeID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid master key id %q", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		e.keys[id] = aead
+	}
+	if activeID != "" && e.keys[activeID] == nil {
+		return nil, fmt.Errorf("active master key %q is not configured", activeID)
+	}
+	return e, nil
+}
+
+// NewEnvelopeFromConfig reads the active master key and the retired keys
+// still needed for decryption from the security config. Keys are base64.
+func NewEnvelopeFromConfig(cfg config.SecurityConfig) (*Envelope, error) {
+	keys := make(map[string][]byte)
+	activeID := ""
+	if cfg.EncryptionKey != "" {
+		activeID = cfg.EncryptionKeyID
+		if activeID == "" {
+			activeID = "default"
+		}
+		key, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("security.encryption_key is not valid base64: %w", err)
+		}
+		keys[activeID] = key
+	}
+	for _, entry := range strings.Split(cfg.EncryptionRetiredKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("security.encryption_retired_keys entry %q must be id:key", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("retired master key %q is not valid base64: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewEnvelope(activeID, keys)
+}
+
+// Enabled reports whether new values are encrypted.
+func (e *Envelope) Enabled() bool {
+	return e.activeID != ""
+}
+
+func (e *Envelope) Encrypt(plaintext string) (string, error) {
+	if !e.Enabled() {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(e.keys[e.activeID], dataKey)
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataAEAD, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return sealedPrefix + e.activeID + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (e *Envelope) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, sealedPrefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed sealed value")
+	}
+	master := e.keys[parts[0]]
+	if master == nil {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, parts[0])
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed sealed value: %w", err)
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed sealed value: %w", err)
+	}
+
+	dataKey, err := open(master, wrapped)
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether value should be re-encrypted: it is still
+// plaintext or sealed with a master key other than the active one.
+func (e *Envelope) NeedsRotation(value string) bool {
+	if !e.Enabled() || value == "" {
+		return false
+	}
+	return !strings.HasPrefix(value, sealedPrefix+e.activeID+":")
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns nonce || ciphertext.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed value too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt sealed value")
+	}
+	return plaintext, nil
+}
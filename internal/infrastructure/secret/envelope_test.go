@@ -0,0 +1,152 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestEnvelopeSealOpen(t *testing.T) {
+	e, err := NewEnvelope("k1", map[string][]byte{"k1": testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plaintext := range []string{"", "sk-test", strings.Repeat("x", 1000), "rahasia ☕"} {
+		sealed, err := e.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sealed, sealedPrefix+"k1:") {
+			t.Fatalf("sealed value %q does not name its key", sealed)
+		}
+		if plaintext != "" && strings.Contains(sealed, plaintext) {
+			t.Fatalf("sealed value %q contains the plaintext", sealed)
+		}
+		opened, err := e.Decrypt(sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", plaintext, opened)
+		}
+	}
+
+	a, _ := e.Encrypt("same")
+	b, _ := e.Encrypt("same")
+	if a == b {
+		t.Error("sealing the same value twice gave the same output")
+	}
+}
+
+func TestEnvelopeDisabled(t *testing.T) {
+	e, err := NewEnvelope("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := e.Encrypt("sk-test")
+	if err != nil || sealed != "sk-test" {
+		t.Fatalf("Encrypt = %q, %v; want the plaintext", sealed, err)
+	}
+	if e.NeedsRotation("sk-test") {
+		t.Error("a disabled envelope should not ask for rotation")
+	}
+}
+
+func TestEnvelopeLegacyPlaintext(t *testing.T) {
+	e, _ := NewEnvelope("k1", map[string][]byte{"k1": testKey(1)})
+	opened, err := e.Decrypt("sk-legacy")
+	if err != nil || opened != "sk-legacy" {
+		t.Fatalf("Decrypt = %q, %v; want the plaintext", opened, err)
+	}
+	if !e.NeedsRotation("sk-legacy") {
+		t.Error("plaintext should need rotation")
+	}
+	if e.NeedsRotation("") {
+		t.Error("an empty value should not need rotation")
+	}
+}
+
+func TestEnvelopeRotate(t *testing.T) {
+	old, _ := NewEnvelope("k1", map[string][]byte{"k1": testKey(1)})
+	sealed, err := old.Encrypt("sk-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := NewEnvelope("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rotated.NeedsRotation(sealed) {
+		t.Fatal("a value sealed with a retired key should need rotation")
+	}
+	plaintext, err := rotated.Decrypt(sealed)
+	if err != nil || plaintext != "sk-test" {
+		t.Fatalf("Decrypt with retired key = %q, %v", plaintext, err)
+	}
+	resealed, err := rotated.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.NeedsRotation(resealed) {
+		t.Error("a value sealed with the active key should not need rotation")
+	}
+
+	// Once the retired key is dropped, only the resealed value opens.
+	current, _ := NewEnvelope("k2", map[string][]byte{"k2": testKey(2)})
+	if _, err := current.Decrypt(sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt with dropped key: err = %v, want ErrUnknownKey", err)
+	}
+	if plaintext, err := current.Decrypt(resealed); err != nil || plaintext != "sk-test" {
+		t.Errorf("Decrypt after rotation = %q, %v", plaintext, err)
+	}
+}
+
+func TestEnvelopeRejectsTampering(t *testing.T) {
+	e, _ := NewEnvelope("k1", map[string][]byte{"k1": testKey(1)})
+	sealed, _ := e.Encrypt("sk-test")
+
+	tests := map[string]string{
+		"missing part":  strings.Join(strings.Split(sealed, ":")[:4], ":"),
+		"bad base64":    sealed + "!",
+		"flipped byte":  sealed[:len(sealed)-2] + flip(sealed[len(sealed)-2]) + sealed[len(sealed)-1:],
+		"wrong key":     strings.Replace(sealed, sealedPrefix+"k1:", sealedPrefix+"k9:", 1),
+		"too short":     sealedPrefix + "k1:AA:AA",
+		"empty payload": sealedPrefix + "k1::",
+	}
+	for name, value := range tests {
+		if _, err := e.Decrypt(value); err == nil {
+			t.Errorf("%s: Decrypt(%q) succeeded", name, value)
+		}
+	}
+}
+
+func flip(c byte) string {
+	if c == 'A' {
+		return "B"
+	}
+	return "A"
+}
+
+func TestNewEnvelopeValidatesKeys(t *testing.T) {
+	tests := map[string]struct {
+		activeID string
+		keys     map[string][]byte
+	}{
+		"short key":      {"k1", map[string][]byte{"k1": []byte("short")}},
+		"missing active": {"k2", map[string][]byte{"k1": testKey(1)}},
+		"colon in id":    {"k:1", map[string][]byte{"k:1": testKey(1)}},
+		"empty id":       {"k1", map[string][]byte{"k1": testKey(1), "": testKey(2)}},
+	}
+	for name, tt := range tests {
+		if _, err := NewEnvelope(tt.activeID, tt.keys); err == nil {
+			t.Errorf("%s: NewEnvelope succeeded", name)
+		}
+	}
+}
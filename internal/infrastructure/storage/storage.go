@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"whatsapp-api/pkg/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps binary objects (downloaded media) under slash-separated keys.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore builds the store selected by storage.driver, defaulting to the
+// local filesystem.
+func NewBlobStore(cfg config.StorageConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "", "local":
+		path := cfg.LocalPath
+		if path == "" {
+			path = "data/media"
+		}
+		return NewLocalStore(path)
+	case "s3":
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// maxResponseBody bounds how much of a receiver's response we keep for the
+// delivery log.
+const maxResponseBody = 4096
+
+type Client struct {
+	httpClient *http.Client
+}
+
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type DeliveryResult struct {
+	StatusCode int
+	Body       []byte
+	Duration   time.Duration
+}
+
+// Sign returns the value of the signature header for body: the hex encoded
+// HMAC-SHA256 of the raw request body keyed with the webhook secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver POSTs a signed JSON payload to url. A non-nil error means the
+// request did not complete; callers decide what status codes count as success.
+func (c *Client) Deliver(ctx context.Context, url, secret, event string, deliveryID int, body []byte) (*DeliveryResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "whatsapp-api-webhook/1.0")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(deliveryID))
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	return &DeliveryResult{
+		StatusCode: resp.StatusCode,
+		Body:       respBody,
+		Duration:   time.Since(start),
+	}, nil
+}
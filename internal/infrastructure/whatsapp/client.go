@@ -33,6 +33,12 @@ func NewClientManager(db *sqlx.DB) (*ClientManager, error) {
 	}, nil
 }
 
+// Ping checks that the device store can be read.
+func (m *ClientManager) Ping(ctx context.Context) error {
+	_, err := m.Container.GetAllDevices(ctx)
+	return err
+}
+
 func (m *ClientManager) NewClient() (*whatsmeow.Client, error) {
 	device := m.Container.NewDevice()
 	return whatsmeow.NewClient(device, waLog.Stdout("Client", "INFO", true)), nil
@@ -0,0 +1,51 @@
+package whatsapp
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+
+	// Decoders for the formats image.Decode should understand.
+	_ "image/gif"
+	_ "image/png"
+)
+
+const thumbnailMaxSide = 72
+
+// GenerateThumbnail decodes an image and returns a small JPEG preview together
+// with the original dimensions, which WhatsApp shows while the full image downloads.
+func GenerateThumbnail(data []byte) (thumb []byte, width, height int, err error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	bounds := src.Bounds()
+	width, height = bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, width, height, nil
+	}
+
+	tw, th := thumbnailMaxSide, thumbnailMaxSide
+	if width > height {
+		th = max(1, height*thumbnailMaxSide/width)
+	} else {
+		tw = max(1, width*thumbnailMaxSide/height)
+	}
+
+	// Nearest-neighbour scaling is plenty for a blurred preview.
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy := bounds.Min.Y + y*height/th
+		for x := 0; x < tw; x++ {
+			sx := bounds.Min.X + x*width/tw
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 60}); err != nil {
+		return nil, width, height, err
+	}
+	return buf.Bytes(), width, height, nil
+}
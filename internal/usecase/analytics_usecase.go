@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+)
+
+var ErrInvalidAnalytics = errors.New("invalid analytics query")
+
+const (
+	// analyticsDefaultPeriod is reported when no since is given.
+	analyticsDefaultPeriod = 30 * 24 * time.Hour
+	// analyticsMaxBuckets bounds the series a single request returns.
+	analyticsMaxBuckets = 1000
+)
+
+// analyticsBuckets are the supported bucket sizes, with their (longest)
+// length for bounding the series.
+var analyticsBuckets = map[string]time.Duration{
+	"hour":  time.Hour,
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 28 * 24 * time.Hour,
+}
+
+type AnalyticsUseCase struct {
+	sessionRepo   repository.SessionRepository
+	analyticsRepo repository.AnalyticsRepository
+}
+
+func NewAnalyticsUseCase(sessionRepo repository.SessionRepository, analyticsRepo repository.AnalyticsRepository) *AnalyticsUseCase {
+	return &AnalyticsUseCase{
+		sessionRepo:   sessionRepo,
+		analyticsRepo: analyticsRepo,
+	}
+}
+
+// AnalyticsInput selects a session's activity in [Since, Until), by default
+// the last 30 days in daily buckets.
+type AnalyticsInput struct {
+	Caller  Caller
+	AgentID string
+	Bucket  string
+	Since   time.Time
+	Until   time.Time
+}
+
+// SessionAnalytics is a session's message volume and Langchain performance
+// over a period. Rates are fractions between 0 and 1, and 0 when there is
+// nothing to divide.
+type SessionAnalytics struct {
+	Since     time.Time
+	Until     time.Time
+	Bucket    string
+	Buckets   []*entity.AnalyticsBucket
+	Totals    *entity.AnalyticsTotals
+	Langchain *entity.LangchainAnalytics
+	// ResponseRate is the share of incoming messages that got a bot reply.
+	ResponseRate float64
+	SuccessRate  float64
+	FailureRate  float64
+}
+
+// Get computes a session's analytics. Buckets follow the server's clock,
+// the same one messages are stored in; the first and last may be partial.
+func (uc *AnalyticsUseCase) Get(ctx context.Context, in AnalyticsInput) (*SessionAnalytics, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := in.Bucket
+	if bucket == "" {
+		bucket = "day"
+	}
+	size, ok := analyticsBuckets[bucket]
+	if !ok {
+		return nil, fmt.Errorf("%w: bucket must be hour, day, week or month", ErrInvalidAnalytics)
+	}
+
+	since, until := localRange(in.Since, in.Until)
+	if until.IsZero() {
+		until = time.Now()
+	}
+	if since.IsZero() {
+		since = until.Add(-analyticsDefaultPeriod)
+	}
+	if !since.Before(until) {
+		return nil, fmt.Errorf("%w: since must be before until", ErrInvalidAnalytics)
+	}
+	if until.Sub(since)/size > analyticsMaxBuckets {
+		return nil, fmt.Errorf("%w: more than %d %s buckets; use a larger bucket or a shorter period", ErrInvalidAnalytics, analyticsMaxBuckets, bucket)
+	}
+
+	filter := repository.AnalyticsFilter{
+		SessionID: session.ID,
+		Bucket:    bucket,
+		Since:     since,
+		Until:     until,
+	}
+	buckets, err := uc.analyticsRepo.Buckets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := uc.analyticsRepo.Totals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	langchain, err := uc.analyticsRepo.Langchain(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionAnalytics{
+		Since:        since,
+		Until:        until,
+		Bucket:       bucket,
+		Buckets:      buckets,
+		Totals:       totals,
+		Langchain:    langchain,
+		ResponseRate: ratio(totals.Answered, totals.Incoming),
+		SuccessRate:  ratio(langchain.Succeeded, langchain.Executions),
+		FailureRate:  ratio(langchain.Failed, langchain.Executions),
+	}, nil
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
@@ -0,0 +1,625 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrInvalidCampaign  = errors.New("invalid campaign")
+	// ErrCampaignState is returned for actions the campaign's current status
+	// does not allow, e.g. pausing a completed campaign.
+	ErrCampaignState = errors.New("action not allowed in the campaign's current status")
+)
+
+const (
+	campaignTickInterval = 2 * time.Second
+	campaignClaimBatch   = 20
+	// campaignLease hides a claimed campaign from other workers while one
+	// recipient is handed to the outbound queue.
+	campaignLease = time.Minute
+	// campaignOfflineDelay is how long a campaign waits for its session to
+	// come back before trying again.
+	campaignOfflineDelay = 30 * time.Second
+	// campaignOrphanAge is how long a queued recipient may go without an
+	// outbound item before it is taken as lost between claim and enqueue,
+	// e.g. by a crash, and claimed again.
+	campaignOrphanAge     = 5 * time.Minute
+	maxCampaignRecipients = 50000
+)
+
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// CampaignConfig paces campaign sends on top of the outbound queue's own
+// per-session throttle.
+type CampaignConfig struct {
+	// A random delay between MinDelay and MaxDelay separates two recipients.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// MaxConsecutiveFailures stops a campaign after this many recipients in
+	// a row failed; 0 never stops it.
+	MaxConsecutiveFailures int
+}
+
+type CampaignUseCase struct {
+	sessionRepo  repository.SessionRepository
+	campaignRepo repository.CampaignRepository
+	sessionUC    *SessionUseCase
+	cfg          CampaignConfig
+}
+
+func NewCampaignUseCase(
+	sessionRepo repository.SessionRepository,
+	campaignRepo repository.CampaignRepository,
+	sessionUC *SessionUseCase,
+	cfg CampaignConfig,
+) *CampaignUseCase {
+	return &CampaignUseCase{
+		sessionRepo:  sessionRepo,
+		campaignRepo: campaignRepo,
+		sessionUC:    sessionUC,
+		cfg:          cfg,
+	}
+}
+
+type CampaignRecipientInput struct {
+	Phone     string            `json:"phone"`
+	Name      string            `json:"name,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+type CreateCampaignInput struct {
+	Caller  Caller
+	AgentID string
+	Name    string
+	// Template is the message body; {{name}}, {{phone}} and recipient
+	// variables are filled in per recipient.
+	Template   string
+	Recipients []CampaignRecipientInput
+}
+
+// CampaignProgress counts a campaign's recipients by status.
+type CampaignProgress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Queued    int `json:"queued"`
+	Sent      int `json:"sent"`
+	Delivered int `json:"delivered"`
+	Read      int `json:"read"`
+	Failed    int `json:"failed"`
+}
+
+// Create stores a draft campaign with its recipients. Recipients are
+// deduplicated by phone number.
+func (uc *CampaignUseCase) Create(ctx context.Context, in CreateCampaignInput) (*entity.Campaign, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(in.Name)
+	if name == "" || len(name) > 255 {
+		return nil, fmt.Errorf("%w: name must be 1-255 characters", ErrInvalidCampaign)
+	}
+	if strings.TrimSpace(in.Template) == "" {
+		return nil, fmt.Errorf("%w: template is required", ErrInvalidCampaign)
+	}
+	if len(in.Recipients) == 0 {
+		return nil, fmt.Errorf("%w: at least one recipient is required", ErrInvalidCampaign)
+	}
+	if len(in.Recipients) > maxCampaignRecipients {
+		return nil, fmt.Errorf("%w: at most %d recipients are allowed", ErrInvalidCampaign, maxCampaignRecipients)
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(in.Recipients))
+	recipients := make([]*entity.CampaignRecipient, 0, len(in.Recipients))
+	for i, r := range in.Recipients {
+		to, err := parseRecipient(r.Phone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: recipient %d: %v", ErrInvalidCampaign, i+1, err)
+		}
+		if seen[to.String()] {
+			continue
+		}
+		seen[to.String()] = true
+
+		variables := r.Variables
+		if variables == nil {
+			variables = map[string]string{}
+		}
+		varsJSON, _ := json.Marshal(variables)
+		recipients = append(recipients, &entity.CampaignRecipient{
+			AgentID:   session.AgentID,
+			Phone:     strings.TrimSpace(r.Phone),
+			Name:      sql.NullString{String: strings.TrimSpace(r.Name), Valid: strings.TrimSpace(r.Name) != ""},
+			Variables: varsJSON,
+			Status:    "pending",
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	campaign := &entity.Campaign{
+		SessionID: session.ID,
+		AgentID:   session.AgentID,
+		Name:      name,
+		Template:  in.Template,
+		Status:    "draft",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := uc.campaignRepo.Create(ctx, campaign, recipients); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// ParseRecipientsCSV reads a recipient list with a header row. The phone
+// column is required, name is optional and every other column becomes a
+// template variable.
+func ParseRecipientsCSV(r io.Reader) ([]CampaignRecipientInput, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: CSV header: %v", ErrInvalidCampaign, err)
+	}
+	phoneCol, nameCol := -1, -1
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))
+		header[i] = col
+		switch col {
+		case "phone":
+			phoneCol = i
+		case "name":
+			nameCol = i
+		}
+	}
+	if phoneCol < 0 {
+		return nil, fmt.Errorf("%w: CSV needs a phone column", ErrInvalidCampaign)
+	}
+
+	var recipients []CampaignRecipientInput
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: CSV line %d: %v", ErrInvalidCampaign, line, err)
+		}
+		if phoneCol >= len(record) || strings.TrimSpace(record[phoneCol]) == "" {
+			continue
+		}
+
+		recipient := CampaignRecipientInput{
+			Phone:     record[phoneCol],
+			Variables: map[string]string{},
+		}
+		for i, value := range record {
+			switch {
+			case i == phoneCol || i >= len(header) || header[i] == "":
+			case i == nameCol:
+				recipient.Name = value
+			default:
+				recipient.Variables[header[i]] = value
+			}
+		}
+		recipients = append(recipients, recipient)
+		if len(recipients) > maxCampaignRecipients {
+			return nil, fmt.Errorf("%w: at most %d recipients are allowed", ErrInvalidCampaign, maxCampaignRecipients)
+		}
+	}
+	return recipients, nil
+}
+
+func (uc *CampaignUseCase) Get(ctx context.Context, caller Caller, id int) (*entity.Campaign, *CampaignProgress, error) {
+	campaign, err := uc.ownedCampaign(ctx, caller, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	progress, err := uc.progress(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return campaign, progress, nil
+}
+
+func (uc *CampaignUseCase) List(ctx context.Context, caller Caller, agentID string, limit, offset int) ([]*entity.Campaign, error) {
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+		return nil, err
+	}
+	return uc.campaignRepo.GetByAgentID(ctx, agentID, limit, offset)
+}
+
+// ListRecipients pages through a campaign's recipients; an empty status
+// lists all of them.
+func (uc *CampaignUseCase) ListRecipients(ctx context.Context, caller Caller, id int, status string, limit, offset int) ([]*entity.CampaignRecipient, error) {
+	if _, err := uc.ownedCampaign(ctx, caller, id); err != nil {
+		return nil, err
+	}
+	return uc.campaignRepo.GetRecipients(ctx, id, status, limit, offset)
+}
+
+func (uc *CampaignUseCase) Start(ctx context.Context, caller Caller, id int) (*entity.Campaign, error) {
+	return uc.transition(ctx, caller, id, func(c *entity.Campaign, now time.Time) bool {
+		if c.Status != "draft" {
+			return false
+		}
+		c.Status = "running"
+		c.StartedAt = sql.NullTime{Time: now, Valid: true}
+		c.NextSendAt = sql.NullTime{}
+		return true
+	})
+}
+
+func (uc *CampaignUseCase) Pause(ctx context.Context, caller Caller, id int) (*entity.Campaign, error) {
+	return uc.transition(ctx, caller, id, func(c *entity.Campaign, now time.Time) bool {
+		if c.Status != "running" {
+			return false
+		}
+		c.Status = "paused"
+		return true
+	})
+}
+
+// Resume continues a paused campaign, or one stopped after repeated
+// failures, with a clean failure count.
+func (uc *CampaignUseCase) Resume(ctx context.Context, caller Caller, id int) (*entity.Campaign, error) {
+	return uc.transition(ctx, caller, id, func(c *entity.Campaign, now time.Time) bool {
+		if c.Status != "paused" && c.Status != "stopped" {
+			return false
+		}
+		c.Status = "running"
+		c.ConsecutiveFailures = 0
+		c.StopReason = sql.NullString{}
+		c.NextSendAt = sql.NullTime{}
+		return true
+	})
+}
+
+// Cancel ends a campaign for good. Messages already queued are still sent.
+func (uc *CampaignUseCase) Cancel(ctx context.Context, caller Caller, id int) (*entity.Campaign, error) {
+	return uc.transition(ctx, caller, id, func(c *entity.Campaign, now time.Time) bool {
+		if c.Status == "completed" || c.Status == "cancelled" {
+			return false
+		}
+		c.Status = "cancelled"
+		c.FinishedAt = sql.NullTime{Time: now, Valid: true}
+		return true
+	})
+}
+
+// transition applies change to an owned campaign and stores it, failing
+// with ErrCampaignState if change rejects the current status or the status
+// changed concurrently.
+func (uc *CampaignUseCase) transition(ctx context.Context, caller Caller, id int, change func(c *entity.Campaign, now time.Time) bool) (*entity.Campaign, error) {
+	campaign, err := uc.ownedCampaign(ctx, caller, id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := campaign.Status
+	now := time.Now()
+	if !change(campaign, now) {
+		return nil, fmt.Errorf("%w: campaign is %s", ErrCampaignState, from)
+	}
+	campaign.UpdatedAt = now
+	ok, err := uc.campaignRepo.Transition(ctx, campaign, from)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: campaign changed concurrently", ErrCampaignState)
+	}
+	return campaign, nil
+}
+
+func (uc *CampaignUseCase) ownedCampaign(ctx context.Context, caller Caller, id int) (*entity.Campaign, error) {
+	campaign, err := uc.campaignRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, ErrCampaignNotFound
+	}
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, campaign.AgentID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (uc *CampaignUseCase) progress(ctx context.Context, id int) (*CampaignProgress, error) {
+	counts, err := uc.campaignRepo.CountRecipientsByStatus(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	progress := &CampaignProgress{
+		Pending:   counts["pending"],
+		Queued:    counts["queued"],
+		Sent:      counts["sent"],
+		Delivered: counts["delivered"],
+		Read:      counts["read"],
+		Failed:    counts["failed"],
+	}
+	for _, n := range counts {
+		progress.Total += n
+	}
+	return progress, nil
+}
+
+// StartWorker hands recipients of running campaigns to the outbound queue,
+// one at a time per campaign with a random delay in between.
+func (uc *CampaignUseCase) StartWorker(ctx context.Context) {
+	ticker := time.NewTicker(campaignTickInterval)
+	defer ticker.Stop()
+	for {
+		campaigns, err := uc.campaignRepo.ClaimDue(ctx, campaignClaimBatch, campaignLease)
+		if err != nil {
+			log.Printf("campaign: failed to claim due campaigns: %v", err)
+		}
+		for _, campaign := range campaigns {
+			uc.advance(ctx, campaign)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// advance queues the campaign's next recipient, or completes the campaign
+// once every recipient has been handled. A recipient still in the queue
+// holds the campaign back so delays are measured from the last send.
+func (uc *CampaignUseCase) advance(ctx context.Context, campaign *entity.Campaign) {
+	now := time.Now()
+	if _, err := uc.sessionUC.connectedClient(campaign.AgentID); err != nil {
+		uc.scheduleNext(ctx, campaign, now.Add(campaignOfflineDelay))
+		return
+	}
+
+	counts, err := uc.campaignRepo.CountRecipientsByStatus(ctx, campaign.ID)
+	if err != nil {
+		log.Printf("campaign: failed to count recipients of campaign %d: %v", campaign.ID, err)
+		return
+	}
+	if counts["queued"] > 0 {
+		released, err := uc.campaignRepo.ReleaseOrphanedRecipients(ctx, campaign.ID, now.Add(-campaignOrphanAge))
+		if err != nil {
+			log.Printf("campaign: failed to release orphaned recipients of campaign %d: %v", campaign.ID, err)
+		} else if released > 0 {
+			log.Printf("campaign: released %d orphaned recipients of campaign %d", released, campaign.ID)
+		}
+		uc.scheduleNext(ctx, campaign, now.Add(campaignTickInterval))
+		return
+	}
+
+	recipient, err := uc.campaignRepo.ClaimNextRecipient(ctx, campaign.ID)
+	if err != nil {
+		log.Printf("campaign: failed to claim recipient of campaign %d: %v", campaign.ID, err)
+		return
+	}
+	if recipient == nil {
+		uc.finish(ctx, campaign, "completed", "")
+		return
+	}
+
+	item, err := uc.queueRecipient(ctx, campaign, recipient)
+	switch {
+	case errors.Is(err, ErrSessionNotConnected):
+		recipient.Status = "pending"
+		recipient.UpdatedAt = time.Now()
+		if err := uc.campaignRepo.UpdateRecipient(ctx, recipient); err != nil {
+			log.Printf("campaign: failed to release recipient %d: %v", recipient.ID, err)
+		}
+		uc.scheduleNext(ctx, campaign, now.Add(campaignOfflineDelay))
+		return
+	case err != nil:
+		uc.recipientFailed(ctx, campaign.ID, recipient, err.Error())
+	default:
+		// The sender may already have finished the item; OutboundFinished
+		// finds the recipient through the item's metadata either way.
+		if err := uc.campaignRepo.LinkRecipientOutbound(ctx, recipient.ID, item.ID); err != nil {
+			log.Printf("campaign: failed to link recipient %d to outbound item %d: %v", recipient.ID, item.ID, err)
+		}
+	}
+	uc.scheduleNext(ctx, campaign, now.Add(uc.nextDelay()))
+}
+
+func (uc *CampaignUseCase) queueRecipient(ctx context.Context, campaign *entity.Campaign, recipient *entity.CampaignRecipient) (*entity.OutboundMessage, error) {
+	session, err := uc.sessionRepo.GetByAgentID(ctx, campaign.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	to, err := parseRecipient(recipient.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	text := renderTemplate(campaign.Template, recipient)
+	return uc.sessionUC.sendMessage(ctx, session, outboundMessage{
+		To:      to,
+		Message: &waProto.Message{Conversation: proto.String(text)},
+		Text:    text,
+		Metadata: map[string]interface{}{
+			"campaignId":          campaign.ID,
+			"campaignRecipientId": recipient.ID,
+		},
+	}, PriorityBulk)
+}
+
+func (uc *CampaignUseCase) scheduleNext(ctx context.Context, campaign *entity.Campaign, at time.Time) {
+	if err := uc.campaignRepo.ScheduleNext(ctx, campaign.ID, at); err != nil {
+		log.Printf("campaign: failed to schedule campaign %d: %v", campaign.ID, err)
+	}
+}
+
+func (uc *CampaignUseCase) nextDelay() time.Duration {
+	delay := uc.cfg.MinDelay
+	if spread := uc.cfg.MaxDelay - uc.cfg.MinDelay; spread > 0 {
+		delay += time.Duration(rand.Int63n(int64(spread)))
+	}
+	return delay
+}
+
+// finish moves a running campaign to a final status and tells webhooks.
+func (uc *CampaignUseCase) finish(ctx context.Context, campaign *entity.Campaign, status, reason string) {
+	now := time.Now()
+	campaign.Status = status
+	campaign.StopReason = sql.NullString{String: reason, Valid: reason != ""}
+	campaign.NextSendAt = sql.NullTime{}
+	campaign.UpdatedAt = now
+	event := EventCampaignStopped
+	if status == "completed" {
+		campaign.FinishedAt = sql.NullTime{Time: now, Valid: true}
+		event = EventCampaignCompleted
+	}
+
+	ok, err := uc.campaignRepo.Transition(ctx, campaign, "running")
+	if err != nil {
+		log.Printf("campaign: failed to mark campaign %d %s: %v", campaign.ID, status, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	data := map[string]interface{}{"campaignId": campaign.ID, "name": campaign.Name}
+	if reason != "" {
+		data["reason"] = reason
+	}
+	if progress, err := uc.progress(ctx, campaign.ID); err == nil {
+		data["progress"] = progress
+	}
+	uc.sessionUC.emit(campaign.AgentID, event, data)
+}
+
+// recipientFailed marks a recipient failed and stops the campaign once too
+// many recipients in a row have failed.
+func (uc *CampaignUseCase) recipientFailed(ctx context.Context, campaignID int, recipient *entity.CampaignRecipient, reason string) {
+	recipient.Status = "failed"
+	recipient.ErrorMessage = sql.NullString{String: reason, Valid: reason != ""}
+	recipient.UpdatedAt = time.Now()
+	if err := uc.campaignRepo.UpdateRecipient(ctx, recipient); err != nil {
+		log.Printf("campaign: failed to update recipient %d: %v", recipient.ID, err)
+	}
+
+	failures, err := uc.campaignRepo.AddFailure(ctx, campaignID)
+	if err != nil {
+		log.Printf("campaign: failed to count failure of campaign %d: %v", campaignID, err)
+		return
+	}
+	if uc.cfg.MaxConsecutiveFailures <= 0 || failures < uc.cfg.MaxConsecutiveFailures {
+		return
+	}
+	campaign, err := uc.campaignRepo.GetByID(ctx, campaignID)
+	if err != nil || campaign == nil {
+		return
+	}
+	log.Printf("campaign: stopping campaign %d after %d consecutive failures", campaignID, failures)
+	uc.finish(ctx, campaign, "stopped", fmt.Sprintf("%d consecutive recipients failed; last error: %s", failures, reason))
+}
+
+// OutboundFinished records the outcome of a campaign message.
+func (uc *CampaignUseCase) OutboundFinished(ctx context.Context, item *entity.OutboundMessage, msg *entity.Message) {
+	if item.Priority != PriorityBulk {
+		return
+	}
+	recipient, err := uc.recipientOf(ctx, item)
+	if err != nil {
+		log.Printf("campaign: failed to look up recipient of outbound item %d: %v", item.ID, err)
+		return
+	}
+	if recipient == nil {
+		return
+	}
+	recipient.OutboundID = sql.NullInt64{Int64: int64(item.ID), Valid: true}
+
+	if msg == nil {
+		uc.recipientFailed(ctx, recipient.CampaignID, recipient, item.ErrorMessage.String)
+		return
+	}
+	recipient.Status = "sent"
+	recipient.MessageID = msg.MessageID
+	recipient.SentAt = sql.NullTime{Time: msg.CreatedAt, Valid: true}
+	recipient.ErrorMessage = sql.NullString{}
+	recipient.UpdatedAt = time.Now()
+	if err := uc.campaignRepo.UpdateRecipient(ctx, recipient); err != nil {
+		log.Printf("campaign: failed to update recipient %d: %v", recipient.ID, err)
+	}
+	if err := uc.campaignRepo.ResetFailures(ctx, recipient.CampaignID); err != nil {
+		log.Printf("campaign: failed to reset failures of campaign %d: %v", recipient.CampaignID, err)
+	}
+}
+
+// recipientOf finds the recipient an outbound item was queued for, by the
+// recipient ID in its metadata or, for items queued without one, by the
+// recipient's link to the item.
+func (uc *CampaignUseCase) recipientOf(ctx context.Context, item *entity.OutboundMessage) (*entity.CampaignRecipient, error) {
+	var meta struct {
+		CampaignRecipientID int `json:"campaignRecipientId"`
+	}
+	if len(item.Metadata) > 0 && json.Unmarshal(item.Metadata, &meta) == nil && meta.CampaignRecipientID != 0 {
+		return uc.campaignRepo.GetRecipient(ctx, meta.CampaignRecipientID)
+	}
+	return uc.campaignRepo.GetRecipientByOutboundID(ctx, item.ID)
+}
+
+// ReceiptReceived advances sent recipients to delivered or read.
+func (uc *CampaignUseCase) ReceiptReceived(ctx context.Context, agentID string, messageIDs []string, receipt string, at time.Time) {
+	status := ""
+	switch receipt {
+	case "delivered":
+		status = "delivered"
+	case "read", "played":
+		status = "read"
+	default:
+		return
+	}
+	if err := uc.campaignRepo.ApplyReceipt(ctx, agentID, messageIDs, status, at); err != nil {
+		log.Printf("campaign: failed to apply %s receipt for %s: %v", receipt, agentID, err)
+	}
+}
+
+// renderTemplate fills {{variable}} placeholders from the recipient's name,
+// phone and variables. Unknown variables render empty.
+func renderTemplate(template string, recipient *entity.CampaignRecipient) string {
+	vars := map[string]string{}
+	_ = json.Unmarshal(recipient.Variables, &vars)
+	if _, ok := vars["name"]; !ok {
+		vars["name"] = recipient.Name.String
+	}
+	if _, ok := vars["phone"]; !ok {
+		vars["phone"] = recipient.Phone
+	}
+	return templateVariable.ReplaceAllStringFunc(template, func(match string) string {
+		return vars[templateVariable.FindStringSubmatch(match)[1]]
+	})
+}
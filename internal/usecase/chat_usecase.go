@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var ErrChatNotFound = errors.New("chat not found")
+
+type ChatUseCase struct {
+	sessionRepo repository.SessionRepository
+	chatRepo    repository.ChatRepository
+}
+
+func NewChatUseCase(sessionRepo repository.SessionRepository, chatRepo repository.ChatRepository) *ChatUseCase {
+	return &ChatUseCase{
+		sessionRepo: sessionRepo,
+		chatRepo:    chatRepo,
+	}
+}
+
+// ListChatsInput filters a session's chats. Empty fields do not filter.
+type ListChatsInput struct {
+	Caller   Caller
+	AgentID  string
+	ChatType string
+	Archived *bool
+	Limit    int
+	Offset   int
+}
+
+// UpdateChatInput changes the fields that are set.
+type UpdateChatInput struct {
+	Caller   Caller
+	ID       int
+	Archived *bool
+	Muted    *bool
+	// MarkRead resets the unread count.
+	MarkRead bool
+}
+
+// List returns a session's chats, most recently active first.
+func (uc *ChatUseCase) List(ctx context.Context, in ListChatsInput) ([]*entity.Chat, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.chatRepo.Find(ctx, repository.ChatFilter{
+		SessionID: session.ID,
+		ChatType:  in.ChatType,
+		Archived:  in.Archived,
+		Limit:     in.Limit,
+		Offset:    in.Offset,
+	})
+}
+
+func (uc *ChatUseCase) Get(ctx context.Context, caller Caller, id int) (*entity.Chat, error) {
+	return uc.ownedChat(ctx, caller, id)
+}
+
+func (uc *ChatUseCase) Update(ctx context.Context, in UpdateChatInput) (*entity.Chat, error) {
+	chat, err := uc.ownedChat(ctx, in.Caller, in.ID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Archived != nil {
+		chat.Archived = *in.Archived
+	}
+	if in.Muted != nil {
+		chat.Muted = *in.Muted
+	}
+	if in.MarkRead {
+		chat.UnreadCount = 0
+	}
+	chat.UpdatedAt = time.Now()
+	if err := uc.chatRepo.Update(ctx, chat); err != nil {
+		return nil, err
+	}
+	return chat, nil
+}
+
+func (uc *ChatUseCase) ownedChat(ctx context.Context, caller Caller, id int) (*entity.Chat, error) {
+	chat, err := uc.chatRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if chat == nil {
+		return nil, ErrChatNotFound
+	}
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, chat.AgentID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrChatNotFound
+		}
+		return nil, err
+	}
+	return chat, nil
+}
+
+// chatKey is the JID a message is filed under. Direct chats are keyed by
+// phone number, so a contact who writes from a LID address shares a thread
+// with the replies sent to their number. alt is the alternative address an
+// incoming message carries, if any.
+func chatKey(ctx context.Context, client *whatsmeow.Client, jid, alt types.JID) types.JID {
+	jid = jid.ToNonAD()
+	if jid.Server != types.HiddenUserServer {
+		return jid
+	}
+	if alt.Server == types.DefaultUserServer {
+		return alt.ToNonAD()
+	}
+	if client != nil && client.Store != nil && client.Store.LIDs != nil {
+		if pn, err := client.Store.LIDs.GetPNForLID(ctx, jid); err == nil && !pn.IsEmpty() {
+			return pn.ToNonAD()
+		}
+	}
+	return jid
+}
+
+// contactAliases returns the numbers a contact is stored under on agentID's
+// session: its phone number and, when the device knows it, its LID.
+func (uc *SessionUseCase) contactAliases(ctx context.Context, agentID string, jid types.JID) []string {
+	numbers := []string{jid.User}
+	uc.mu.RLock()
+	client := uc.clients[agentID]
+	uc.mu.RUnlock()
+	if client == nil || client.Store == nil || client.Store.LIDs == nil || jid.Server != types.DefaultUserServer {
+		return numbers
+	}
+	if lid, err := client.Store.LIDs.GetLIDForPN(ctx, jid); err == nil && !lid.IsEmpty() {
+		numbers = append(numbers, lid.User)
+	}
+	return numbers
+}
+
+// recordChat files a stored message under its chat. name is the contact's
+// push name when known; a chat still without a name gets one looked up from
+// the group info or the contact store.
+func (uc *SessionUseCase) recordChat(ctx context.Context, client *whatsmeow.Client, msg *entity.Message, chatJID types.JID, name string) {
+	if uc.chatRepo == nil {
+		return
+	}
+	chatType := "direct"
+	if chatJID.Server == types.GroupServer {
+		chatType = "group"
+		// A push name in a group belongs to the sender, not the chat.
+		name = ""
+	}
+
+	chat, err := uc.chatRepo.RecordMessage(ctx, &entity.Chat{
+		SessionID:   msg.SessionID,
+		AgentID:     msg.AgentID,
+		ChatJID:     chatJID.String(),
+		ChatType:    chatType,
+		DisplayName: sql.NullString{String: name, Valid: name != ""},
+	}, msg)
+	if err != nil {
+		log.Printf("failed to update chat %s for agent %s: %v", chatJID, msg.AgentID, err)
+		return
+	}
+	if chat.DisplayName.Valid || client == nil {
+		return
+	}
+
+	go func() {
+		name := lookupChatName(client, chatJID)
+		if name == "" {
+			return
+		}
+		if err := uc.chatRepo.SetDisplayName(context.Background(), chat.ID, name); err != nil {
+			log.Printf("failed to name chat %s for agent %s: %v", chatJID, msg.AgentID, err)
+		}
+	}()
+}
+
+func lookupChatName(client *whatsmeow.Client, chatJID types.JID) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if chatJID.Server == types.GroupServer {
+		info, err := client.GetGroupInfo(ctx, chatJID)
+		if err != nil {
+			return ""
+		}
+		return info.Name
+	}
+	if client.Store == nil || client.Store.Contacts == nil {
+		return ""
+	}
+	contact, err := client.Store.Contacts.GetContact(ctx, chatJID)
+	if err != nil || !contact.Found {
+		return ""
+	}
+	return fallbackString(contact.FullName, fallbackString(contact.PushName, contact.BusinessName))
+}
@@ -0,0 +1,323 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+)
+
+var ErrInvalidExport = errors.New("invalid export")
+
+// Export formats. Text follows WhatsApp's own "Export chat" transcripts.
+const (
+	ExportFormatJSONL = "jsonl"
+	ExportFormatCSV   = "csv"
+	ExportFormatText  = "txt"
+)
+
+// exportBatchSize is how many messages each export query reads; only one
+// batch is held in memory at a time.
+const exportBatchSize = 500
+
+// mediaTypes are written as "<Media omitted>" in text transcripts, as
+// WhatsApp does.
+var mediaTypes = map[string]bool{"image": true, "video": true, "audio": true, "document": true, "sticker": true}
+
+var exportCSVHeader = []string{
+	"id", "timestamp", "direction", "from", "to", "chat", "chat_name", "type", "status", "text",
+	"message_id", "reply_to_id", "langchain_execution_id", "langchain_status",
+	"langchain_execution_time_ms", "langchain_error", "langchain_replay_of",
+}
+
+type ExportUseCase struct {
+	sessionRepo repository.SessionRepository
+	messageRepo repository.MessageRepository
+}
+
+func NewExportUseCase(sessionRepo repository.SessionRepository, messageRepo repository.MessageRepository) *ExportUseCase {
+	return &ExportUseCase{
+		sessionRepo: sessionRepo,
+		messageRepo: messageRepo,
+	}
+}
+
+// ExportInput selects the messages of one session to export, optionally a
+// single chat JID and a time range.
+type ExportInput struct {
+	Caller  Caller
+	AgentID string
+	Format  string
+	Chat    string
+	Since   time.Time
+	Until   time.Time
+	// Timezone is the IANA zone timestamps are written in; empty uses the
+	// server's.
+	Timezone string
+}
+
+// MessageExport is a checked export request, ready to stream.
+type MessageExport struct {
+	Format      string
+	ContentType string
+	FileName    string
+
+	messageRepo repository.MessageRepository
+	filter      repository.MessageExportFilter
+	loc         *time.Location
+}
+
+// Prepare checks an export before anything is written, so errors can still
+// be reported with a status code.
+func (uc *ExportUseCase) Prepare(ctx context.Context, in ExportInput) (*MessageExport, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &MessageExport{
+		Format:      strings.ToLower(in.Format),
+		messageRepo: uc.messageRepo,
+		filter: repository.MessageExportFilter{
+			SessionID: session.ID,
+			Chat:      strings.TrimSpace(in.Chat),
+			Limit:     exportBatchSize,
+		},
+		loc: time.Local,
+	}
+	if export.Format == "" {
+		export.Format = ExportFormatJSONL
+	}
+	switch export.Format {
+	case ExportFormatJSONL:
+		export.ContentType = "application/x-ndjson"
+	case ExportFormatCSV:
+		export.ContentType = "text/csv; charset=utf-8"
+	case ExportFormatText:
+		export.ContentType = "text/plain; charset=utf-8"
+	default:
+		return nil, fmt.Errorf("%w: format must be jsonl, csv or txt", ErrInvalidExport)
+	}
+	if in.Timezone != "" {
+		if export.loc, err = time.LoadLocation(in.Timezone); err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidExport, in.Timezone)
+		}
+	}
+	export.filter.Since, export.filter.Until = localRange(in.Since, in.Until)
+	if !export.filter.Since.IsZero() && !export.filter.Until.IsZero() && !export.filter.Since.Before(export.filter.Until) {
+		return nil, fmt.Errorf("%w: since must be before until", ErrInvalidExport)
+	}
+
+	export.FileName = fmt.Sprintf("%s-messages-%s.%s", session.AgentID, time.Now().In(export.loc).Format("20060102"), export.Format)
+	return export, nil
+}
+
+// WriteTo streams the messages oldest first, flushing w after each batch
+// when it supports it. It stops at the first write error, e.g. when the
+// client goes away.
+func (e *MessageExport) WriteTo(ctx context.Context, w io.Writer) error {
+	flush := func() error { return nil }
+	if f, ok := w.(interface{ Flush() error }); ok {
+		flush = f.Flush
+	}
+
+	var write func(*entity.ExportedMessage) error
+	switch e.Format {
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportCSVHeader); err != nil {
+			return err
+		}
+		write = func(m *entity.ExportedMessage) error { return cw.Write(e.csvRecord(m)) }
+		inner := flush
+		flush = func() error {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+			return inner()
+		}
+	case ExportFormatText:
+		write = func(m *entity.ExportedMessage) error {
+			_, err := io.WriteString(w, e.textLine(m))
+			return err
+		}
+	default:
+		enc := json.NewEncoder(w)
+		write = func(m *entity.ExportedMessage) error { return enc.Encode(e.jsonRecord(m)) }
+	}
+
+	filter := e.filter
+	for {
+		batch, err := e.messageRepo.Export(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, m := range batch {
+			if err := write(m); err != nil {
+				return err
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if len(batch) < filter.Limit {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		filter.AfterTime, filter.AfterID = last.CreatedAt, last.ID
+	}
+}
+
+type exportRecord struct {
+	ID        int                `json:"id"`
+	MessageID string             `json:"messageId,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+	Direction string             `json:"direction"`
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Chat      string             `json:"chat,omitempty"`
+	ChatName  string             `json:"chatName,omitempty"`
+	Type      string             `json:"type"`
+	Status    string             `json:"status,omitempty"`
+	Text      string             `json:"text"`
+	ReplyToID int64              `json:"replyToId,omitempty"`
+	Metadata  json.RawMessage    `json:"metadata,omitempty"`
+	Langchain *exportedExecution `json:"langchain,omitempty"`
+}
+
+type exportedExecution struct {
+	ExecutionID     int64  `json:"executionId"`
+	Status          string `json:"status,omitempty"`
+	ExecutionTimeMs int64  `json:"executionTimeMs,omitempty"`
+	Error           string `json:"error,omitempty"`
+	ReplayOf        int64  `json:"replayOf,omitempty"`
+}
+
+// timestamp returns when m was stored, in the export's time zone.
+func (e *MessageExport) timestamp(m *entity.ExportedMessage) time.Time {
+	return storedTime(m.CreatedAt).In(e.loc)
+}
+
+func (e *MessageExport) jsonRecord(m *entity.ExportedMessage) exportRecord {
+	rec := exportRecord{
+		ID:        m.ID,
+		MessageID: m.MessageID.String,
+		Timestamp: e.timestamp(m),
+		Direction: m.Direction.String,
+		From:      m.FromNumber.String,
+		To:        m.ToNumber.String,
+		Chat:      exportChat(m),
+		ChatName:  m.ChatName.String,
+		Type:      m.MessageType.String,
+		Status:    m.Status.String,
+		Text:      m.MessageText.String,
+		ReplyToID: m.ReplyToID.Int64,
+	}
+	if len(m.Metadata) > 0 {
+		rec.Metadata = json.RawMessage(m.Metadata)
+	}
+	if m.LangchainExecutionID.Valid {
+		rec.Langchain = &exportedExecution{
+			ExecutionID:     m.LangchainExecutionID.Int64,
+			Status:          m.ExecutionStatus.String,
+			ExecutionTimeMs: m.ExecutionTimeMs.Int64,
+			Error:           m.ExecutionError.String,
+			ReplayOf:        m.ExecutionReplayOf.Int64,
+		}
+	}
+	return rec
+}
+
+func (e *MessageExport) csvRecord(m *entity.ExportedMessage) []string {
+	optional := func(valid bool, v int64) string {
+		if !valid {
+			return ""
+		}
+		return strconv.FormatInt(v, 10)
+	}
+	return []string{
+		strconv.Itoa(m.ID),
+		e.timestamp(m).Format(time.RFC3339),
+		m.Direction.String,
+		m.FromNumber.String,
+		m.ToNumber.String,
+		exportChat(m),
+		m.ChatName.String,
+		m.MessageType.String,
+		m.Status.String,
+		m.MessageText.String,
+		m.MessageID.String,
+		optional(m.ReplyToID.Valid, m.ReplyToID.Int64),
+		optional(m.LangchainExecutionID.Valid, m.LangchainExecutionID.Int64),
+		m.ExecutionStatus.String,
+		optional(m.ExecutionTimeMs.Valid, m.ExecutionTimeMs.Int64),
+		m.ExecutionError.String,
+		optional(m.ExecutionReplayOf.Valid, m.ExecutionReplayOf.Int64),
+	}
+}
+
+// textLine writes a message the way WhatsApp's "Export chat" does, e.g.
+// "16/10/2026, 14:05 - +62812...: hello". Bot replies end with their
+// Langchain execution.
+func (e *MessageExport) textLine(m *entity.ExportedMessage) string {
+	var b strings.Builder
+	b.WriteString(e.timestamp(m).Format("02/01/2006, 15:04"))
+	b.WriteString(" - ")
+	b.WriteString(exportSender(m))
+	b.WriteString(": ")
+
+	text := m.MessageText.String
+	if mediaTypes[m.MessageType.String] {
+		if text == "" {
+			text = "<Media omitted>"
+		} else {
+			text = "<Media omitted>\n" + text
+		}
+	}
+	b.WriteString(text)
+
+	if m.LangchainExecutionID.Valid {
+		fmt.Fprintf(&b, " <Langchain execution %d", m.LangchainExecutionID.Int64)
+		if m.ExecutionStatus.Valid {
+			b.WriteString(": " + m.ExecutionStatus.String)
+		}
+		if m.ExecutionTimeMs.Valid {
+			fmt.Fprintf(&b, ", %d ms", m.ExecutionTimeMs.Int64)
+		}
+		b.WriteString(">")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// exportSender names the author of a message: the contact's name in direct
+// chats when known, otherwise the phone number.
+func exportSender(m *entity.ExportedMessage) string {
+	if m.Direction.String == "incoming" && m.ChatName.Valid && m.ChatName.String != "" &&
+		!strings.HasSuffix(exportChat(m), "@g.us") {
+		return m.ChatName.String
+	}
+	if m.FromNumber.String == "" {
+		return "unknown"
+	}
+	return "+" + m.FromNumber.String
+}
+
+func exportChat(m *entity.ExportedMessage) string {
+	var meta struct {
+		Chat string `json:"chat"`
+	}
+	if len(m.Metadata) > 0 {
+		_ = json.Unmarshal(m.Metadata, &meta)
+	}
+	return meta.Chat
+}
@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+)
+
+// exportRepo serves Export from a fixed list of messages, in batches.
+type exportRepo struct {
+	repository.MessageRepository
+	messages []*entity.ExportedMessage
+	filters  []repository.MessageExportFilter
+}
+
+func (r *exportRepo) Export(ctx context.Context, filter repository.MessageExportFilter) ([]*entity.ExportedMessage, error) {
+	r.filters = append(r.filters, filter)
+	var batch []*entity.ExportedMessage
+	for _, m := range r.messages {
+		if m.ID > filter.AfterID && len(batch) < filter.Limit {
+			batch = append(batch, m)
+		}
+	}
+	return batch, nil
+}
+
+var exportZone = time.FixedZone("WIB", 7*3600)
+
+// useServerZone runs the test as if the server's time zone were UTC+2, so
+// rows hold that wall clock.
+func useServerZone(t *testing.T) {
+	t.Helper()
+	loc := time.Local
+	time.Local = time.FixedZone("CEST", 2*3600)
+	t.Cleanup(func() { time.Local = loc })
+}
+
+func exportFixture() []*entity.ExportedMessage {
+	str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	num := func(n int64) sql.NullInt64 { return sql.NullInt64{Int64: n, Valid: true} }
+	// 09:05 server time (07:05Z) as pgx reads it back: the wall clock
+	// labelled UTC.
+	at := time.Date(2026, 10, 16, 9, 5, 0, 0, time.UTC)
+	return []*entity.ExportedMessage{
+		{
+			Message: entity.Message{
+				ID: 1, MessageID: str("ABC"), FromNumber: str("62811"), ToNumber: str("62899"),
+				MessageText: str("hello, \"bot\"\nsecond line"), MessageType: str("text"), Direction: str("incoming"),
+				Status: str("received"), Metadata: []byte(`{"chat":"62811@s.whatsapp.net"}`), CreatedAt: at,
+			},
+			ChatName: str("Budi"),
+		},
+		{
+			Message: entity.Message{
+				ID: 2, MessageID: str("DEF"), FromNumber: str("62899"), ToNumber: str("62811"),
+				MessageText: str("hi Budi"), MessageType: str("text"), Direction: str("outgoing"), Status: str("read"),
+				Metadata: []byte(`{"chat":"62811@s.whatsapp.net"}`), ReplyToID: num(1), LangchainExecutionID: num(7),
+				CreatedAt: at.Add(time.Minute),
+			},
+			ChatName:        str("Budi"),
+			ExecutionStatus: str("success"),
+			ExecutionTimeMs: num(1200),
+		},
+		{
+			Message: entity.Message{
+				ID: 3, FromNumber: str("62822"), MessageType: str("image"), Direction: str("incoming"),
+				Metadata: []byte(`{"chat":"1203@g.us"}`), CreatedAt: at.Add(2 * time.Minute),
+			},
+			ChatName: str("Family"),
+		},
+	}
+}
+
+func newTestExport(format string, messages []*entity.ExportedMessage, batch int) (*MessageExport, *exportRepo) {
+	repo := &exportRepo{messages: messages}
+	return &MessageExport{
+		Format:      format,
+		messageRepo: repo,
+		filter:      repository.MessageExportFilter{SessionID: 1, Limit: batch},
+		loc:         exportZone,
+	}, repo
+}
+
+func TestExportJSONL(t *testing.T) {
+	useServerZone(t)
+	export, _ := newTestExport(ExportFormatJSONL, exportFixture(), exportBatchSize)
+	var out bytes.Buffer
+	if err := export.WriteTo(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), out.String())
+	}
+	var first, second map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first["timestamp"] != "2026-10-16T14:05:00+07:00" {
+		t.Errorf("timestamp = %v, want it in the export's time zone", first["timestamp"])
+	}
+	if first["chat"] != "62811@s.whatsapp.net" || first["chatName"] != "Budi" || first["messageId"] != "ABC" {
+		t.Errorf("first record = %v", first)
+	}
+	if _, ok := first["langchain"]; ok {
+		t.Errorf("first record has a langchain block: %v", first["langchain"])
+	}
+	if meta, ok := first["metadata"].(map[string]interface{}); !ok || meta["chat"] != "62811@s.whatsapp.net" {
+		t.Errorf("metadata = %v, want the stored JSON", first["metadata"])
+	}
+	want := map[string]interface{}{"executionId": float64(7), "status": "success", "executionTimeMs": float64(1200)}
+	if !reflect.DeepEqual(second["langchain"], want) {
+		t.Errorf("langchain = %v, want %v", second["langchain"], want)
+	}
+	if second["replyToId"] != float64(1) {
+		t.Errorf("replyToId = %v, want 1", second["replyToId"])
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	useServerZone(t)
+	export, _ := newTestExport(ExportFormatCSV, exportFixture(), exportBatchSize)
+	var out bytes.Buffer
+	if err := export.WriteTo(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want a header and 3 rows", len(records))
+	}
+	if !reflect.DeepEqual(records[0], exportCSVHeader) {
+		t.Errorf("header = %v", records[0])
+	}
+	want := [][]string{
+		{"1", "2026-10-16T14:05:00+07:00", "incoming", "62811", "62899", "62811@s.whatsapp.net", "Budi", "text", "received",
+			"hello, \"bot\"\nsecond line", "ABC", "", "", "", "", "", ""},
+		{"2", "2026-10-16T14:06:00+07:00", "outgoing", "62899", "62811", "62811@s.whatsapp.net", "Budi", "text", "read",
+			"hi Budi", "DEF", "1", "7", "success", "1200", "", ""},
+		{"3", "2026-10-16T14:07:00+07:00", "incoming", "62822", "", "1203@g.us", "Family", "image", "",
+			"", "", "", "", "", "", "", ""},
+	}
+	for i, row := range want {
+		if !reflect.DeepEqual(records[i+1], row) {
+			t.Errorf("row %d = %q\nwant %q", i+1, records[i+1], row)
+		}
+	}
+}
+
+func TestExportText(t *testing.T) {
+	useServerZone(t)
+	export, _ := newTestExport(ExportFormatText, exportFixture(), exportBatchSize)
+	var out bytes.Buffer
+	if err := export.WriteTo(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "16/10/2026, 14:05 - Budi: hello, \"bot\"\nsecond line\n" +
+		"16/10/2026, 14:06 - +62899: hi Budi <Langchain execution 7: success, 1200 ms>\n" +
+		// Group chats name the sender by number, not the group's name.
+		"16/10/2026, 14:07 - +62822: <Media omitted>\n"
+	if out.String() != want {
+		t.Errorf("transcript =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestExportSender(t *testing.T) {
+	str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: s != ""} }
+	tests := []struct {
+		direction, from, chat, chatName string
+		want                            string
+	}{
+		{"incoming", "62811", "62811@s.whatsapp.net", "Budi", "Budi"},
+		{"incoming", "62811", "62811@s.whatsapp.net", "", "+62811"},
+		{"incoming", "62811", "1203@g.us", "Family", "+62811"},
+		{"outgoing", "62899", "62811@s.whatsapp.net", "Budi", "+62899"},
+		{"incoming", "", "", "", "unknown"},
+	}
+	for _, tt := range tests {
+		m := &entity.ExportedMessage{
+			Message: entity.Message{
+				Direction:  str(tt.direction),
+				FromNumber: str(tt.from),
+				Metadata:   []byte(`{"chat":"` + tt.chat + `"}`),
+			},
+			ChatName: str(tt.chatName),
+		}
+		if got := exportSender(m); got != tt.want {
+			t.Errorf("exportSender(%s from %q in %q named %q) = %q, want %q", tt.direction, tt.from, tt.chat, tt.chatName, got, tt.want)
+		}
+	}
+}
+
+func TestExportReadsInBatches(t *testing.T) {
+	var messages []*entity.ExportedMessage
+	at := time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC)
+	for id := 1; id <= 5; id++ {
+		messages = append(messages, &entity.ExportedMessage{Message: entity.Message{
+			ID: id, MessageText: sql.NullString{String: "m", Valid: true}, CreatedAt: at.Add(time.Duration(id) * time.Minute),
+		}})
+	}
+	export, repo := newTestExport(ExportFormatJSONL, messages, 2)
+	var out bytes.Buffer
+	if err := export.WriteTo(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(out.String(), "\n"); n != 5 {
+		t.Errorf("wrote %d records, want 5", n)
+	}
+	if len(repo.filters) != 3 {
+		t.Fatalf("made %d queries, want 3", len(repo.filters))
+	}
+	for i, wantID := range []int{0, 2, 4} {
+		f := repo.filters[i]
+		if f.AfterID != wantID || f.Limit != 2 || f.SessionID != 1 {
+			t.Errorf("query %d filter = %+v, want AfterID %d", i, f, wantID)
+		}
+		if wantID > 0 && !f.AfterTime.Equal(messages[wantID-1].CreatedAt) {
+			t.Errorf("query %d AfterTime = %s, want %s", i, f.AfterTime, messages[wantID-1].CreatedAt)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("client went away")
+}
+
+func TestExportStopsOnWriteError(t *testing.T) {
+	for _, format := range []string{ExportFormatJSONL, ExportFormatCSV, ExportFormatText} {
+		export, repo := newTestExport(format, exportFixture(), 1)
+		if err := export.WriteTo(context.Background(), failingWriter{}); err == nil {
+			t.Errorf("%s: WriteTo succeeded on a failing writer", format)
+		}
+		if len(repo.filters) > 1 {
+			t.Errorf("%s: kept reading after the write failed (%d queries)", format, len(repo.filters))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/internal/infrastructure/whatsapp"
+)
+
+type HealthUseCase struct {
+	healthRepo  repository.HealthRepository
+	sessionRepo repository.SessionRepository
+	waManager   *whatsapp.ClientManager
+	sessionUC   *SessionUseCase
+}
+
+func NewHealthUseCase(healthRepo repository.HealthRepository, sessionRepo repository.SessionRepository, waManager *whatsapp.ClientManager, sessionUC *SessionUseCase) *HealthUseCase {
+	return &HealthUseCase{
+		healthRepo:  healthRepo,
+		sessionRepo: sessionRepo,
+		waManager:   waManager,
+		sessionUC:   sessionUC,
+	}
+}
+
+// ReadinessCheck is the outcome of one dependency check; Error is empty
+// when it passed.
+type ReadinessCheck struct {
+	Name  string
+	Error string
+}
+
+// Ready checks Postgres, the whatsmeow device store and that an object each
+// migration creates exists. Later checks are skipped when the database is
+// down.
+func (uc *HealthUseCase) Ready(ctx context.Context) ([]ReadinessCheck, bool) {
+	if err := uc.healthRepo.Ping(ctx); err != nil {
+		return []ReadinessCheck{
+			{Name: "database", Error: err.Error()},
+			{Name: "whatsmeowStore", Error: "skipped: database unavailable"},
+			{Name: "migrations", Error: "skipped: database unavailable"},
+		}, false
+	}
+	checks := []ReadinessCheck{{Name: "database"}}
+	ready := true
+
+	store := ReadinessCheck{Name: "whatsmeowStore"}
+	if err := uc.waManager.Ping(ctx); err != nil {
+		store.Error = err.Error()
+		ready = false
+	}
+	checks = append(checks, store)
+
+	migrations := ReadinessCheck{Name: "migrations"}
+	missing, err := uc.healthRepo.MissingMigrations(ctx)
+	switch {
+	case err != nil:
+		migrations.Error = err.Error()
+	case len(missing) > 0:
+		migrations.Error = "not applied: " + strings.Join(missing, ", ")
+	}
+	if migrations.Error != "" {
+		ready = false
+	}
+	checks = append(checks, migrations)
+
+	return checks, ready
+}
+
+// SessionHealth compares the status stored for a session with its live
+// whatsmeow client. Consistent is false when the database claims the
+// session is connected but the client is not connected and logged in, or
+// the other way round.
+type SessionHealth struct {
+	AgentID    string
+	DBStatus   string
+	Client     ClientState
+	Consistent bool
+}
+
+// Sessions reports every session the caller can see; admins see all.
+func (uc *HealthUseCase) Sessions(ctx context.Context, caller Caller) ([]*SessionHealth, error) {
+	var sessions []*entity.Session
+	var err error
+	if caller.IsAdmin {
+		sessions, err = uc.sessionRepo.GetAllSessions(ctx)
+	} else {
+		sessions, err = uc.sessionRepo.GetByUserID(ctx, caller.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	health := make([]*SessionHealth, 0, len(sessions))
+	for _, session := range sessions {
+		if !caller.CanAccessAgent(session.AgentID) {
+			continue
+		}
+		state := uc.sessionUC.ClientState(session.AgentID)
+		live := state.Connected && state.LoggedIn
+		health = append(health, &SessionHealth{
+			AgentID:    session.AgentID,
+			DBStatus:   session.Status,
+			Client:     state,
+			Consistent: live == (session.Status == "connected"),
+		})
+	}
+	return health, nil
+}
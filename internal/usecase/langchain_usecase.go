@@ -3,7 +3,9 @@ package usecase
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"whatsapp-api/internal/domain/entity"
@@ -11,9 +13,15 @@ import (
 	"whatsapp-api/internal/infrastructure/langchain"
 )
 
+var (
+	ErrExecutionNotFound      = errors.New("langchain execution not found")
+	ErrExecutionNotReplayable = errors.New("langchain execution has no user message to replay")
+)
+
 type LangchainUseCase struct {
 	sessionRepo         repository.SessionRepository
 	langchainRepo       repository.LangchainRepository
+	messageRepo         repository.MessageRepository
 	langchainClient     *langchain.Client
 	defaultLangchainURL string
 	defaultParams       map[string]interface{}
@@ -22,6 +30,7 @@ type LangchainUseCase struct {
 func NewLangchainUseCase(
 	sessionRepo repository.SessionRepository,
 	langchainRepo repository.LangchainRepository,
+	messageRepo repository.MessageRepository,
 	client *langchain.Client,
 	defaultLangchainURL string,
 	defaultParams map[string]interface{},
@@ -29,13 +38,36 @@ func NewLangchainUseCase(
 	return &LangchainUseCase{
 		sessionRepo:         sessionRepo,
 		langchainRepo:       langchainRepo,
+		messageRepo:         messageRepo,
 		langchainClient:     client,
 		defaultLangchainURL: defaultLangchainURL,
 		defaultParams:       defaultParams,
 	}
 }
 
+// ExecuteForUser runs Execute on behalf of an API user, who must own the session.
+func (uc *LangchainUseCase) ExecuteForUser(ctx context.Context, caller Caller, agentID, userMessage, sender string, overrideParams map[string]interface{}) (*entity.LangchainExecution, error) {
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+		return nil, err
+	}
+	return uc.Execute(ctx, agentID, userMessage, sender, overrideParams)
+}
+
 func (uc *LangchainUseCase) Execute(ctx context.Context, agentID, userMessage, sender string, overrideParams map[string]interface{}) (*entity.LangchainExecution, error) {
+	return uc.run(ctx, agentID, userMessage, sender, overrideParams, func(*entity.LangchainExecution) {})
+}
+
+// ExecuteForMessage runs Execute for an incoming message and records the
+// message row as the execution's trigger.
+func (uc *LangchainUseCase) ExecuteForMessage(ctx context.Context, agentID, userMessage, sender string, overrideParams map[string]interface{}, messageID sql.NullInt64) (*entity.LangchainExecution, error) {
+	return uc.run(ctx, agentID, userMessage, sender, overrideParams, func(exec *entity.LangchainExecution) {
+		exec.MessageID = messageID
+	})
+}
+
+// run executes against the session's current Langchain config and stores
+// the result; link fills in how the execution came about before it is saved.
+func (uc *LangchainUseCase) run(ctx context.Context, agentID, userMessage, sender string, overrideParams map[string]interface{}, link func(*entity.LangchainExecution)) (*entity.LangchainExecution, error) {
 	session, err := uc.sessionRepo.GetByAgentID(ctx, agentID)
 	if err != nil {
 		return nil, err
@@ -94,12 +126,14 @@ func (uc *LangchainUseCase) Execute(ctx context.Context, agentID, userMessage, s
 		SessionID:         session.ID,
 		AgentID:           agentID,
 		UserMessage:       sql.NullString{String: userMessage, Valid: userMessage != ""},
+		Sender:            sql.NullString{String: sender, Valid: sender != ""},
 		LangchainResponse: respBody,
 		ExecutionTimeMs:   execTime,
 		Status:            status,
 		ErrorMessage:      errMsg,
 		CreatedAt:         time.Now(),
 	}
+	link(execution)
 
 	if errCreate := uc.langchainRepo.Create(ctx, execution); errCreate != nil {
 		return nil, errCreate
@@ -111,6 +145,99 @@ func (uc *LangchainUseCase) Execute(ctx context.Context, agentID, userMessage, s
 	return execution, nil
 }
 
+// ListExecutionsInput filters a session's Langchain executions. Empty
+// fields do not filter.
+type ListExecutionsInput struct {
+	Caller  Caller
+	AgentID string
+	Status  string
+	Sender  string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+	Offset  int
+}
+
+// ExecutionDetail is an execution with the incoming message that triggered
+// it and the replies sent from its response.
+type ExecutionDetail struct {
+	Execution *entity.LangchainExecution
+	Incoming  *entity.Message
+	Replies   []*entity.Message
+}
+
+// ListExecutions returns a session's Langchain executions, newest first.
+func (uc *LangchainUseCase) ListExecutions(ctx context.Context, in ListExecutionsInput) ([]*entity.LangchainExecution, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.LangchainExecutionFilter{
+		SessionID: session.ID,
+		Status:    in.Status,
+		Sender:    strings.TrimSpace(in.Sender),
+		Limit:     in.Limit,
+		Offset:    in.Offset,
+	}
+	filter.Since, filter.Until = localRange(in.Since, in.Until)
+	return uc.langchainRepo.Find(ctx, filter)
+}
+
+// GetExecution returns one of the caller's executions with its messages.
+func (uc *LangchainUseCase) GetExecution(ctx context.Context, caller Caller, id int) (*ExecutionDetail, error) {
+	exec, err := uc.ownedExecution(ctx, caller, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &ExecutionDetail{Execution: exec}
+	if exec.MessageID.Valid {
+		if detail.Incoming, err = uc.messageRepo.GetByID(ctx, int(exec.MessageID.Int64)); err != nil {
+			return nil, err
+		}
+	}
+	if detail.Replies, err = uc.messageRepo.GetByLangchainExecutionID(ctx, exec.ID); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// ReplayExecution re-runs a stored execution's user message against the
+// session's current Langchain URL, API key and default params, so prompt
+// changes can be compared. The new execution is stored with ReplayOf set;
+// nothing is sent on WhatsApp.
+func (uc *LangchainUseCase) ReplayExecution(ctx context.Context, caller Caller, id int) (*entity.LangchainExecution, error) {
+	original, err := uc.ownedExecution(ctx, caller, id)
+	if err != nil {
+		return nil, err
+	}
+	if !original.UserMessage.Valid || original.UserMessage.String == "" {
+		return nil, ErrExecutionNotReplayable
+	}
+
+	return uc.run(ctx, original.AgentID, original.UserMessage.String, original.Sender.String, nil, func(exec *entity.LangchainExecution) {
+		exec.ReplayOf = sql.NullInt64{Int64: int64(original.ID), Valid: true}
+	})
+}
+
+func (uc *LangchainUseCase) ownedExecution(ctx context.Context, caller Caller, id int) (*entity.LangchainExecution, error) {
+	exec, err := uc.langchainRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if exec == nil {
+		return nil, ErrExecutionNotFound
+	}
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, exec.AgentID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrExecutionNotFound
+		}
+		return nil, err
+	}
+	return exec, nil
+}
+
 func resultDurationMs(result *langchain.ExecuteResult) int64 {
 	if result == nil {
 		return 0
@@ -0,0 +1,348 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// incomingContent is what the pipeline understands about an incoming
+// message: the text to store and forward to Langchain, and for anything that
+// is not plain text a structured description of the attachment.
+type incomingContent struct {
+	Type        string
+	Text        string
+	Attachment  map[string]interface{}
+	Media       *incomingMedia
+	ContextInfo *waProto.ContextInfo
+}
+
+// incomingMedia describes a downloadable attachment on an incoming message.
+type incomingMedia struct {
+	Type     string
+	Message  whatsmeow.DownloadableMessage
+	Mimetype string
+	FileName string
+	Caption  string
+	Length   uint64
+}
+
+// describeMessage derives the textual input and attachment description for an
+// incoming message. It returns nil for messages the pipeline does not handle
+// (protocol messages, reactions, ...).
+func (uc *SessionUseCase) describeMessage(agentID string, evt *events.Message) *incomingContent {
+	if evt == nil || evt.Message == nil {
+		return nil
+	}
+	msg := evt.Message
+
+	if conv := msg.GetConversation(); conv != "" {
+		return &incomingContent{Type: "text", Text: conv}
+	}
+	if ext := msg.GetExtendedTextMessage(); ext != nil && ext.GetText() != "" {
+		return &incomingContent{Type: "text", Text: ext.GetText(), ContextInfo: ext.GetContextInfo()}
+	}
+
+	if media, ctxInfo := mediaFromMessage(msg); media != nil {
+		attachment := map[string]interface{}{
+			"type":     media.Type,
+			"mimetype": media.Mimetype,
+			"size":     media.Length,
+		}
+		if media.FileName != "" {
+			attachment["fileName"] = media.FileName
+		}
+		if media.Caption != "" {
+			attachment["caption"] = media.Caption
+		}
+		text := media.Caption
+		if text == "" {
+			text = mediaPlaceholder(media)
+		}
+		return &incomingContent{Type: media.Type, Text: text, Attachment: attachment, Media: media, ContextInfo: ctxInfo}
+	}
+
+	if loc := msg.GetLocationMessage(); loc != nil {
+		attachment := map[string]interface{}{
+			"type":      "location",
+			"latitude":  loc.GetDegreesLatitude(),
+			"longitude": loc.GetDegreesLongitude(),
+		}
+		if loc.GetName() != "" {
+			attachment["name"] = loc.GetName()
+		}
+		if loc.GetAddress() != "" {
+			attachment["address"] = loc.GetAddress()
+		}
+		text := fmt.Sprintf("[Location] %.6f, %.6f", loc.GetDegreesLatitude(), loc.GetDegreesLongitude())
+		if label := strings.TrimSpace(loc.GetName() + " " + loc.GetAddress()); label != "" {
+			text = fmt.Sprintf("[Location] %s (%.6f, %.6f)", label, loc.GetDegreesLatitude(), loc.GetDegreesLongitude())
+		}
+		return &incomingContent{Type: "location", Text: text, Attachment: attachment, ContextInfo: loc.GetContextInfo()}
+	}
+	if live := msg.GetLiveLocationMessage(); live != nil {
+		attachment := map[string]interface{}{
+			"type":      "live_location",
+			"latitude":  live.GetDegreesLatitude(),
+			"longitude": live.GetDegreesLongitude(),
+		}
+		text := fmt.Sprintf("[Live location] %.6f, %.6f", live.GetDegreesLatitude(), live.GetDegreesLongitude())
+		if live.GetCaption() != "" {
+			attachment["caption"] = live.GetCaption()
+			text = live.GetCaption() + "\n" + text
+		}
+		return &incomingContent{Type: "location", Text: text, Attachment: attachment, ContextInfo: live.GetContextInfo()}
+	}
+
+	if contact := msg.GetContactMessage(); contact != nil {
+		card := describeContact(contact)
+		return &incomingContent{
+			Type:        "contact",
+			Text:        "[Contact] " + contactLine(card),
+			Attachment:  map[string]interface{}{"type": "contact", "contacts": []map[string]interface{}{card}},
+			ContextInfo: contact.GetContextInfo(),
+		}
+	}
+	if contacts := msg.GetContactsArrayMessage(); contacts != nil {
+		cards := make([]map[string]interface{}, 0, len(contacts.GetContacts()))
+		lines := make([]string, 0, len(contacts.GetContacts()))
+		for _, c := range contacts.GetContacts() {
+			card := describeContact(c)
+			cards = append(cards, card)
+			lines = append(lines, contactLine(card))
+		}
+		return &incomingContent{
+			Type:        "contact",
+			Text:        "[Contacts] " + strings.Join(lines, "; "),
+			Attachment:  map[string]interface{}{"type": "contact", "contacts": cards},
+			ContextInfo: contacts.GetContextInfo(),
+		}
+	}
+
+	if btn := msg.GetButtonsResponseMessage(); btn != nil {
+		return &incomingContent{
+			Type: "button_response",
+			Text: btn.GetSelectedDisplayText(),
+			Attachment: map[string]interface{}{
+				"type":       "button_response",
+				"selectedId": btn.GetSelectedButtonID(),
+				"text":       btn.GetSelectedDisplayText(),
+			},
+			ContextInfo: btn.GetContextInfo(),
+		}
+	}
+	if tpl := msg.GetTemplateButtonReplyMessage(); tpl != nil {
+		return &incomingContent{
+			Type: "button_response",
+			Text: tpl.GetSelectedDisplayText(),
+			Attachment: map[string]interface{}{
+				"type":       "button_response",
+				"selectedId": tpl.GetSelectedID(),
+				"text":       tpl.GetSelectedDisplayText(),
+			},
+			ContextInfo: tpl.GetContextInfo(),
+		}
+	}
+	if list := msg.GetListResponseMessage(); list != nil {
+		return &incomingContent{
+			Type: "list_response",
+			Text: list.GetTitle(),
+			Attachment: map[string]interface{}{
+				"type":        "list_response",
+				"selectedId":  list.GetSingleSelectReply().GetSelectedRowID(),
+				"title":       list.GetTitle(),
+				"description": list.GetDescription(),
+			},
+			ContextInfo: list.GetContextInfo(),
+		}
+	}
+	if interactive := msg.GetInteractiveResponseMessage(); interactive != nil {
+		attachment := map[string]interface{}{
+			"type": "interactive_response",
+			"text": interactive.GetBody().GetText(),
+		}
+		if flow := interactive.GetNativeFlowResponseMessage(); flow != nil {
+			attachment["name"] = flow.GetName()
+			var params interface{}
+			if json.Unmarshal([]byte(flow.GetParamsJSON()), &params) == nil {
+				attachment["params"] = params
+			}
+		}
+		return &incomingContent{
+			Type:        "interactive_response",
+			Text:        interactive.GetBody().GetText(),
+			Attachment:  attachment,
+			ContextInfo: interactive.GetContextInfo(),
+		}
+	}
+
+	if poll := pollCreation(msg); poll != nil {
+		options := make([]string, 0, len(poll.GetOptions()))
+		for _, opt := range poll.GetOptions() {
+			options = append(options, opt.GetOptionName())
+		}
+		return &incomingContent{
+			Type: "poll",
+			Text: fmt.Sprintf("[Poll] %s: %s", poll.GetName(), strings.Join(options, " / ")),
+			Attachment: map[string]interface{}{
+				"type":        "poll",
+				"question":    poll.GetName(),
+				"pollOptions": options,
+			},
+			ContextInfo: poll.GetContextInfo(),
+		}
+	}
+	if msg.GetPollUpdateMessage() != nil {
+		return uc.describePollVote(agentID, evt)
+	}
+
+	return nil
+}
+
+// describePollVote decrypts a poll vote and maps the selected option hashes
+// back to names using the stored poll, when we have seen it.
+func (uc *SessionUseCase) describePollVote(agentID string, evt *events.Message) *incomingContent {
+	uc.mu.RLock()
+	client := uc.clients[agentID]
+	uc.mu.RUnlock()
+	if client == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	vote, err := client.DecryptPollVote(ctx, evt)
+	if err != nil {
+		log.Printf("failed to decrypt poll vote for agent %s: %v", agentID, err)
+		return nil
+	}
+
+	pollID := evt.Message.GetPollUpdateMessage().GetPollCreationMessageKey().GetID()
+	attachment := map[string]interface{}{
+		"type":   "poll_vote",
+		"pollId": pollID,
+	}
+
+	var optionNames map[string]string
+	if uc.messageRepo != nil {
+		if poll, _ := uc.messageRepo.GetByMessageID(ctx, agentID, pollID); poll != nil {
+			var meta struct {
+				Question    string   `json:"question"`
+				PollOptions []string `json:"pollOptions"`
+			}
+			if json.Unmarshal(poll.Metadata, &meta) == nil {
+				attachment["question"] = meta.Question
+				optionNames = make(map[string]string, len(meta.PollOptions))
+				for _, name := range meta.PollOptions {
+					sum := sha256.Sum256([]byte(name))
+					optionNames[hex.EncodeToString(sum[:])] = name
+				}
+			}
+		}
+	}
+
+	selected := make([]string, 0, len(vote.GetSelectedOptions()))
+	for _, hash := range vote.GetSelectedOptions() {
+		key := hex.EncodeToString(hash)
+		if name, ok := optionNames[key]; ok {
+			selected = append(selected, name)
+		} else {
+			selected = append(selected, key)
+		}
+	}
+	attachment["selectedOptions"] = selected
+
+	text := "[Poll vote] " + strings.Join(selected, " / ")
+	if len(selected) == 0 {
+		text = "[Poll vote] (vote removed)"
+	}
+	return &incomingContent{Type: "poll_vote", Text: text, Attachment: attachment}
+}
+
+func mediaFromMessage(msg *waProto.Message) (*incomingMedia, *waProto.ContextInfo) {
+	if img := msg.GetImageMessage(); img != nil {
+		return &incomingMedia{Type: "image", Message: img, Mimetype: img.GetMimetype(), Caption: img.GetCaption(), Length: img.GetFileLength()}, img.GetContextInfo()
+	}
+	if vid := msg.GetVideoMessage(); vid != nil {
+		return &incomingMedia{Type: "video", Message: vid, Mimetype: vid.GetMimetype(), Caption: vid.GetCaption(), Length: vid.GetFileLength()}, vid.GetContextInfo()
+	}
+	if audio := msg.GetAudioMessage(); audio != nil {
+		return &incomingMedia{Type: "audio", Message: audio, Mimetype: audio.GetMimetype(), Length: audio.GetFileLength()}, audio.GetContextInfo()
+	}
+	doc := msg.GetDocumentMessage()
+	if doc == nil {
+		// Documents sent with a caption arrive wrapped in a FutureProofMessage.
+		doc = msg.GetDocumentWithCaptionMessage().GetMessage().GetDocumentMessage()
+	}
+	if doc != nil {
+		return &incomingMedia{Type: "document", Message: doc, Mimetype: doc.GetMimetype(), FileName: doc.GetFileName(), Caption: doc.GetCaption(), Length: doc.GetFileLength()}, doc.GetContextInfo()
+	}
+	if sticker := msg.GetStickerMessage(); sticker != nil {
+		return &incomingMedia{Type: "sticker", Message: sticker, Mimetype: sticker.GetMimetype(), Length: sticker.GetFileLength()}, sticker.GetContextInfo()
+	}
+	return nil, nil
+}
+
+func mediaPlaceholder(media *incomingMedia) string {
+	switch media.Type {
+	case "audio":
+		return "[Voice note]"
+	case "document":
+		if media.FileName != "" {
+			return "[Document] " + media.FileName
+		}
+		return "[Document]"
+	default:
+		return "[" + strings.ToUpper(media.Type[:1]) + media.Type[1:] + "]"
+	}
+}
+
+func pollCreation(msg *waProto.Message) *waProto.PollCreationMessage {
+	if poll := msg.GetPollCreationMessage(); poll != nil {
+		return poll
+	}
+	if poll := msg.GetPollCreationMessageV2(); poll != nil {
+		return poll
+	}
+	return msg.GetPollCreationMessageV3()
+}
+
+func describeContact(contact *waProto.ContactMessage) map[string]interface{} {
+	card := map[string]interface{}{"name": contact.GetDisplayName()}
+	if phones := vcardPhones(contact.GetVcard()); len(phones) > 0 {
+		card["phones"] = phones
+	}
+	return card
+}
+
+func contactLine(card map[string]interface{}) string {
+	line, _ := card["name"].(string)
+	if phones, ok := card["phones"].([]string); ok {
+		line += " " + strings.Join(phones, ", ")
+	}
+	return strings.TrimSpace(line)
+}
+
+// vcardPhones pulls the phone numbers out of TEL lines such as
+// "TEL;type=CELL;waid=628123:+62 812-3".
+func vcardPhones(vcard string) []string {
+	var phones []string
+	for _, line := range strings.Split(vcard, "\n") {
+		line = strings.TrimSpace(line)
+		upper := strings.ToUpper(line)
+		if !strings.HasPrefix(upper, "TEL") && !strings.Contains(upper, ".TEL") {
+			continue
+		}
+		if idx := strings.LastIndex(line, ":"); idx >= 0 && idx < len(line)-1 {
+			phones = append(phones, strings.TrimSpace(line[idx+1:]))
+		}
+	}
+	return phones
+}
@@ -0,0 +1,662 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/internal/infrastructure/storage"
+	"whatsapp-api/internal/infrastructure/whatsapp"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionNotConnected = errors.New("session is not connected")
+	ErrInvalidRecipient    = errors.New("invalid recipient")
+	ErrInvalidMedia        = errors.New("invalid media")
+	ErrMediaNotFound       = errors.New("media not found")
+	ErrMessageNotFound     = errors.New("message not found")
+	ErrInvalidFilter       = errors.New("invalid filter")
+)
+
+// maxMediaSize caps uploads and URL downloads; WhatsApp itself rejects most
+// media above this size.
+const maxMediaSize = 64 << 20
+
+var mediaUploadTypes = map[string]whatsmeow.MediaType{
+	"image":    whatsmeow.MediaImage,
+	"document": whatsmeow.MediaDocument,
+	"audio":    whatsmeow.MediaAudio,
+	"video":    whatsmeow.MediaVideo,
+	"sticker":  whatsmeow.MediaImage,
+}
+
+type MessageUseCase struct {
+	sessionRepo repository.SessionRepository
+	messageRepo repository.MessageRepository
+	sessionUC   *SessionUseCase
+	mediaStore  storage.BlobStore
+	httpClient  *http.Client
+}
+
+func NewMessageUseCase(
+	sessionRepo repository.SessionRepository,
+	messageRepo repository.MessageRepository,
+	sessionUC *SessionUseCase,
+	mediaStore storage.BlobStore,
+) *MessageUseCase {
+	return &MessageUseCase{
+		sessionRepo: sessionRepo,
+		messageRepo: messageRepo,
+		sessionUC:   sessionUC,
+		mediaStore:  mediaStore,
+		httpClient:  newMediaHTTPClient(60 * time.Second),
+	}
+}
+
+type SendTextInput struct {
+	Caller          Caller
+	AgentID         string
+	To              string
+	Text            string
+	QuotedMessageID string
+	// Bulk queues the message behind regular sends.
+	Bulk bool
+}
+
+// SendText queues a plain text message on the agent's WhatsApp session. It
+// is stored as an outgoing row in messages once sent.
+func (uc *MessageUseCase) SendText(ctx context.Context, in SendTextInput) (*entity.OutboundMessage, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parseRecipient(in.To)
+	if err != nil {
+		return nil, err
+	}
+
+	out := outboundMessage{
+		To:      to,
+		Message: &waProto.Message{Conversation: proto.String(in.Text)},
+		Text:    in.Text,
+	}
+	if in.QuotedMessageID != "" {
+		out.Message = &waProto.Message{
+			ExtendedTextMessage: &waProto.ExtendedTextMessage{
+				Text: proto.String(in.Text),
+			},
+		}
+		out.Message.ExtendedTextMessage.ContextInfo, out.ReplyToID = uc.resolveQuote(ctx, in.AgentID, in.QuotedMessageID)
+		out.Metadata = map[string]interface{}{"quotedMessageId": in.QuotedMessageID}
+	}
+
+	return uc.sessionUC.sendMessage(ctx, session, out, sendPriority(in.Bulk))
+}
+
+// SendTyping shows or clears the typing indicator in a chat.
+func (uc *MessageUseCase) SendTyping(ctx context.Context, caller Caller, agentID, to string, typing bool) error {
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+		return err
+	}
+	jid, err := parseRecipient(to)
+	if err != nil {
+		return err
+	}
+	client, err := uc.sessionUC.connectedClient(agentID)
+	if err != nil {
+		return err
+	}
+
+	state := types.ChatPresencePaused
+	if typing {
+		state = types.ChatPresenceComposing
+	}
+	return client.SendChatPresence(ctx, jid, state, types.ChatPresenceMediaText)
+}
+
+type SendMediaInput struct {
+	Caller  Caller
+	AgentID string
+	To      string
+	// Type is one of image, document, audio, video or sticker.
+	Type string
+	// Data holds the raw file; when empty the file is fetched from URL.
+	Data            []byte
+	URL             string
+	MimeType        string
+	FileName        string
+	Caption         string
+	QuotedMessageID string
+	// VoiceNote marks audio as push-to-talk so it renders as a voice message.
+	VoiceNote bool
+	Bulk      bool
+}
+
+// SendMedia uploads a file through the session's client and queues it as an
+// image, document, audio, video or sticker message.
+func (uc *MessageUseCase) SendMedia(ctx context.Context, in SendMediaInput) (*entity.OutboundMessage, error) {
+	uploadType, ok := mediaUploadTypes[in.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidMedia, in.Type)
+	}
+
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parseRecipient(in.To)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := uc.sessionUC.connectedClient(in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := in.Data
+	remoteType := ""
+	if len(data) == 0 && in.URL != "" {
+		data, remoteType, err = uc.fetchMedia(ctx, in.URL)
+		if err != nil {
+			return nil, err
+		}
+		if in.FileName == "" {
+			in.FileName = path.Base(in.URL)
+		}
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidMedia)
+	}
+	if len(data) > maxMediaSize {
+		return nil, fmt.Errorf("%w: file exceeds %d bytes", ErrInvalidMedia, maxMediaSize)
+	}
+
+	mimetype := detectMimeType(in, remoteType, data)
+
+	upload, err := client.Upload(ctx, data, uploadType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload media: %w", err)
+	}
+
+	var contextInfo *waProto.ContextInfo
+	var replyToID sql.NullInt64
+	if in.QuotedMessageID != "" {
+		contextInfo, replyToID = uc.resolveQuote(ctx, in.AgentID, in.QuotedMessageID)
+	}
+
+	meta := map[string]interface{}{
+		"mimetype":   mimetype,
+		"fileLength": upload.FileLength,
+		"fileSha256": hex.EncodeToString(upload.FileSHA256),
+		"directPath": upload.DirectPath,
+	}
+	if in.FileName != "" {
+		meta["fileName"] = in.FileName
+	}
+	if in.QuotedMessageID != "" {
+		meta["quotedMessageId"] = in.QuotedMessageID
+	}
+
+	msg := &waProto.Message{}
+	switch in.Type {
+	case "image":
+		thumb, width, height, err := whatsapp.GenerateThumbnail(data)
+		if err != nil {
+			log.Printf("failed to generate image thumbnail: %v", err)
+		}
+		meta["width"], meta["height"] = width, height
+		msg.ImageMessage = &waProto.ImageMessage{
+			URL:           proto.String(upload.URL),
+			DirectPath:    proto.String(upload.DirectPath),
+			MediaKey:      upload.MediaKey,
+			FileEncSHA256: upload.FileEncSHA256,
+			FileSHA256:    upload.FileSHA256,
+			FileLength:    proto.Uint64(upload.FileLength),
+			Mimetype:      proto.String(mimetype),
+			Caption:       optionalString(in.Caption),
+			JPEGThumbnail: thumb,
+			Width:         proto.Uint32(uint32(width)),
+			Height:        proto.Uint32(uint32(height)),
+			ContextInfo:   contextInfo,
+		}
+	case "document":
+		fileName := fallbackString(in.FileName, "file")
+		meta["fileName"] = fileName
+		msg.DocumentMessage = &waProto.DocumentMessage{
+			URL:           proto.String(upload.URL),
+			DirectPath:    proto.String(upload.DirectPath),
+			MediaKey:      upload.MediaKey,
+			FileEncSHA256: upload.FileEncSHA256,
+			FileSHA256:    upload.FileSHA256,
+			FileLength:    proto.Uint64(upload.FileLength),
+			Mimetype:      proto.String(mimetype),
+			FileName:      proto.String(fileName),
+			Title:         proto.String(fileName),
+			Caption:       optionalString(in.Caption),
+			ContextInfo:   contextInfo,
+		}
+	case "audio":
+		meta["voiceNote"] = in.VoiceNote
+		msg.AudioMessage = &waProto.AudioMessage{
+			URL:           proto.String(upload.URL),
+			DirectPath:    proto.String(upload.DirectPath),
+			MediaKey:      upload.MediaKey,
+			FileEncSHA256: upload.FileEncSHA256,
+			FileSHA256:    upload.FileSHA256,
+			FileLength:    proto.Uint64(upload.FileLength),
+			Mimetype:      proto.String(mimetype),
+			PTT:           proto.Bool(in.VoiceNote),
+			ContextInfo:   contextInfo,
+		}
+	case "video":
+		msg.VideoMessage = &waProto.VideoMessage{
+			URL:           proto.String(upload.URL),
+			DirectPath:    proto.String(upload.DirectPath),
+			MediaKey:      upload.MediaKey,
+			FileEncSHA256: upload.FileEncSHA256,
+			FileSHA256:    upload.FileSHA256,
+			FileLength:    proto.Uint64(upload.FileLength),
+			Mimetype:      proto.String(mimetype),
+			Caption:       optionalString(in.Caption),
+			ContextInfo:   contextInfo,
+		}
+	case "sticker":
+		msg.StickerMessage = &waProto.StickerMessage{
+			URL:           proto.String(upload.URL),
+			DirectPath:    proto.String(upload.DirectPath),
+			MediaKey:      upload.MediaKey,
+			FileEncSHA256: upload.FileEncSHA256,
+			FileSHA256:    upload.FileSHA256,
+			FileLength:    proto.Uint64(upload.FileLength),
+			Mimetype:      proto.String(mimetype),
+			ContextInfo:   contextInfo,
+		}
+	}
+
+	caption := in.Caption
+	if in.Type == "sticker" || in.Type == "audio" {
+		// These message types have no caption field on WhatsApp.
+		caption = ""
+	}
+
+	return uc.sessionUC.sendMessage(ctx, session, outboundMessage{
+		To:        to,
+		Message:   msg,
+		Text:      caption,
+		Type:      in.Type,
+		ReplyToID: replyToID,
+		Metadata:  meta,
+	}, sendPriority(in.Bulk))
+}
+
+// ListQueue returns a session's outbound queue items, newest first.
+func (uc *MessageUseCase) ListQueue(ctx context.Context, caller Caller, agentID, status string, limit, offset int) ([]*entity.OutboundMessage, error) {
+	return uc.sessionUC.ListOutbound(ctx, caller, agentID, status, limit, offset)
+}
+
+// ListMessagesInput filters a session's message history. Empty fields do
+// not filter; Cursor continues from a previous page.
+type ListMessagesInput struct {
+	Caller    Caller
+	AgentID   string
+	Direction string
+	Type      string
+	// Contact is a phone number or JID on either side of the message.
+	Contact string
+	Chat    string
+	Status  string
+	Since   time.Time
+	Until   time.Time
+	Cursor  string
+	Limit   int
+}
+
+// ListMessages pages through a session's messages, newest first. The
+// returned cursor is empty on the last page.
+func (uc *MessageUseCase) ListMessages(ctx context.Context, in ListMessagesInput) ([]*entity.Message, string, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filter := repository.MessageFilter{
+		SessionID:   session.ID,
+		Direction:   in.Direction,
+		MessageType: in.Type,
+		Chat:        strings.TrimSpace(in.Chat),
+		Status:      in.Status,
+		Limit:       in.Limit,
+	}
+	if in.Contact != "" {
+		jid, err := parseRecipient(in.Contact)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid contact", ErrInvalidFilter)
+		}
+		filter.Contact = jid.User
+	}
+	filter.Since, filter.Until = localRange(in.Since, in.Until)
+	if in.Cursor != "" {
+		filter.BeforeTime, filter.BeforeID, err = decodeMessageCursor(in.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	messages, err := uc.messageRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(messages) == in.Limit && in.Limit > 0 {
+		last := messages[len(messages)-1]
+		next = encodeMessageCursor(last.CreatedAt, last.ID)
+	}
+	return messages, next, nil
+}
+
+// GetMessage returns one stored message of the caller's sessions.
+func (uc *MessageUseCase) GetMessage(ctx context.Context, caller Caller, id int) (*entity.Message, error) {
+	msg, err := uc.messageRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, ErrMessageNotFound
+	}
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, msg.AgentID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+// localRange converts a since/until filter to the server's time zone. The
+// TIMESTAMP columns hold the server's local wall clock and pgx writes
+// parameters by their wall clock, so filters must be in time.Local to line
+// up with the rows. Zero bounds stay zero.
+func localRange(since, until time.Time) (time.Time, time.Time) {
+	if !since.IsZero() {
+		since = since.Local()
+	}
+	if !until.IsZero() {
+		until = until.Local()
+	}
+	return since, until
+}
+
+// storedTime returns the instant a TIMESTAMP value read back by pgx stands
+// for. pgx labels the stored wall clock UTC, but it is the server's local
+// time, so it is rebuilt in time.Local before converting to other zones.
+func storedTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
+
+// Message cursors are "<created_at unix nanos>:<id>" in URL-safe base64.
+func encodeMessageCursor(createdAt time.Time, id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", createdAt.UnixNano(), id)))
+}
+
+func decodeMessageCursor(cursor string) (time.Time, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	var nanos int64
+	var id int
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &id); err != nil || id <= 0 {
+		return time.Time{}, 0, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	return time.Unix(0, nanos).UTC(), id, nil
+}
+
+func sendPriority(bulk bool) int {
+	if bulk {
+		return PriorityBulk
+	}
+	return PriorityNormal
+}
+
+type MediaObject struct {
+	Body     io.ReadCloser
+	MimeType string
+	FileName string
+}
+
+// OpenMedia opens the stored attachment of a message. The caller must close Body.
+func (uc *MessageUseCase) OpenMedia(ctx context.Context, caller Caller, id int) (*MediaObject, error) {
+	msg, err := uc.messageRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil || len(msg.Metadata) == 0 || uc.mediaStore == nil {
+		return nil, ErrMediaNotFound
+	}
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, msg.AgentID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+
+	var meta struct {
+		Path     string `json:"path"`
+		Mimetype string `json:"mimetype"`
+		FileName string `json:"fileName"`
+	}
+	if err := json.Unmarshal(msg.Metadata, &meta); err != nil || meta.Path == "" {
+		return nil, ErrMediaNotFound
+	}
+
+	body, err := uc.mediaStore.Open(ctx, meta.Path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := meta.FileName
+	if fileName == "" {
+		fileName = path.Base(meta.Path)
+	}
+	return &MediaObject{Body: body, MimeType: meta.Mimetype, FileName: fileName}, nil
+}
+
+// fetchMedia downloads a remote file for SendMedia, refusing anything larger
+// than maxMediaSize. Only http and https URLs on public addresses are
+// fetched. It returns the body and the server-reported content type.
+func (uc *MessageUseCase) fetchMedia(ctx context.Context, rawURL string) ([]byte, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, "", fmt.Errorf("%w: url must be http or https", ErrInvalidMedia)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidMedia, err)
+	}
+	resp, err := uc.httpClient.Do(req)
+	if err != nil {
+		if errors.Is(err, errBlockedAddress) {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidMedia, errBlockedAddress)
+		}
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("%w: download returned status %d", ErrInvalidMedia, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+var errBlockedAddress = errors.New("url resolves to a private or local address")
+
+// newMediaHTTPClient downloads user-supplied URLs. The address is checked
+// after DNS resolution, on every connection including redirects, so a
+// public name cannot point the server at localhost, the private network or
+// a cloud metadata endpoint. Proxies are not used since they would hide
+// the destination.
+func newMediaHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddr(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrInvalidMedia, req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// sharedAddressSpace is carrier-grade NAT (RFC 6598), private in practice.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() &&
+		ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// detectMimeType prefers an explicit mimetype, then the remote server's
+// content type, then the file extension and finally content sniffing.
+func detectMimeType(in SendMediaInput, remoteType string, data []byte) string {
+	if in.MimeType != "" {
+		return in.MimeType
+	}
+	if in.Type == "audio" && in.VoiceNote {
+		return "audio/ogg; codecs=opus"
+	}
+	if in.Type == "sticker" {
+		return "image/webp"
+	}
+	if remoteType != "" && !strings.HasPrefix(remoteType, "application/octet-stream") {
+		return remoteType
+	}
+	if ext := path.Ext(in.FileName); ext != "" {
+		if byExt := mime.TypeByExtension(ext); byExt != "" {
+			return byExt
+		}
+	}
+	return http.DetectContentType(data)
+}
+
+// resolveQuote returns the reply context for quotedID and, when the quoted
+// message is stored locally, its row ID for messages.reply_to_id.
+func (uc *MessageUseCase) resolveQuote(ctx context.Context, agentID, quotedID string) (*waProto.ContextInfo, sql.NullInt64) {
+	quoted, _ := uc.messageRepo.GetByMessageID(ctx, agentID, quotedID)
+	var replyToID sql.NullInt64
+	if quoted != nil {
+		replyToID = sql.NullInt64{Int64: int64(quoted.ID), Valid: true}
+	}
+	return quoteContext(quotedID, quoted), replyToID
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return proto.String(s)
+}
+
+// quoteContext builds the reply context for a quoted message. When the quoted
+// message is known locally its text and sender are attached so WhatsApp can
+// render the preview; otherwise only the stanza ID is referenced.
+func quoteContext(quotedID string, quoted *entity.Message) *waProto.ContextInfo {
+	info := &waProto.ContextInfo{StanzaID: proto.String(quotedID)}
+	if quoted == nil {
+		return info
+	}
+	if quoted.MessageText.Valid {
+		info.QuotedMessage = &waProto.Message{Conversation: proto.String(quoted.MessageText.String)}
+	}
+	if quoted.Direction.String == "incoming" && quoted.FromNumber.Valid {
+		info.Participant = proto.String(types.NewJID(quoted.FromNumber.String, types.DefaultUserServer).String())
+	}
+	return info
+}
+
+// parseRecipient accepts either a full JID (user or group) or a phone number
+// in international format, with or without a leading "+".
+func parseRecipient(to string) (types.JID, error) {
+	to = strings.TrimSpace(to)
+	if to == "" {
+		return types.JID{}, ErrInvalidRecipient
+	}
+
+	if strings.Contains(to, "@") {
+		jid, err := types.ParseJID(to)
+		if err != nil || jid.User == "" {
+			return types.JID{}, ErrInvalidRecipient
+		}
+		return jid, nil
+	}
+
+	phone := strings.NewReplacer("+", "", " ", "", "-", "").Replace(to)
+	if len(phone) < 6 {
+		return types.JID{}, ErrInvalidRecipient
+	}
+	for _, r := range phone {
+		if r < '0' || r > '9' {
+			return types.JID{}, ErrInvalidRecipient
+		}
+	}
+	return types.NewJID(phone, types.DefaultUserServer), nil
+}
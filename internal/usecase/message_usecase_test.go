@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestFetchMediaRejectsLocalAndNonHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer srv.Close()
+
+	uc := &MessageUseCase{httpClient: newMediaHTTPClient(5 * time.Second)}
+	for _, rawURL := range []string{srv.URL, "file:///etc/passwd", "gopher://example.com/", "http://"} {
+		if _, _, err := uc.fetchMedia(context.Background(), rawURL); !errors.Is(err, ErrInvalidMedia) {
+			t.Errorf("fetchMedia(%q) error = %v, want ErrInvalidMedia", rawURL, err)
+		}
+	}
+}
+
+func TestMessageCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		createdAt time.Time
+		id        int
+	}{
+		{time.Date(2026, 10, 16, 14, 5, 0, 0, time.UTC), 1},
+		{time.Date(2026, 10, 16, 14, 5, 0, 123456789, time.FixedZone("WIB", 7*3600)), 42},
+		{time.Date(1999, 12, 31, 23, 59, 59, 999000, time.UTC), 1 << 30},
+	}
+	for _, tt := range tests {
+		cursor := encodeMessageCursor(tt.createdAt, tt.id)
+		if strings.ContainsAny(cursor, "+/=") {
+			t.Errorf("cursor %q is not URL-safe", cursor)
+		}
+		createdAt, id, err := decodeMessageCursor(cursor)
+		if err != nil {
+			t.Fatalf("decodeMessageCursor(%q): %v", cursor, err)
+		}
+		if !createdAt.Equal(tt.createdAt) || id != tt.id {
+			t.Errorf("round trip of (%s, %d) = (%s, %d)", tt.createdAt, tt.id, createdAt, id)
+		}
+	}
+}
+
+func TestDecodeMessageCursorRejectsMalformed(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	for _, cursor := range []string{
+		"",
+		"not base64!",
+		encode("garbage"),
+		encode("1760623500000000000"),
+		encode("1760623500000000000:0"),
+		encode("1760623500000000000:-5"),
+		encode("x:5"),
+	} {
+		if _, _, err := decodeMessageCursor(cursor); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("decodeMessageCursor(%q) error = %v, want ErrInvalidFilter", cursor, err)
+		}
+	}
+}
+
+func TestLocalRange(t *testing.T) {
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+	time.Local = time.FixedZone("WIB", 7*3600)
+
+	since, until := localRange(time.Date(2026, 10, 16, 1, 0, 0, 0, time.UTC), time.Time{})
+	if since.Location() != time.Local || since.Hour() != 8 {
+		t.Errorf("since = %s, want 08:00 in the server's zone", since)
+	}
+	if !until.IsZero() {
+		t.Errorf("until = %s, want it left unset", until)
+	}
+}
@@ -0,0 +1,346 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+	"unicode/utf8"
+
+	"whatsapp-api/internal/domain/entity"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// Priority lanes of the outbound queue; lower values are sent first.
+const (
+	PriorityReply  = 0 // bot replies to incoming messages
+	PriorityNormal = 1 // API sends
+	PriorityBulk   = 2 // campaigns and other mass sends
+)
+
+const (
+	outboundSweepInterval = 15 * time.Second
+	// outboundLease is how long a claimed item stays hidden from other
+	// senders; it must cover typing simulation plus the send itself.
+	outboundLease      = 2 * time.Minute
+	outboundRetryDelay = 30 * time.Second
+	outboundErrorDelay = 5 * time.Second
+)
+
+// OutboundConfig throttles what a single session sends so bursts do not get
+// the number flagged.
+type OutboundConfig struct {
+	MessagesPerMinute int
+	// Jitter adds a random extra delay of up to this much between sends.
+	Jitter time.Duration
+	// TypingPerChar and MaxTyping size the typing indicator shown before a
+	// text is sent.
+	TypingPerChar time.Duration
+	MaxTyping     time.Duration
+	MaxAttempts   int
+}
+
+// OutboundListener follows queued messages after they leave the API, e.g.
+// to track campaign recipients. Listeners are called synchronously from the
+// sender and must be quick.
+type OutboundListener interface {
+	// OutboundFinished is called once an item is sent (msg is the sent
+	// message; its ID is 0 if storing it failed) or has failed for good
+	// (msg is nil).
+	OutboundFinished(ctx context.Context, item *entity.OutboundMessage, msg *entity.Message)
+	// ReceiptReceived reports delivery ("delivered") and read ("read",
+	// "played") receipts for sent message IDs.
+	ReceiptReceived(ctx context.Context, agentID string, messageIDs []string, receipt string, at time.Time)
+}
+
+// AddOutboundListener registers l. It must be called before sessions start.
+func (uc *SessionUseCase) AddOutboundListener(l OutboundListener) {
+	uc.listeners = append(uc.listeners, l)
+}
+
+// senderState tracks the sender goroutine of one session. wake is set when
+// work arrives while it runs, so it checks the queue once more before exiting.
+type senderState struct {
+	running bool
+	wake    bool
+}
+
+// sendMessage is the single path for anything the API sends on a session:
+// it puts the message on the session's outbound queue, which sends it at the
+// configured pace and records the outgoing row once it has left.
+func (uc *SessionUseCase) sendMessage(ctx context.Context, session *entity.Session, out outboundMessage, priority int) (*entity.OutboundMessage, error) {
+	if _, err := uc.connectedClient(session.AgentID); err != nil {
+		return nil, err
+	}
+	return uc.enqueue(ctx, session, out, priority)
+}
+
+// enqueue queues a message even while the session is offline; it is sent
+// once the session reconnects. Callers that answer an API request should
+// use sendMessage so clients learn about a disconnected session right away.
+func (uc *SessionUseCase) enqueue(ctx context.Context, session *entity.Session, out outboundMessage, priority int) (*entity.OutboundMessage, error) {
+	payload, err := proto.Marshal(out.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	var metaJSON []byte
+	if len(out.Metadata) > 0 {
+		metaJSON, _ = json.Marshal(out.Metadata)
+	}
+
+	now := time.Now()
+	item := &entity.OutboundMessage{
+		SessionID:            session.ID,
+		AgentID:              session.AgentID,
+		Priority:             priority,
+		Recipient:            out.To.String(),
+		Payload:              payload,
+		MessageText:          sql.NullString{String: out.Text, Valid: out.Text != ""},
+		MessageType:          fallbackString(out.Type, "text"),
+		Metadata:             metaJSON,
+		ReplyToID:            out.ReplyToID,
+		LangchainExecutionID: out.ExecutionID,
+		Status:               "queued",
+		SendAfter:            now,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+	if err := uc.outboundRepo.Create(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to queue message: %w", err)
+	}
+
+	uc.wakeSender(session.AgentID)
+	return item, nil
+}
+
+// ListOutbound returns a session's queued, sent or failed outbound items,
+// newest first. An empty status lists all of them.
+func (uc *SessionUseCase) ListOutbound(ctx context.Context, caller Caller, agentID, status string, limit, offset int) ([]*entity.OutboundMessage, error) {
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+		return nil, err
+	}
+	return uc.outboundRepo.GetByAgentID(ctx, agentID, status, limit, offset)
+}
+
+// StartOutboundWorker periodically starts senders for sessions with due
+// items: after a restart, after backoff delays and once sessions reconnect.
+func (uc *SessionUseCase) StartOutboundWorker(ctx context.Context) {
+	ticker := time.NewTicker(outboundSweepInterval)
+	defer ticker.Stop()
+	for {
+		agentIDs, err := uc.outboundRepo.GetAgentsWithDue(ctx)
+		if err != nil {
+			log.Printf("outbound: failed to list due items: %v", err)
+		}
+		for _, agentID := range agentIDs {
+			uc.wakeSender(agentID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// wakeSender makes sure a sender goroutine drains agentID's queue.
+func (uc *SessionUseCase) wakeSender(agentID string) {
+	uc.senderMu.Lock()
+	defer uc.senderMu.Unlock()
+	state := uc.senders[agentID]
+	if state == nil {
+		state = &senderState{}
+		uc.senders[agentID] = state
+	}
+	if state.running {
+		state.wake = true
+		return
+	}
+	state.running = true
+	go uc.runSender(agentID, state)
+}
+
+// runSender sends agentID's due items one at a time until the queue is empty
+// or the session goes offline.
+func (uc *SessionUseCase) runSender(agentID string, state *senderState) {
+	ctx := context.Background()
+	defer func() {
+		uc.senderMu.Lock()
+		state.running = false
+		uc.senderMu.Unlock()
+	}()
+
+	for {
+		client, err := uc.connectedClient(agentID)
+		if err != nil {
+			// Picked up again by the sweep once the session is back.
+			return
+		}
+
+		item, notBefore, err := uc.outboundRepo.ClaimNext(ctx, agentID, outboundLease, uc.sendGap())
+		if err != nil {
+			log.Printf("outbound: failed to claim next item for %s: %v", agentID, err)
+			time.Sleep(outboundErrorDelay)
+			continue
+		}
+		if !notBefore.IsZero() {
+			time.Sleep(time.Until(notBefore))
+			continue
+		}
+		if item == nil {
+			uc.senderMu.Lock()
+			again := state.wake
+			state.wake = false
+			uc.senderMu.Unlock()
+			if again {
+				continue
+			}
+			return
+		}
+
+		uc.deliverQueued(ctx, client, item)
+	}
+}
+
+// sendGap is how long a session waits after claiming an item before it may
+// claim the next under MessagesPerMinute, plus a random jitter.
+func (uc *SessionUseCase) sendGap() time.Duration {
+	if uc.outboundCfg.MessagesPerMinute <= 0 {
+		return 0
+	}
+	gap := time.Minute / time.Duration(uc.outboundCfg.MessagesPerMinute)
+	if uc.outboundCfg.Jitter > 0 {
+		gap += time.Duration(rand.Int63n(int64(uc.outboundCfg.Jitter)))
+	}
+	return gap
+}
+
+// deliverQueued sends a claimed item, simulating typing for texts, and
+// records the outcome on the item.
+func (uc *SessionUseCase) deliverQueued(ctx context.Context, client *whatsmeow.Client, item *entity.OutboundMessage) {
+	out, err := uc.decodeQueued(item)
+	var session *entity.Session
+	if err == nil {
+		session, err = uc.sessionRepo.GetByAgentID(ctx, item.AgentID)
+		if err == nil && session == nil {
+			err = ErrSessionNotFound
+		}
+	}
+	if err != nil {
+		// Nothing a retry can fix.
+		item.Attempts = uc.outboundCfg.MaxAttempts
+		uc.failQueued(ctx, item, err)
+		return
+	}
+
+	if typing := uc.typingDuration(item.MessageText.String); typing > 0 {
+		if err := client.SendChatPresence(ctx, out.To, types.ChatPresenceComposing, types.ChatPresenceMediaText); err != nil {
+			log.Printf("Failed to send typing presence to %s: %v", out.To, err)
+		}
+		time.Sleep(typing)
+	}
+
+	msg, err := uc.deliver(ctx, client, session, out)
+	if msg == nil {
+		uc.failQueued(ctx, item, err)
+		return
+	}
+	if err != nil {
+		log.Printf("outbound: item %d: %v", item.ID, err)
+	}
+
+	now := time.Now()
+	item.Status = "sent"
+	item.ErrorMessage = sql.NullString{}
+	item.MessageRowID = sql.NullInt64{Int64: int64(msg.ID), Valid: msg.ID != 0}
+	item.SentAt = sql.NullTime{Time: msg.CreatedAt, Valid: true}
+	item.UpdatedAt = now
+	if err := uc.outboundRepo.Update(ctx, item); err != nil {
+		log.Printf("outbound: failed to mark item %d sent: %v", item.ID, err)
+	}
+	for _, l := range uc.listeners {
+		l.OutboundFinished(ctx, item, msg)
+	}
+}
+
+// failQueued schedules a retry with linear backoff, or marks the item failed
+// once it is out of attempts.
+func (uc *SessionUseCase) failQueued(ctx context.Context, item *entity.OutboundMessage, sendErr error) {
+	now := time.Now()
+	item.ErrorMessage = sql.NullString{String: sendErr.Error(), Valid: true}
+	item.UpdatedAt = now
+	if item.Attempts < uc.outboundCfg.MaxAttempts {
+		item.SendAfter = now.Add(time.Duration(item.Attempts) * outboundRetryDelay)
+	} else {
+		item.Status = "failed"
+	}
+	if err := uc.outboundRepo.Update(ctx, item); err != nil {
+		log.Printf("outbound: failed to update item %d: %v", item.ID, err)
+	}
+	if item.Status == "failed" {
+		log.Printf("outbound: giving up on item %d for %s: %v", item.ID, item.AgentID, sendErr)
+		uc.emit(item.AgentID, EventMessageFailed, outboundEventData(item))
+		for _, l := range uc.listeners {
+			l.OutboundFinished(ctx, item, nil)
+		}
+	}
+}
+
+func (uc *SessionUseCase) decodeQueued(item *entity.OutboundMessage) (outboundMessage, error) {
+	to, err := types.ParseJID(item.Recipient)
+	if err != nil {
+		return outboundMessage{}, fmt.Errorf("%w: %v", ErrInvalidRecipient, err)
+	}
+	msg := &waProto.Message{}
+	if err := proto.Unmarshal(item.Payload, msg); err != nil {
+		return outboundMessage{}, fmt.Errorf("failed to decode queued message: %w", err)
+	}
+	var meta map[string]interface{}
+	if len(item.Metadata) > 0 {
+		_ = json.Unmarshal(item.Metadata, &meta)
+	}
+	return outboundMessage{
+		To:          to,
+		Message:     msg,
+		Text:        item.MessageText.String,
+		Type:        item.MessageType,
+		ReplyToID:   item.ReplyToID,
+		ExecutionID: item.LangchainExecutionID,
+		Metadata:    meta,
+	}, nil
+}
+
+// typingDuration is how long to show "typing…" before sending text, growing
+// with its length up to MaxTyping.
+func (uc *SessionUseCase) typingDuration(text string) time.Duration {
+	if text == "" || uc.outboundCfg.TypingPerChar <= 0 {
+		return 0
+	}
+	d := time.Duration(utf8.RuneCountInString(text)) * uc.outboundCfg.TypingPerChar
+	if uc.outboundCfg.MaxTyping > 0 && d > uc.outboundCfg.MaxTyping {
+		d = uc.outboundCfg.MaxTyping
+	}
+	return d
+}
+
+func outboundEventData(item *entity.OutboundMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"queueId":   item.ID,
+		"to":        item.Recipient,
+		"text":      item.MessageText.String,
+		"type":      item.MessageType,
+		"priority":  item.Priority,
+		"status":    item.Status,
+		"attempts":  item.Attempts,
+		"error":     item.ErrorMessage.String,
+		"timestamp": item.UpdatedAt,
+	}
+}
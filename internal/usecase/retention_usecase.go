@@ -0,0 +1,412 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/internal/infrastructure/storage"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	ErrRetentionNotFound = errors.New("retention policy not found")
+	ErrInvalidRetention  = errors.New("invalid retention policy")
+)
+
+// retentionBatchPause separates purge batches so deletes never hold locks
+// for long or starve live traffic.
+const retentionBatchPause = 200 * time.Millisecond
+
+// RetentionConfig paces the purge worker.
+type RetentionConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+type RetentionUseCase struct {
+	sessionRepo   repository.SessionRepository
+	retentionRepo repository.RetentionRepository
+	mediaStore    storage.BlobStore
+	sessionUC     *SessionUseCase
+	cfg           RetentionConfig
+}
+
+func NewRetentionUseCase(
+	sessionRepo repository.SessionRepository,
+	retentionRepo repository.RetentionRepository,
+	mediaStore storage.BlobStore,
+	sessionUC *SessionUseCase,
+	cfg RetentionConfig,
+) *RetentionUseCase {
+	return &RetentionUseCase{
+		sessionRepo:   sessionRepo,
+		retentionRepo: retentionRepo,
+		mediaStore:    mediaStore,
+		sessionUC:     sessionUC,
+		cfg:           cfg,
+	}
+}
+
+// SetRetentionInput sets the caller's default retention, or a session's
+// override when AgentID is set. A nil day count inherits the default (or,
+// for the default itself, keeps data forever); 0 keeps data forever.
+type SetRetentionInput struct {
+	Caller        Caller
+	AgentID       string
+	MessageDays   *int
+	ExecutionDays *int
+	MediaDays     *int
+}
+
+// RetentionPreview is what the next purge would delete from a session.
+type RetentionPreview struct {
+	Retention *entity.SessionRetention
+	Cutoffs   repository.RetentionCutoffs
+	Counts    *repository.PurgeCounts
+}
+
+// EraseContactInput erases one contact from the caller's sessions, or only
+// from AgentID's.
+type EraseContactInput struct {
+	Caller  Caller
+	Phone   string
+	AgentID string
+}
+
+// ContactErasureResult counts what erasing a contact removed.
+type ContactErasureResult struct {
+	repository.ContactErasure
+	Messages int `json:"messages"`
+	Media    int `json:"media"`
+}
+
+func (uc *RetentionUseCase) List(ctx context.Context, caller Caller) ([]*entity.RetentionPolicy, error) {
+	policies, err := uc.retentionRepo.GetByUserID(ctx, caller.UserID)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]*entity.RetentionPolicy, 0, len(policies))
+	for _, policy := range policies {
+		if !policy.AgentID.Valid || caller.CanAccessAgent(policy.AgentID.String) {
+			visible = append(visible, policy)
+		}
+	}
+	return visible, nil
+}
+
+func (uc *RetentionUseCase) Set(ctx context.Context, in SetRetentionInput) (*entity.RetentionPolicy, error) {
+	policy := &entity.RetentionPolicy{
+		UserID:    in.Caller.UserID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if in.AgentID != "" {
+		session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+		if err != nil {
+			return nil, err
+		}
+		policy.SessionID = sql.NullInt64{Int64: int64(session.ID), Valid: true}
+		policy.AgentID = sql.NullString{String: session.AgentID, Valid: true}
+	}
+	for _, field := range []struct {
+		name string
+		days *int
+		dst  *sql.NullInt64
+	}{
+		{"messageDays", in.MessageDays, &policy.MessageDays},
+		{"executionDays", in.ExecutionDays, &policy.ExecutionDays},
+		{"mediaDays", in.MediaDays, &policy.MediaDays},
+	} {
+		if field.days == nil {
+			continue
+		}
+		if *field.days < 0 {
+			return nil, fmt.Errorf("%w: %s must not be negative", ErrInvalidRetention, field.name)
+		}
+		*field.dst = sql.NullInt64{Int64: int64(*field.days), Valid: true}
+	}
+
+	if err := uc.retentionRepo.Upsert(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Delete removes the caller's default retention, or a session's override
+// when agentID is set.
+func (uc *RetentionUseCase) Delete(ctx context.Context, caller Caller, agentID string) error {
+	var sessionID sql.NullInt64
+	if agentID != "" {
+		session, err := ownedSession(ctx, uc.sessionRepo, caller, agentID)
+		if err != nil {
+			return err
+		}
+		sessionID = sql.NullInt64{Int64: int64(session.ID), Valid: true}
+	}
+	if err := uc.retentionRepo.Delete(ctx, caller.UserID, sessionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRetentionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Preview counts what a purge would delete right now from the caller's
+// sessions, or only from agentID's, without deleting anything.
+func (uc *RetentionUseCase) Preview(ctx context.Context, caller Caller, agentID string) ([]*RetentionPreview, error) {
+	if agentID != "" {
+		if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+			return nil, err
+		}
+	}
+	retention, err := uc.retentionRepo.GetEffective(ctx, caller.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	previews := make([]*RetentionPreview, 0, len(retention))
+	for _, r := range retention {
+		if (agentID != "" && r.AgentID != agentID) || !caller.CanAccessAgent(r.AgentID) {
+			continue
+		}
+		cutoffs := retentionCutoffs(r, now)
+		counts, err := uc.retentionRepo.CountExpired(ctx, r.SessionID, cutoffs)
+		if err != nil {
+			return nil, err
+		}
+		previews = append(previews, &RetentionPreview{Retention: r, Cutoffs: cutoffs, Counts: counts})
+	}
+	return previews, nil
+}
+
+// StartPurgeWorker applies every session's retention once per interval.
+func (uc *RetentionUseCase) StartPurgeWorker(ctx context.Context) {
+	ticker := time.NewTicker(uc.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		uc.purgeAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (uc *RetentionUseCase) purgeAll(ctx context.Context) {
+	retention, err := uc.retentionRepo.GetEffective(ctx, "")
+	if err != nil {
+		log.Printf("retention: failed to load policies: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, r := range retention {
+		if r.MessageDays == 0 && r.ExecutionDays == 0 && r.MediaDays == 0 {
+			continue
+		}
+		counts, err := uc.purge(ctx, r.SessionID, retentionCutoffs(r, now))
+		if err != nil {
+			log.Printf("retention: purge failed for agent %s: %v", r.AgentID, err)
+		}
+		if counts.Messages+counts.Executions+counts.Media > 0 {
+			log.Printf("retention: purged %d messages, %d executions and %d media files for agent %s",
+				counts.Messages, counts.Executions, counts.Media, r.AgentID)
+		}
+	}
+}
+
+// purge deletes a session's expired data in batches. Messages whose media
+// could not be deleted are kept for the next run.
+func (uc *RetentionUseCase) purge(ctx context.Context, sessionID int, cutoffs repository.RetentionCutoffs) (repository.PurgeCounts, error) {
+	var counts repository.PurgeCounts
+	batch := uc.cfg.BatchSize
+
+	if !cutoffs.Media.IsZero() {
+		for afterID := 0; ; {
+			messages, err := uc.retentionRepo.ExpiredMedia(ctx, sessionID, cutoffs.Media, afterID, batch)
+			if err != nil {
+				return counts, err
+			}
+			if len(messages) == 0 {
+				break
+			}
+			afterID = messages[len(messages)-1].ID
+			ids, deleted := uc.deleteMedia(ctx, messages)
+			if err := uc.retentionRepo.ClearMedia(ctx, ids); err != nil {
+				return counts, err
+			}
+			counts.Media += deleted
+			time.Sleep(retentionBatchPause)
+		}
+	}
+
+	if !cutoffs.Messages.IsZero() {
+		for afterID := 0; ; {
+			messages, err := uc.retentionRepo.ExpiredMessages(ctx, sessionID, cutoffs.Messages, afterID, batch)
+			if err != nil {
+				return counts, err
+			}
+			if len(messages) == 0 {
+				break
+			}
+			afterID = messages[len(messages)-1].ID
+			ids, deleted := uc.deleteMedia(ctx, messages)
+			n, err := uc.retentionRepo.DeleteMessages(ctx, ids)
+			if err != nil {
+				return counts, err
+			}
+			counts.Messages += n
+			counts.Media += deleted
+			time.Sleep(retentionBatchPause)
+		}
+		if err := uc.retentionRepo.ClearChatPreviews(ctx, sessionID, cutoffs.Messages); err != nil {
+			return counts, err
+		}
+	}
+
+	if !cutoffs.Executions.IsZero() {
+		for {
+			n, err := uc.retentionRepo.DeleteExpiredExecutions(ctx, sessionID, cutoffs.Executions, batch)
+			if err != nil {
+				return counts, err
+			}
+			counts.Executions += n
+			if n < batch {
+				break
+			}
+			time.Sleep(retentionBatchPause)
+		}
+	}
+	return counts, nil
+}
+
+// deleteMedia deletes the stored attachments of messages. It returns the
+// ids of the messages that no longer have one and how many files it deleted.
+func (uc *RetentionUseCase) deleteMedia(ctx context.Context, messages []*entity.Message) ([]int, int) {
+	ids := make([]int, 0, len(messages))
+	deleted := 0
+	for _, msg := range messages {
+		if key := mediaKey(msg); key != "" && uc.mediaStore != nil {
+			if err := uc.mediaStore.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("retention: failed to delete media %s of message %d: %v", key, msg.ID, err)
+				continue
+			}
+			deleted++
+		}
+		ids = append(ids, msg.ID)
+	}
+	return ids, deleted
+}
+
+// EraseContact removes everything stored about one phone number: messages
+// to and from it with their media, its Langchain executions, its chat,
+// campaign recipients, scheduled and queued messages to it, and webhook
+// deliveries about it. It is safe to repeat.
+func (uc *RetentionUseCase) EraseContact(ctx context.Context, in EraseContactInput) (*ContactErasureResult, error) {
+	jid, err := parseRecipient(in.Phone)
+	if err != nil || jid.Server != types.DefaultUserServer {
+		return nil, ErrInvalidRecipient
+	}
+
+	var sessions []*entity.Session
+	if in.AgentID != "" {
+		session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	} else {
+		owned, err := uc.sessionRepo.GetByUserID(ctx, in.Caller.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range owned {
+			if in.Caller.CanAccessAgent(session.AgentID) {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+
+	result := &ContactErasureResult{}
+	if len(sessions) == 0 {
+		return result, nil
+	}
+	sessionIDs := make([]int, 0, len(sessions))
+	numbers := []string{jid.User}
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.ID)
+		// The same contact may write from a LID address.
+		for _, n := range uc.sessionUC.contactAliases(ctx, session.AgentID, jid) {
+			if n != jid.User {
+				numbers = append(numbers, n)
+			}
+		}
+	}
+
+	messages, err := uc.retentionRepo.ContactMessages(ctx, sessionIDs, numbers)
+	if err != nil {
+		return nil, err
+	}
+	ids, deleted := uc.deleteMedia(ctx, messages)
+	result.Media = deleted
+	for start := 0; start < len(ids); start += uc.cfg.BatchSize {
+		end := min(start+uc.cfg.BatchSize, len(ids))
+		n, err := uc.retentionRepo.DeleteMessages(ctx, ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		result.Messages += n
+	}
+
+	erased, err := uc.retentionRepo.EraseContact(ctx, sessionIDs, numbers)
+	if err != nil {
+		return nil, err
+	}
+	result.ContactErasure = *erased
+
+	// Messages whose media could not be deleted stay; erasing again
+	// retries them.
+	if kept := len(messages) - len(ids); kept > 0 {
+		return result, fmt.Errorf("erasure incomplete: media of %d messages could not be deleted, retry to finish", kept)
+	}
+	return result, nil
+}
+
+func retentionCutoffs(r *entity.SessionRetention, now time.Time) repository.RetentionCutoffs {
+	var cutoffs repository.RetentionCutoffs
+	if r.MessageDays > 0 {
+		cutoffs.Messages = now.AddDate(0, 0, -r.MessageDays)
+	}
+	if r.ExecutionDays > 0 {
+		cutoffs.Executions = now.AddDate(0, 0, -r.ExecutionDays)
+	}
+	if r.MediaDays > 0 {
+		cutoffs.Media = now.AddDate(0, 0, -r.MediaDays)
+	}
+	return cutoffs
+}
+
+// mediaKey is the blob store key of a message's downloaded attachment.
+func mediaKey(msg *entity.Message) string {
+	if len(msg.Metadata) == 0 {
+		return ""
+	}
+	var meta struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(msg.Metadata, &meta); err != nil {
+		return ""
+	}
+	return meta.Path
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+)
+
+func TestRetentionCutoffs(t *testing.T) {
+	now := time.Date(2026, 10, 16, 14, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		retention entity.SessionRetention
+		want      repository.RetentionCutoffs
+	}{
+		{
+			name: "nothing set keeps everything",
+		},
+		{
+			name:      "each kind on its own",
+			retention: entity.SessionRetention{MessageDays: 30, ExecutionDays: 7, MediaDays: 1},
+			want: repository.RetentionCutoffs{
+				Messages:   time.Date(2026, 9, 16, 14, 5, 0, 0, time.UTC),
+				Executions: time.Date(2026, 10, 9, 14, 5, 0, 0, time.UTC),
+				Media:      time.Date(2026, 10, 15, 14, 5, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:      "only media",
+			retention: entity.SessionRetention{MediaDays: 90},
+			want:      repository.RetentionCutoffs{Media: time.Date(2026, 7, 18, 14, 5, 0, 0, time.UTC)},
+		},
+		{
+			name:      "negative days are ignored",
+			retention: entity.SessionRetention{MessageDays: -1, ExecutionDays: 0},
+		},
+	}
+	for _, tt := range tests {
+		got := retentionCutoffs(&tt.retention, now)
+		if !got.Messages.Equal(tt.want.Messages) || !got.Executions.Equal(tt.want.Executions) || !got.Media.Equal(tt.want.Media) {
+			t.Errorf("%s: retentionCutoffs = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Days are calendar days, so the cutoff keeps the wall clock time across a
+// daylight saving change.
+func TestRetentionCutoffsCalendarDays(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone not available: %v", err)
+	}
+	now := time.Date(2026, 11, 2, 9, 0, 0, 0, ny)
+	got := retentionCutoffs(&entity.SessionRetention{MessageDays: 2}, now)
+	if want := time.Date(2026, 10, 31, 9, 0, 0, 0, ny); !got.Messages.Equal(want) {
+		t.Errorf("Messages = %s, want %s", got.Messages, want)
+	}
+}
+
+func TestMediaKey(t *testing.T) {
+	tests := []struct {
+		metadata string
+		want     string
+	}{
+		{"", ""},
+		{`{"path":"agent-1/2026/10/abc.jpg","mimeType":"image/jpeg"}`, "agent-1/2026/10/abc.jpg"},
+		{`{"mimeType":"image/jpeg"}`, ""},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		msg := &entity.Message{Metadata: []byte(tt.metadata)}
+		if got := mediaKey(msg); got != tt.want {
+			t.Errorf("mediaKey(%s) = %q, want %q", tt.metadata, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/pkg/cron"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("scheduled message not found")
+	ErrInvalidSchedule  = errors.New("invalid scheduled message")
+	// ErrScheduleChanged is returned when a schedule fired or was edited
+	// while an update was being applied.
+	ErrScheduleChanged = errors.New("scheduled message changed while updating; try again")
+)
+
+const (
+	scheduleTickInterval = 5 * time.Second
+	scheduleClaimBatch   = 50
+	// scheduleOrphanAge is how long a run may stay pending without reaching
+	// the outbound queue before it is taken as lost, e.g. by a crash, and
+	// queued again.
+	scheduleOrphanAge = 2 * time.Minute
+)
+
+type ScheduleUseCase struct {
+	sessionRepo  repository.SessionRepository
+	scheduleRepo repository.ScheduleRepository
+	sessionUC    *SessionUseCase
+}
+
+func NewScheduleUseCase(
+	sessionRepo repository.SessionRepository,
+	scheduleRepo repository.ScheduleRepository,
+	sessionUC *SessionUseCase,
+) *ScheduleUseCase {
+	return &ScheduleUseCase{
+		sessionRepo:  sessionRepo,
+		scheduleRepo: scheduleRepo,
+		sessionUC:    sessionUC,
+	}
+}
+
+// CreateScheduleInput describes a scheduled message: set SendAt for a
+// one-off send or Cron for a recurring one.
+type CreateScheduleInput struct {
+	Caller  Caller
+	AgentID string
+	To      string
+	Text    string
+	SendAt  *time.Time
+	// Cron is a five-field expression such as "0 9 * * mon-fri" or a macro
+	// like "@daily", evaluated in Timezone (default UTC).
+	Cron     string
+	Timezone string
+}
+
+// UpdateScheduleInput changes the fields that are set. Setting SendAt turns
+// a recurring schedule into a one-off one and setting Cron the reverse.
+type UpdateScheduleInput struct {
+	Caller   Caller
+	ID       int
+	To       *string
+	Text     *string
+	SendAt   *time.Time
+	Cron     *string
+	Timezone *string
+	// Paused pauses or resumes an active schedule.
+	Paused *bool
+}
+
+func (uc *ScheduleUseCase) Create(ctx context.Context, in CreateScheduleInput) (*entity.ScheduledMessage, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedule := &entity.ScheduledMessage{
+		SessionID: session.ID,
+		AgentID:   session.AgentID,
+		Timezone:  fallbackString(strings.TrimSpace(in.Timezone), "UTC"),
+		Status:    "active",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := setScheduleRecipient(schedule, in.To); err != nil {
+		return nil, err
+	}
+	if err := setScheduleText(schedule, in.Text); err != nil {
+		return nil, err
+	}
+	if err := setScheduleTiming(schedule, in.SendAt, in.Cron, now); err != nil {
+		return nil, err
+	}
+
+	if err := uc.scheduleRepo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (uc *ScheduleUseCase) Update(ctx context.Context, in UpdateScheduleInput) (*entity.ScheduledMessage, error) {
+	schedule, err := uc.ownedSchedule(ctx, in.Caller, in.ID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status == "completed" {
+		return nil, fmt.Errorf("%w: schedule has completed", ErrInvalidSchedule)
+	}
+	fromStatus, fromNextRunAt := schedule.Status, schedule.NextRunAt
+
+	now := time.Now()
+	if in.To != nil {
+		if err := setScheduleRecipient(schedule, *in.To); err != nil {
+			return nil, err
+		}
+	}
+	if in.Text != nil {
+		if err := setScheduleText(schedule, *in.Text); err != nil {
+			return nil, err
+		}
+	}
+	if in.Timezone != nil {
+		schedule.Timezone = fallbackString(strings.TrimSpace(*in.Timezone), "UTC")
+	}
+	if in.SendAt != nil || in.Cron != nil || in.Timezone != nil {
+		cronExpr := schedule.CronExpr.String
+		if in.Cron != nil {
+			cronExpr = *in.Cron
+		}
+		if in.SendAt != nil {
+			cronExpr = ""
+		}
+		sendAt := in.SendAt
+		if sendAt == nil && cronExpr == "" {
+			sendAt = &schedule.NextRunAt.Time
+		}
+		if err := setScheduleTiming(schedule, sendAt, cronExpr, now); err != nil {
+			return nil, err
+		}
+	}
+	if in.Paused != nil {
+		if *in.Paused {
+			schedule.Status = "paused"
+		} else {
+			schedule.Status = "active"
+		}
+	}
+
+	schedule.UpdatedAt = now
+	ok, err := uc.scheduleRepo.Update(ctx, schedule, fromStatus, fromNextRunAt)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrScheduleChanged
+	}
+	return schedule, nil
+}
+
+func (uc *ScheduleUseCase) Delete(ctx context.Context, caller Caller, id int) error {
+	if _, err := uc.ownedSchedule(ctx, caller, id); err != nil {
+		return err
+	}
+	err := uc.scheduleRepo.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrScheduleNotFound
+	}
+	return err
+}
+
+func (uc *ScheduleUseCase) Get(ctx context.Context, caller Caller, id int) (*entity.ScheduledMessage, error) {
+	return uc.ownedSchedule(ctx, caller, id)
+}
+
+// List returns a session's schedules, newest first. An empty status lists
+// all of them.
+func (uc *ScheduleUseCase) List(ctx context.Context, caller Caller, agentID, status string, limit, offset int) ([]*entity.ScheduledMessage, error) {
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+		return nil, err
+	}
+	return uc.scheduleRepo.GetByAgentID(ctx, agentID, status, limit, offset)
+}
+
+// ListRuns returns the times a schedule fired, newest first.
+func (uc *ScheduleUseCase) ListRuns(ctx context.Context, caller Caller, id, limit, offset int) ([]*entity.ScheduledMessageRun, error) {
+	if _, err := uc.ownedSchedule(ctx, caller, id); err != nil {
+		return nil, err
+	}
+	return uc.scheduleRepo.GetRuns(ctx, id, limit, offset)
+}
+
+func (uc *ScheduleUseCase) ownedSchedule(ctx context.Context, caller Caller, id int) (*entity.ScheduledMessage, error) {
+	schedule, err := uc.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, ErrScheduleNotFound
+	}
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, schedule.AgentID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func setScheduleRecipient(schedule *entity.ScheduledMessage, to string) error {
+	jid, err := parseRecipient(to)
+	if err != nil {
+		return err
+	}
+	schedule.Recipient = jid.String()
+	return nil
+}
+
+func setScheduleText(schedule *entity.ScheduledMessage, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%w: message is required", ErrInvalidSchedule)
+	}
+	schedule.MessageText = text
+	return nil
+}
+
+// setScheduleTiming validates exactly one of sendAt and cronExpr and sets
+// the schedule's next run from it.
+func setScheduleTiming(schedule *entity.ScheduledMessage, sendAt *time.Time, cronExpr string, now time.Time) error {
+	cronExpr = strings.TrimSpace(cronExpr)
+	if (sendAt == nil) == (cronExpr == "") {
+		return fmt.Errorf("%w: set exactly one of sendAt and cron", ErrInvalidSchedule)
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, schedule.Timezone)
+	}
+
+	if sendAt != nil {
+		if !sendAt.After(now) {
+			return fmt.Errorf("%w: sendAt must be in the future", ErrInvalidSchedule)
+		}
+		schedule.CronExpr = sql.NullString{}
+		schedule.NextRunAt = sql.NullTime{Time: *sendAt, Valid: true}
+		return nil
+	}
+
+	expr, err := cron.Parse(cronExpr)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	next := expr.Next(now.In(loc))
+	if next.IsZero() {
+		return fmt.Errorf("%w: cron expression never matches", ErrInvalidSchedule)
+	}
+	schedule.CronExpr = sql.NullString{String: cronExpr, Valid: true}
+	schedule.NextRunAt = sql.NullTime{Time: next, Valid: true}
+	return nil
+}
+
+// StartScheduler fires due schedules. Claiming and advancing a schedule
+// happen under a row lock, so several API instances can run it side by side.
+func (uc *ScheduleUseCase) StartScheduler(ctx context.Context) {
+	ticker := time.NewTicker(scheduleTickInterval)
+	defer ticker.Stop()
+	for {
+		uc.fireDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type scheduleFiring struct {
+	schedule *entity.ScheduledMessage
+	run      *entity.ScheduledMessageRun
+}
+
+func (uc *ScheduleUseCase) fireDue(ctx context.Context) {
+	var fired []scheduleFiring
+	err := uc.scheduleRepo.ClaimDue(ctx, scheduleClaimBatch, func(schedule *entity.ScheduledMessage) *entity.ScheduledMessageRun {
+		now := time.Now()
+		run := &entity.ScheduledMessageRun{
+			ScheduleID:   schedule.ID,
+			AgentID:      schedule.AgentID,
+			ScheduledFor: schedule.NextRunAt.Time,
+			Status:       "pending",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		advanceSchedule(schedule, now)
+		fired = append(fired, scheduleFiring{schedule: schedule, run: run})
+		return run
+	})
+	if err != nil {
+		log.Printf("schedule: failed to claim due schedules: %v", err)
+		return
+	}
+
+	for _, f := range fired {
+		uc.queueRun(ctx, f.schedule, f.run)
+	}
+	uc.requeueOrphans(ctx)
+}
+
+// requeueOrphans queues runs that were recorded but never queued because
+// the instance stopped in between.
+func (uc *ScheduleUseCase) requeueOrphans(ctx context.Context) {
+	runs, err := uc.scheduleRepo.ClaimOrphanedRuns(ctx, time.Now().Add(-scheduleOrphanAge), scheduleClaimBatch)
+	if err != nil {
+		log.Printf("schedule: failed to claim orphaned runs: %v", err)
+		return
+	}
+	for _, run := range runs {
+		schedule, err := uc.scheduleRepo.GetByID(ctx, run.ScheduleID)
+		if err != nil {
+			log.Printf("schedule: failed to load schedule %d of run %d: %v", run.ScheduleID, run.ID, err)
+			continue
+		}
+		if schedule == nil {
+			continue
+		}
+		log.Printf("schedule: queueing orphaned run %d of schedule %d", run.ID, schedule.ID)
+		uc.queueRun(ctx, schedule, run)
+	}
+}
+
+// advanceSchedule moves a schedule that just fired to its next run, or
+// completes it. Runs missed while no instance was up are skipped, not
+// replayed.
+func advanceSchedule(schedule *entity.ScheduledMessage, now time.Time) {
+	schedule.RunCount++
+	schedule.LastRunAt = sql.NullTime{Time: now, Valid: true}
+	schedule.UpdatedAt = now
+	schedule.NextRunAt = sql.NullTime{}
+	schedule.Status = "completed"
+	if !schedule.CronExpr.Valid {
+		return
+	}
+
+	expr, err := cron.Parse(schedule.CronExpr.String)
+	if err != nil {
+		log.Printf("schedule: completing schedule %d with invalid cron %q: %v", schedule.ID, schedule.CronExpr.String, err)
+		return
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	if next := expr.Next(now.In(loc)); !next.IsZero() {
+		schedule.NextRunAt = sql.NullTime{Time: next, Valid: true}
+		schedule.Status = "active"
+	}
+}
+
+// queueRun hands a fired schedule to the session's outbound queue. The
+// message waits there if the session is offline.
+func (uc *ScheduleUseCase) queueRun(ctx context.Context, schedule *entity.ScheduledMessage, run *entity.ScheduledMessageRun) {
+	item, err := uc.enqueue(ctx, schedule, run)
+	run.UpdatedAt = time.Now()
+	if err != nil {
+		log.Printf("schedule: failed to queue run %d of schedule %d: %v", run.ID, schedule.ID, err)
+		run.Status = "failed"
+		run.ErrorMessage = sql.NullString{String: err.Error(), Valid: true}
+	} else {
+		run.Status = "queued"
+		run.OutboundID = sql.NullInt64{Int64: int64(item.ID), Valid: true}
+	}
+	if err := uc.scheduleRepo.UpdateRun(ctx, run); err != nil {
+		log.Printf("schedule: failed to update run %d: %v", run.ID, err)
+	}
+}
+
+func (uc *ScheduleUseCase) enqueue(ctx context.Context, schedule *entity.ScheduledMessage, run *entity.ScheduledMessageRun) (*entity.OutboundMessage, error) {
+	session, err := uc.sessionRepo.GetByAgentID(ctx, schedule.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	to, err := parseRecipient(schedule.Recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.sessionUC.enqueue(ctx, session, outboundMessage{
+		To:      to,
+		Message: &waProto.Message{Conversation: proto.String(schedule.MessageText)},
+		Text:    schedule.MessageText,
+		Metadata: map[string]interface{}{
+			"scheduleId":    schedule.ID,
+			"scheduleRunId": run.ID,
+		},
+	}, PriorityNormal)
+}
+
+// OutboundFinished links a sent scheduled message to its run.
+func (uc *ScheduleUseCase) OutboundFinished(ctx context.Context, item *entity.OutboundMessage, msg *entity.Message) {
+	var meta struct {
+		ScheduleRunID int `json:"scheduleRunId"`
+	}
+	if len(item.Metadata) == 0 || json.Unmarshal(item.Metadata, &meta) != nil || meta.ScheduleRunID == 0 {
+		return
+	}
+	run, err := uc.scheduleRepo.GetRun(ctx, meta.ScheduleRunID)
+	if err != nil || run == nil {
+		return
+	}
+
+	run.UpdatedAt = time.Now()
+	run.OutboundID = sql.NullInt64{Int64: int64(item.ID), Valid: true}
+	if msg == nil {
+		run.Status = "failed"
+		run.ErrorMessage = item.ErrorMessage
+	} else {
+		run.Status = "sent"
+		run.ErrorMessage = sql.NullString{}
+		run.MessageRowID = sql.NullInt64{Int64: int64(msg.ID), Valid: msg.ID != 0}
+	}
+	if err := uc.scheduleRepo.UpdateRun(ctx, run); err != nil {
+		log.Printf("schedule: failed to update run %d: %v", run.ID, err)
+	}
+}
+
+// ReceiptReceived is part of OutboundListener; runs do not track receipts.
+func (uc *ScheduleUseCase) ReceiptReceived(ctx context.Context, agentID string, messageIDs []string, receipt string, at time.Time) {
+}
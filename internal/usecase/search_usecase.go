@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+)
+
+var ErrInvalidSearch = errors.New("invalid search")
+
+// searchReindexBatch is how many rows each re-index statement rewrites
+// after the search language changes.
+const searchReindexBatch = 5000
+
+type SearchUseCase struct {
+	sessionRepo repository.SessionRepository
+	searchRepo  repository.SearchRepository
+	language    string
+}
+
+// NewSearchUseCase searches with the Postgres text search configuration
+// language, e.g. "simple", "english" or "indonesian".
+func NewSearchUseCase(sessionRepo repository.SessionRepository, searchRepo repository.SearchRepository, language string) *SearchUseCase {
+	return &SearchUseCase{
+		sessionRepo: sessionRepo,
+		searchRepo:  searchRepo,
+		language:    language,
+	}
+}
+
+// SearchInput is a full-text query over the caller's stored messages and
+// Langchain executions. AgentID narrows it to one session.
+type SearchInput struct {
+	Caller  Caller
+	Query   string
+	AgentID string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+	Offset  int
+}
+
+// ApplyLanguage switches the search index to the configured language,
+// re-indexing stored rows when it changed. Searches keep working meanwhile,
+// partly against the old language.
+func (uc *SearchUseCase) ApplyLanguage(ctx context.Context) {
+	if uc.language == "" {
+		return
+	}
+	started := time.Now()
+	changed, err := uc.searchRepo.EnsureLanguage(ctx, uc.language, searchReindexBatch)
+	if err != nil {
+		log.Printf("failed to set search language %q: %v", uc.language, err)
+		return
+	}
+	if changed {
+		log.Printf("search index rebuilt for language %q in %s", uc.language, time.Since(started).Round(time.Millisecond))
+	}
+}
+
+// Search returns the best matches first. Snippets are HTML-escaped with
+// matched words wrapped in <mark>.
+func (uc *SearchUseCase) Search(ctx context.Context, in SearchInput) ([]*entity.SearchHit, error) {
+	query := strings.TrimSpace(in.Query)
+	if query == "" {
+		return nil, ErrInvalidSearch
+	}
+
+	var sessionIDs []int
+	if in.AgentID != "" {
+		session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+		if err != nil {
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, session.ID)
+	} else {
+		sessions, err := uc.sessionRepo.GetByUserID(ctx, in.Caller.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range sessions {
+			if in.Caller.CanAccessAgent(session.AgentID) {
+				sessionIDs = append(sessionIDs, session.ID)
+			}
+		}
+	}
+	if len(sessionIDs) == 0 {
+		return []*entity.SearchHit{}, nil
+	}
+
+	filter := repository.SearchFilter{
+		Query:      query,
+		SessionIDs: sessionIDs,
+		Limit:      in.Limit,
+		Offset:     in.Offset,
+	}
+	filter.Since, filter.Until = localRange(in.Since, in.Until)
+
+	hits, err := uc.searchRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, hit := range hits {
+		hit.Snippet = highlightSnippet(hit.Snippet)
+	}
+	return hits, nil
+}
+
+var snippetMarks = strings.NewReplacer(
+	repository.SnippetMatchStart, "<mark>",
+	repository.SnippetMatchStop, "</mark>",
+)
+
+// highlightSnippet escapes message text, which may contain markup, before
+// turning the match markers into <mark> tags.
+func highlightSnippet(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
@@ -2,22 +2,31 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"mime"
+	"path"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
 
 	"whatsapp-api/internal/domain/entity"
 	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/internal/infrastructure/eventbus"
+	"whatsapp-api/internal/infrastructure/storage"
 	"whatsapp-api/internal/infrastructure/whatsapp"
 
 	"go.mau.fi/whatsmeow"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
 )
 
 type SessionUseCase struct {
@@ -26,35 +35,52 @@ type SessionUseCase struct {
 	waManager           *whatsapp.ClientManager
 	clients             map[string]*whatsmeow.Client
 	mu                  sync.RWMutex
-	defaultUser         string
 	defaultLangchainURL string
 	langchainUC         *LangchainUseCase
+	mediaStore          storage.BlobStore
+	webhookUC           *WebhookUseCase
+	events              *eventbus.Bus
+	outboundRepo        repository.OutboundRepository
+	outboundCfg         OutboundConfig
+	senders             map[string]*senderState
+	senderMu            sync.Mutex
+	listeners           []OutboundListener
+	chatRepo            repository.ChatRepository
 }
 
 func NewSessionUseCase(
 	sessionRepo repository.SessionRepository,
 	messageRepo repository.MessageRepository,
 	waManager *whatsapp.ClientManager,
-	defaultUser string,
 	defaultLangchainURL string,
 	langchainUC *LangchainUseCase,
+	mediaStore storage.BlobStore,
+	webhookUC *WebhookUseCase,
+	events *eventbus.Bus,
+	outboundRepo repository.OutboundRepository,
+	outboundCfg OutboundConfig,
+	chatRepo repository.ChatRepository,
 ) *SessionUseCase {
 	return &SessionUseCase{
 		sessionRepo:         sessionRepo,
 		messageRepo:         messageRepo,
 		waManager:           waManager,
 		clients:             make(map[string]*whatsmeow.Client),
-		defaultUser:         defaultUser,
 		defaultLangchainURL: defaultLangchainURL,
 		langchainUC:         langchainUC,
+		mediaStore:          mediaStore,
+		webhookUC:           webhookUC,
+		events:              events,
+		outboundRepo:        outboundRepo,
+		outboundCfg:         outboundCfg,
+		senders:             make(map[string]*senderState),
+		chatRepo:            chatRepo,
 	}
 }
 
-func (uc *SessionUseCase) CreateSession(ctx context.Context, agentID, agentName, langchainAPIKey, langchainURL string) (*entity.Session, error) {
-	// Check if session exists
-	existing, _ := uc.sessionRepo.GetByUserIDAndAgentID(ctx, uc.defaultUser, agentID)
-	if existing != nil {
-		return nil, fmt.Errorf("session already exists for agent %s", agentID)
+func (uc *SessionUseCase) CreateSession(ctx context.Context, caller Caller, agentID, agentName, langchainAPIKey, langchainURL string) (*entity.Session, error) {
+	if !caller.CanAccessAgent(agentID) {
+		return nil, ErrAgentNotAllowed
 	}
 
 	// Create new WhatsApp client
@@ -65,7 +91,7 @@ func (uc *SessionUseCase) CreateSession(ctx context.Context, agentID, agentName,
 
 	// Save session to DB
 	session := &entity.Session{
-		UserID:    uc.defaultUser,
+		UserID:    caller.UserID,
 		AgentID:   agentID,
 		AgentName: sql.NullString{String: agentName, Valid: true},
 		Status:    "initializing",
@@ -81,7 +107,13 @@ func (uc *SessionUseCase) CreateSession(ctx context.Context, agentID, agentName,
 		UpdatedAt: time.Now(),
 	}
 
+	// Agent IDs key the live clients and message history, so they are
+	// unique across users. The database enforces it; whose session holds
+	// the ID is not revealed.
 	if err := uc.sessionRepo.Create(ctx, session); err != nil {
+		if errors.Is(err, repository.ErrAgentIDTaken) {
+			return nil, ErrAgentIDUnavailable
+		}
 		return nil, err
 	}
 
@@ -124,6 +156,14 @@ func (uc *SessionUseCase) listenForQR(session *entity.Session, client *whatsmeow
 			session.LastQRGeneratedAt = sql.NullTime{Time: time.Now(), Valid: true}
 
 			uc.sessionRepo.Update(context.Background(), session)
+			uc.emit(session.AgentID, EventSessionStatus, map[string]interface{}{"status": session.Status})
+			// qrCode stays the raw code webhook consumers already
+			// render; qrImage is ready for an <img> tag.
+			uc.emit(session.AgentID, EventSessionQR, map[string]interface{}{
+				"qrCode":       evt.Code,
+				"qrCodeBase64": qrBase64,
+				"qrImage":      "data:image/png;base64," + qrBase64,
+			})
 
 			select {
 			case firstQR <- struct{}{}:
@@ -134,6 +174,7 @@ func (uc *SessionUseCase) listenForQR(session *entity.Session, client *whatsmeow
 			session.QRCode = sql.NullString{Valid: false}
 			session.QRCodeBase64 = sql.NullString{Valid: false}
 			uc.sessionRepo.Update(context.Background(), session)
+			uc.emit(session.AgentID, EventSessionStatus, map[string]interface{}{"status": session.Status})
 
 			// Reconnect to get a fresh QR and continue emitting codes
 			go client.Connect()
@@ -165,11 +206,22 @@ func (uc *SessionUseCase) handleEvent(agentID string, evt interface{}) {
 	switch e := evt.(type) {
 	case *events.Connected:
 		uc.updateSessionStatus(agentID, "connected")
+		uc.emit(agentID, EventSessionConnected, map[string]interface{}{"status": "connected"})
+		// Send whatever queued up while the session was offline.
+		uc.wakeSender(agentID)
+		go uc.markAvailable(agentID)
+	case *events.PushNameSetting:
+		// Presence needs a push name, which a freshly paired device only
+		// learns after connecting.
+		go uc.markAvailable(agentID)
+	case *events.Disconnected:
+		uc.emit(agentID, EventSessionDisconnected, map[string]interface{}{})
 	case *events.LoggedOut:
 		uc.updateSessionStatus(agentID, "disconnected")
 		uc.mu.Lock()
 		delete(uc.clients, agentID)
 		uc.mu.Unlock()
+		uc.emit(agentID, EventSessionLoggedOut, map[string]interface{}{"reason": e.Reason.String()})
 	case *events.PairSuccess:
 		// Get JID
 		uc.mu.RLock()
@@ -180,6 +232,35 @@ func (uc *SessionUseCase) handleEvent(agentID string, evt interface{}) {
 			uc.updateSessionPhone(agentID, jid.User)
 			uc.updateSessionJID(agentID, jid)
 		}
+		uc.emit(agentID, EventSessionPaired, map[string]interface{}{
+			"jid":      e.ID.String(),
+			"platform": e.Platform,
+		})
+	case *events.Receipt:
+		uc.applyReceipt(agentID, e)
+		for _, l := range uc.listeners {
+			l.ReceiptReceived(context.Background(), agentID, e.MessageIDs, receiptType(e.Type), e.Timestamp)
+		}
+		uc.emit(agentID, EventReceipt, map[string]interface{}{
+			"messageIds": e.MessageIDs,
+			"type":       receiptType(e.Type),
+			"chat":       e.Chat.String(),
+			"sender":     e.Sender.User,
+			"timestamp":  e.Timestamp,
+		})
+	case *events.ChatPresence:
+		uc.emit(agentID, EventPresence, map[string]interface{}{
+			"chat":   e.Chat.String(),
+			"sender": e.Sender.User,
+			"state":  string(e.State),
+			"media":  string(e.Media),
+		})
+	case *events.GroupInfo:
+		if e.Name != nil && uc.chatRepo != nil {
+			if err := uc.chatRepo.SetDisplayNameByJID(context.Background(), agentID, e.JID.String(), e.Name.Name); err != nil {
+				log.Printf("failed to rename chat %s for agent %s: %v", e.JID, agentID, err)
+			}
+		}
 	case *events.Message:
 		go uc.handleIncomingMessage(agentID, e)
 	}
@@ -190,10 +271,11 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 		return
 	}
 
-	text := extractText(msgEvt)
-	if text == "" {
+	content := uc.describeMessage(agentID, msgEvt)
+	if content == nil || (content.Text == "" && content.Media == nil) {
 		return
 	}
+	text := content.Text
 
 	session, err := uc.sessionRepo.GetByAgentID(context.Background(), agentID)
 	if err != nil || session == nil {
@@ -204,6 +286,27 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 	from := msgEvt.Info.Sender.User
 	to := session.PhoneNumber.String
 
+	uc.mu.RLock()
+	client := uc.clients[agentID]
+	uc.mu.RUnlock()
+	chat := chatKey(context.Background(), client, msgEvt.Info.Chat, msgEvt.Info.SenderAlt)
+	storedText := text
+	meta := map[string]interface{}{"chat": chat.String()}
+	for k, v := range content.Attachment {
+		if k != "type" {
+			meta[k] = v
+		}
+	}
+	if content.Media != nil {
+		// Keep the real caption rather than the placeholder used for Langchain.
+		storedText = content.Media.Caption
+		for k, v := range uc.storeIncomingMedia(agentID, msgEvt, content.Media) {
+			meta[k] = v
+		}
+	}
+	metaJSON, _ := json.Marshal(meta)
+
+	var incomingID sql.NullInt64
 	if uc.messageRepo != nil {
 		msg := &entity.Message{
 			SessionID:   session.ID,
@@ -211,15 +314,38 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 			MessageID:   sql.NullString{String: msgEvt.Info.ID, Valid: msgEvt.Info.ID != ""},
 			FromNumber:  sql.NullString{String: from, Valid: from != ""},
 			ToNumber:    sql.NullString{String: to, Valid: to != ""},
-			MessageText: sql.NullString{String: text, Valid: text != ""},
-			MessageType: sql.NullString{String: "text", Valid: true},
+			MessageText: sql.NullString{String: storedText, Valid: storedText != ""},
+			MessageType: sql.NullString{String: content.Type, Valid: true},
 			Direction:   sql.NullString{String: "incoming", Valid: true},
 			Status:      sql.NullString{String: "received", Valid: true},
+			Metadata:    metaJSON,
 			CreatedAt:   time.Now(),
 		}
 		if err := uc.messageRepo.Create(context.Background(), msg); err != nil {
 			log.Printf("failed to store incoming message: %v", err)
+		} else {
+			incomingID = sql.NullInt64{Int64: int64(msg.ID), Valid: msg.ID != 0}
+			uc.recordChat(context.Background(), client, msg, chat, msgEvt.Info.PushName)
+		}
+		uc.emit(agentID, EventMessageReceived, messageEventData(msg))
+	}
+
+	if text == "" {
+		return
+	}
+
+	// Non-text messages reach the agent as a textual stand-in plus a
+	// structured description it can act on.
+	var params map[string]interface{}
+	if content.Attachment != nil {
+		attachment := make(map[string]interface{}, len(content.Attachment)+1)
+		for k, v := range content.Attachment {
+			attachment[k] = v
+		}
+		if content.Media != nil && incomingID.Valid {
+			attachment["messageId"] = incomingID.Int64
 		}
+		params = map[string]interface{}{"attachment": attachment}
 	}
 
 	if uc.langchainUC != nil {
@@ -245,10 +371,10 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 				}
 
 				// Check in mentioned JIDs
-				ext := msgEvt.Message.GetExtendedTextMessage()
-				if ext != nil && ext.ContextInfo != nil {
-					log.Printf("[Group Debug] Mentioned JIDs: %v", ext.ContextInfo.MentionedJID)
-					for _, mentioned := range ext.ContextInfo.MentionedJID {
+				mentionCtx := content.ContextInfo
+				if mentionCtx != nil {
+					log.Printf("[Group Debug] Mentioned JIDs: %v", mentionCtx.MentionedJID)
+					for _, mentioned := range mentionCtx.MentionedJID {
 						// MentionedJID is usually the full JID (User@Server)
 						// Check if it matches me (User) or me@s.whatsapp.net
 						// Also check if it matches the bot's LID if available
@@ -297,7 +423,7 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 						}
 					}
 				} else {
-					log.Printf("[Group Debug] No ExtendedTextMessage or ContextInfo found")
+					log.Printf("[Group Debug] No ContextInfo found")
 				}
 
 				// Fallback 1: Check text for @<bot_number>
@@ -319,9 +445,9 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 				// Fallback 3: Check if mentioned JID matches our LID (if we can find it)
 				// Note: Mapping LID to Phone is complex without extra queries.
 				// We rely on PushName fallback for now.
-				if !shouldRespond && ext != nil && ext.ContextInfo != nil {
+				if !shouldRespond && mentionCtx != nil {
 					// Just log for debugging
-					log.Printf("[Group Debug] Mentioned JIDs (LID check skipped): %v", ext.ContextInfo.MentionedJID)
+					log.Printf("[Group Debug] Mentioned JIDs (LID check skipped): %v", mentionCtx.MentionedJID)
 				}
 			} else {
 				log.Printf("[Group Debug] Client or Store ID not available")
@@ -331,7 +457,7 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 		if shouldRespond {
 			uc.sendTyping(agentID, msgEvt.Info.Chat)
 			log.Printf("[Langchain] Executing for agent %s...", agentID)
-			exec, err := uc.langchainUC.Execute(context.Background(), agentID, text, from, nil)
+			exec, err := uc.langchainUC.ExecuteForMessage(context.Background(), agentID, text, from, params, incomingID)
 			if err != nil {
 				log.Printf("langchain execute failed for agent %s: %v", agentID, err)
 				uc.stopTyping(agentID, msgEvt.Info.Chat)
@@ -346,10 +472,17 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 						log.Printf("[Direct Debug] Sending reply to user: %s", target)
 					}
 
-					if err := uc.sendTextMessage(agentID, target, reply); err != nil {
-						log.Printf("failed to send langchain reply to %s: %v", target, err)
+					_, err := uc.sendMessage(context.Background(), session, outboundMessage{
+						To:          target,
+						Message:     &waProto.Message{Conversation: proto.String(reply)},
+						Text:        reply,
+						ReplyToID:   incomingID,
+						ExecutionID: sql.NullInt64{Int64: int64(exec.ID), Valid: exec.ID != 0},
+					}, PriorityReply)
+					if err != nil {
+						log.Printf("failed to queue langchain reply to %s: %v", target, err)
 					} else {
-						log.Printf("Successfully sent reply to %s", target)
+						log.Printf("Queued reply to %s", target)
 					}
 				} else {
 					log.Printf("Langchain returned empty reply")
@@ -362,9 +495,93 @@ func (uc *SessionUseCase) handleIncomingMessage(agentID string, msgEvt *events.M
 	}
 }
 
+// storeIncomingMedia downloads the attachment through the session's client,
+// writes it to the media store and returns the metadata to keep on the
+// message row. Failures are logged and recorded rather than dropping the message.
+func (uc *SessionUseCase) storeIncomingMedia(agentID string, msgEvt *events.Message, media *incomingMedia) map[string]interface{} {
+	meta := map[string]interface{}{
+		"mimetype": media.Mimetype,
+		"size":     media.Length,
+	}
+	if media.FileName != "" {
+		meta["fileName"] = media.FileName
+	}
+
+	uc.mu.RLock()
+	client := uc.clients[agentID]
+	uc.mu.RUnlock()
+	if uc.mediaStore == nil || client == nil {
+		return meta
+	}
+	if media.Length > maxMediaSize {
+		meta["downloadError"] = "file too large"
+		return meta
+	}
+
+	ctx := context.Background()
+	data, err := client.Download(ctx, media.Message)
+	if err != nil {
+		log.Printf("failed to download %s from %s for agent %s: %v", media.Type, msgEvt.Info.Sender, agentID, err)
+		meta["downloadError"] = err.Error()
+		return meta
+	}
+
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%s/%s/%s%s", agentID, msgEvt.Info.Timestamp.Format("2006/01/02"), mediaKeyName(string(msgEvt.Info.ID)), mediaExtension(media.Mimetype, media.FileName))
+	if err := uc.mediaStore.Put(ctx, key, data, media.Mimetype); err != nil {
+		log.Printf("failed to store %s for agent %s: %v", media.Type, agentID, err)
+		meta["downloadError"] = err.Error()
+		return meta
+	}
+
+	meta["size"] = len(data)
+	meta["sha256"] = hex.EncodeToString(sum[:])
+	meta["path"] = key
+	return meta
+}
+
+// mediaKeyName turns a message ID, which the sender controls, into a safe
+// blob name: plain alphanumeric IDs are kept, anything else is hashed.
+func mediaKeyName(messageID string) string {
+	if safeMediaName.MatchString(messageID) {
+		return messageID
+	}
+	sum := sha256.Sum256([]byte(messageID))
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	safeMediaName      = regexp.MustCompile(`^[A-Za-z0-9]{1,64}$`)
+	safeMediaExtension = regexp.MustCompile(`^\.[A-Za-z0-9]{1,10}$`)
+)
+
+func mediaExtension(mimetype, fileName string) string {
+	// The file name comes from the sender; only a plain extension is kept.
+	if ext := path.Ext(fileName); safeMediaExtension.MatchString(ext) {
+		return strings.ToLower(ext)
+	}
+	base, _, _ := strings.Cut(mimetype, ";")
+	switch strings.TrimSpace(base) {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/webp":
+		return ".webp"
+	case "audio/ogg":
+		return ".ogg"
+	case "video/mp4":
+		return ".mp4"
+	case "application/pdf":
+		return ".pdf"
+	}
+	if exts, _ := mime.ExtensionsByType(strings.TrimSpace(base)); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
 func (uc *SessionUseCase) updateSessionStatus(agentID, status string) {
 	session, err := uc.sessionRepo.GetByAgentID(context.Background(), agentID)
-	if err == nil {
+	if err == nil && session != nil {
 		session.Status = status
 		switch status {
 		case "connected":
@@ -375,6 +592,7 @@ func (uc *SessionUseCase) updateSessionStatus(agentID, status string) {
 			session.DisconnectedAt = sql.NullTime{Time: time.Now(), Valid: true}
 		}
 		uc.sessionRepo.Update(context.Background(), session)
+		uc.emit(agentID, EventSessionStatus, map[string]interface{}{"status": status})
 	}
 }
 
@@ -404,16 +622,38 @@ func (uc *SessionUseCase) updateSessionJID(agentID string, jid *types.JID) {
 	}
 }
 
-func (uc *SessionUseCase) GetSession(ctx context.Context, agentID string) (*entity.Session, error) {
-	session, err := uc.sessionRepo.GetByAgentID(ctx, agentID)
-	if err != nil || session == nil {
-		return session, err
+// GetSession returns the user's session for agentID, or nil when the user
+// has no such session.
+func (uc *SessionUseCase) GetSession(ctx context.Context, caller Caller, agentID string) (*entity.Session, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, caller, agentID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	uc.refreshQRIfStale(agentID, session)
 	return session, nil
 }
 
+// ownedSession loads the caller's session for agentID. Sessions of other
+// users, or outside the key's agent list, are reported as not found so their
+// existence isn't revealed.
+func ownedSession(ctx context.Context, repo repository.SessionRepository, caller Caller, agentID string) (*entity.Session, error) {
+	if !caller.CanAccessAgent(agentID) {
+		return nil, ErrSessionNotFound
+	}
+	session, err := repo.GetByUserIDAndAgentID(ctx, caller.UserID, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
 func fallbackString(primary, secondary string) string {
 	if primary != "" {
 		return primary
@@ -421,32 +661,126 @@ func fallbackString(primary, secondary string) string {
 	return secondary
 }
 
-func extractText(msg *events.Message) string {
-	if msg == nil || msg.Message == nil {
-		return ""
+// connectedClient returns the in-memory client for agentID only when it is
+// connected and paired, so callers can send on it straight away.
+func (uc *SessionUseCase) connectedClient(agentID string) (*whatsmeow.Client, error) {
+	uc.mu.RLock()
+	client := uc.clients[agentID]
+	uc.mu.RUnlock()
+	if client == nil || !client.IsConnected() || !client.IsLoggedIn() {
+		return nil, ErrSessionNotConnected
+	}
+	return client, nil
+}
+
+// outboundMessage describes a message leaving a session. Text and Type are
+// what gets stored in messages; Message is what goes over the wire.
+type outboundMessage struct {
+	To          types.JID
+	Message     *waProto.Message
+	Text        string
+	Type        string
+	ReplyToID   sql.NullInt64
+	ExecutionID sql.NullInt64
+	Metadata    map[string]interface{}
+}
+
+// deliver sends a message on the session's client and records the outgoing
+// row so stats and audits see every reply, not just incoming traffic. Only
+// the outbound queue calls it; everything else goes through sendMessage.
+func (uc *SessionUseCase) deliver(ctx context.Context, client *whatsmeow.Client, session *entity.Session, out outboundMessage) (*entity.Message, error) {
+	resp, err := client.SendMessage(ctx, out.To, out.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
 	}
-	if conv := msg.Message.GetConversation(); conv != "" {
-		return conv
+
+	chat := chatKey(ctx, client, out.To, types.EmptyJID)
+	meta := map[string]interface{}{"chat": chat.String()}
+	for k, v := range out.Metadata {
+		meta[k] = v
 	}
-	if ext := msg.Message.GetExtendedTextMessage(); ext != nil && ext.GetText() != "" {
-		return ext.GetText()
+	metaJSON, _ := json.Marshal(meta)
+
+	from := session.PhoneNumber.String
+	msgType := fallbackString(out.Type, "text")
+	outgoing := &entity.Message{
+		SessionID:            session.ID,
+		AgentID:              session.AgentID,
+		MessageID:            sql.NullString{String: resp.ID, Valid: resp.ID != ""},
+		FromNumber:           sql.NullString{String: from, Valid: from != ""},
+		ToNumber:             sql.NullString{String: out.To.User, Valid: out.To.User != ""},
+		MessageText:          sql.NullString{String: out.Text, Valid: out.Text != ""},
+		MessageType:          sql.NullString{String: msgType, Valid: true},
+		Direction:            sql.NullString{String: "outgoing", Valid: true},
+		Status:               sql.NullString{String: "sent", Valid: true},
+		Metadata:             metaJSON,
+		ReplyToID:            out.ReplyToID,
+		LangchainExecutionID: out.ExecutionID,
+		CreatedAt:            sentAt(resp),
 	}
-	return ""
+	var storeErr error
+	if uc.messageRepo != nil {
+		if err := uc.messageRepo.Create(ctx, outgoing); err != nil {
+			// The message already left the device; report it but keep the send result.
+			storeErr = fmt.Errorf("message sent but failed to store: %w", err)
+		} else {
+			uc.recordChat(ctx, client, outgoing, chat, "")
+		}
+	}
+	uc.emit(session.AgentID, EventMessageSent, messageEventData(outgoing))
+	return outgoing, storeErr
 }
 
-func (uc *SessionUseCase) sendTextMessage(agentID string, to types.JID, text string) error {
-	uc.mu.RLock()
-	client := uc.clients[agentID]
-	uc.mu.RUnlock()
-	if client == nil {
-		return fmt.Errorf("client not found for agent %s", agentID)
+// emit publishes an event to in-process subscribers and the session's
+// webhooks.
+func (uc *SessionUseCase) emit(agentID, event string, data interface{}) {
+	if uc.events != nil {
+		uc.events.Publish(eventbus.Event{Type: event, AgentID: agentID, Data: data})
+	}
+	if uc.webhookUC != nil {
+		uc.webhookUC.Dispatch(agentID, event, data)
 	}
+}
+
+// SubscribeEvents streams the events of the given sessions. Callers must
+// release the subscription with UnsubscribeEvents.
+func (uc *SessionUseCase) SubscribeEvents(agentIDs ...string) *eventbus.Subscription {
+	return uc.events.Subscribe(agentIDs...)
+}
+
+func (uc *SessionUseCase) UnsubscribeEvents(sub *eventbus.Subscription) {
+	uc.events.Unsubscribe(sub)
+}
 
-	msg := &waProto.Message{
-		Conversation: &text,
+// applyReceipt moves the outgoing rows a receipt covers to delivered, read
+// or played and emits message.status for each row that changed.
+func (uc *SessionUseCase) applyReceipt(agentID string, e *events.Receipt) {
+	status := receiptType(e.Type)
+	if uc.messageRepo == nil || (status != "delivered" && status != "read" && status != "played") {
+		return
+	}
+	updated, err := uc.messageRepo.ApplyReceipt(context.Background(), agentID, e.MessageIDs, status, e.Timestamp)
+	if err != nil {
+		log.Printf("Failed to apply %s receipt for %s: %v", status, agentID, err)
+		return
+	}
+	for _, msg := range updated {
+		uc.emit(agentID, EventMessageStatus, messageEventData(msg))
 	}
-	_, err := client.SendMessage(context.Background(), to, msg)
-	return err
+}
+
+func receiptType(t types.ReceiptType) string {
+	if t == types.ReceiptTypeDelivered {
+		return "delivered"
+	}
+	return string(t)
+}
+
+func sentAt(resp whatsmeow.SendResponse) time.Time {
+	if resp.Timestamp.IsZero() {
+		return time.Now()
+	}
+	return resp.Timestamp
 }
 
 func (uc *SessionUseCase) sendTyping(agentID string, to types.JID) {
@@ -460,6 +794,25 @@ func (uc *SessionUseCase) sendTyping(agentID string, to types.JID) {
 	}
 }
 
+// markAvailable shows the account as online. WhatsApp only sends chat state
+// (typing, recording) events to clients that are available; the side effect
+// is that the phone stops getting push notifications while the session is
+// connected.
+func (uc *SessionUseCase) markAvailable(agentID string) {
+	uc.mu.RLock()
+	client := uc.clients[agentID]
+	uc.mu.RUnlock()
+	if client == nil {
+		return
+	}
+	if err := client.SendPresence(context.Background(), types.PresenceAvailable); err != nil {
+		if errors.Is(err, whatsmeow.ErrNoPushName) {
+			return
+		}
+		log.Printf("Failed to send available presence for agent %s: %v", agentID, err)
+	}
+}
+
 func (uc *SessionUseCase) stopTyping(agentID string, to types.JID) {
 	uc.mu.RLock()
 	client := uc.clients[agentID]
@@ -488,6 +841,29 @@ func extractLangchainReply(exec *entity.LangchainExecution) string {
 	return ""
 }
 
+// ClientState is what the in-memory whatsmeow client of a session reports.
+type ClientState struct {
+	Loaded    bool
+	Connected bool
+	LoggedIn  bool
+}
+
+// ClientState reports the live client of agentID; Loaded is false when
+// there is none.
+func (uc *SessionUseCase) ClientState(agentID string) ClientState {
+	uc.mu.RLock()
+	client := uc.clients[agentID]
+	uc.mu.RUnlock()
+	if client == nil {
+		return ClientState{}
+	}
+	return ClientState{
+		Loaded:    true,
+		Connected: client.IsConnected(),
+		LoggedIn:  client.IsLoggedIn(),
+	}
+}
+
 type MessageStats struct {
 	Incoming  int `json:"incoming"`
 	Responded int `json:"responded"`
@@ -508,7 +884,14 @@ func (uc *SessionUseCase) GetMessageStats(ctx context.Context, agentID string) M
 	return stats
 }
 
-func (uc *SessionUseCase) DeleteSession(ctx context.Context, agentID string) error {
+func (uc *SessionUseCase) DeleteSession(ctx context.Context, caller Caller, agentID string) error {
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+
 	uc.mu.Lock()
 	client, ok := uc.clients[agentID]
 	delete(uc.clients, agentID)
@@ -552,7 +935,7 @@ func (uc *SessionUseCase) InitializeSessions(ctx context.Context) error {
 		if session.Status == "connected" || session.Status == "initializing" || session.Status == "waiting_scan" {
 			log.Printf("Restoring session for agent %s (Status: %s)", session.AgentID, session.Status)
 			go func(agentID string) {
-				if _, err := uc.ReconnectSession(context.Background(), agentID); err != nil {
+				if _, err := uc.reconnectSession(context.Background(), agentID); err != nil {
 					log.Printf("Failed to restore session %s: %v", agentID, err)
 				} else {
 					log.Printf("Successfully restored session %s", agentID)
@@ -563,14 +946,21 @@ func (uc *SessionUseCase) InitializeSessions(ctx context.Context) error {
 	return nil
 }
 
-func (uc *SessionUseCase) ReconnectSession(ctx context.Context, agentID string) (*entity.Session, error) {
+func (uc *SessionUseCase) ReconnectSession(ctx context.Context, caller Caller, agentID string) (*entity.Session, error) {
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+		return nil, err
+	}
+	return uc.reconnectSession(ctx, agentID)
+}
+
+func (uc *SessionUseCase) reconnectSession(ctx context.Context, agentID string) (*entity.Session, error) {
 	// 1. Get Session from DB
 	session, err := uc.sessionRepo.GetByAgentID(ctx, agentID)
 	if err != nil {
 		return nil, err
 	}
 	if session == nil {
-		return nil, fmt.Errorf("session not found")
+		return nil, ErrSessionNotFound
 	}
 
 	// 2. Resolve Client
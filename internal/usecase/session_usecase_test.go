@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMediaKeyName(t *testing.T) {
+	if got := mediaKeyName("3EB0C767D26A1D3B5C12"); got != "3EB0C767D26A1D3B5C12" {
+		t.Errorf("alphanumeric id changed to %q", got)
+	}
+	for _, id := range []string{"../../etc/passwd", "a/b", "id with space", "", strings.Repeat("A", 65)} {
+		got := mediaKeyName(id)
+		if len(got) != 64 || strings.Trim(got, "0123456789abcdef") != "" {
+			t.Errorf("mediaKeyName(%q) = %q, want a sha256 hex digest", id, got)
+		}
+	}
+	if mediaKeyName("a/b") == mediaKeyName("a_b") {
+		t.Error("different ids share a key")
+	}
+}
+
+func TestMediaExtension(t *testing.T) {
+	tests := []struct {
+		mimetype, fileName, want string
+	}{
+		{"application/pdf", "Invoice.PDF", ".pdf"},
+		{"image/jpeg", "", ".jpg"},
+		{"image/jpeg", "photo.j/../../x", ".jpg"},
+		{"application/pdf", "report.p df", ".pdf"},
+		{"application/x-unknown-type", "", ".bin"},
+	}
+	for _, tt := range tests {
+		if got := mediaExtension(tt.mimetype, tt.fileName); got != tt.want {
+			t.Errorf("mediaExtension(%q, %q) = %q, want %q", tt.mimetype, tt.fileName, got, tt.want)
+		}
+	}
+}
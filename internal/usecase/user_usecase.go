@@ -0,0 +1,358 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrUserExists     = errors.New("user already exists")
+	ErrInvalidUser    = errors.New("invalid user")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrInvalidScope   = errors.New("invalid scope")
+	// ErrAgentNotAllowed is returned when a key limited to some agents acts
+	// on another one.
+	ErrAgentNotAllowed = errors.New("API key is not allowed to access this agent")
+	// ErrAgentIDUnavailable is returned when creating a session with an
+	// agent ID in use, whether by the caller or another user.
+	ErrAgentIDUnavailable = errors.New("agentId is not available; choose another")
+)
+
+// API key scopes.
+const (
+	ScopeAll              = "*"
+	ScopeSessionsRead     = "sessions:read"
+	ScopeSessionsWrite    = "sessions:write"
+	ScopeMessagesRead     = "messages:read"
+	ScopeMessagesSend     = "messages:send"
+	ScopeLangchainExecute = "langchain:execute"
+	ScopeWebhooksManage   = "webhooks:manage"
+	ScopeAdmin            = "admin"
+)
+
+var apiKeyScopes = map[string]bool{
+	ScopeAll:              true,
+	ScopeSessionsRead:     true,
+	ScopeSessionsWrite:    true,
+	ScopeMessagesRead:     true,
+	ScopeMessagesSend:     true,
+	ScopeLangchainExecute: true,
+	ScopeWebhooksManage:   true,
+	ScopeAdmin:            true,
+}
+
+// Caller is the authenticated identity use cases act for: the key's user,
+// what the key may do and which agents it may touch.
+type Caller struct {
+	UserID  string
+	IsAdmin bool
+	KeyID   int
+	Scopes  []string
+	// AgentIDs limits the key to these agents; empty means all of the user's.
+	AgentIDs []string
+}
+
+func (c Caller) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope || (s == ScopeAll && (scope != ScopeAdmin || c.IsAdmin)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Caller) CanAccessAgent(agentID string) bool {
+	if len(c.AgentIDs) == 0 {
+		return true
+	}
+	for _, id := range c.AgentIDs {
+		if id == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueKeyInput describes a new API key. Empty Scopes grants every scope;
+// empty AgentIDs allows every agent of the user.
+type IssueKeyInput struct {
+	UserID   string
+	Name     string
+	Scopes   []string
+	AgentIDs []string
+}
+
+const (
+	// Keys look like wa_<48 hex chars>; the first apiKeyPrefixLen characters
+	// are stored in clear to find and identify the key.
+	apiKeyScheme    = "wa_"
+	apiKeyPrefixLen = len(apiKeyScheme) + 8
+	// lastUsedResolution limits how often last_used_at is written per key.
+	lastUsedResolution = time.Minute
+)
+
+type UserUseCase struct {
+	userRepo   repository.UserRepository
+	apiKeyRepo repository.APIKeyRepository
+}
+
+func NewUserUseCase(userRepo repository.UserRepository, apiKeyRepo repository.APIKeyRepository) *UserUseCase {
+	return &UserUseCase{
+		userRepo:   userRepo,
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// Authenticate resolves a presented API key to its user and permissions.
+func (uc *UserUseCase) Authenticate(ctx context.Context, rawKey string) (*entity.User, *Caller, error) {
+	if rawKey == "" {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	candidates, err := uc.apiKeyRepo.GetActiveByPrefix(ctx, keyPrefix(rawKey))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, key := range candidates {
+		if subtle.ConstantTimeCompare([]byte(hashAPIKey(key.Salt, rawKey)), []byte(key.KeyHash)) != 1 {
+			continue
+		}
+		user, err := uc.userRepo.GetByID(ctx, key.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if user == nil {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		now := time.Now()
+		if !key.LastUsedAt.Valid || now.Sub(key.LastUsedAt.Time) > lastUsedResolution {
+			if err := uc.apiKeyRepo.UpdateLastUsed(ctx, key.ID, now); err != nil {
+				log.Printf("failed to record API key use for key %d: %v", key.ID, err)
+			}
+		}
+		caller := &Caller{
+			UserID:  user.UserID,
+			IsAdmin: user.IsAdmin,
+			KeyID:   key.ID,
+		}
+		if err := json.Unmarshal(key.Scopes, &caller.Scopes); err != nil {
+			return nil, nil, fmt.Errorf("API key %d has malformed scopes: %w", key.ID, err)
+		}
+		if err := json.Unmarshal(key.AgentIDs, &caller.AgentIDs); err != nil {
+			return nil, nil, fmt.Errorf("API key %d has malformed agent list: %w", key.ID, err)
+		}
+		return user, caller, nil
+	}
+	return nil, nil, ErrInvalidAPIKey
+}
+
+func (uc *UserUseCase) CreateUser(ctx context.Context, userID string, isAdmin bool) (*entity.User, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" || len(userID) > 255 {
+		return nil, fmt.Errorf("%w: userId must be 1-255 characters", ErrInvalidUser)
+	}
+	existing, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrUserExists
+	}
+
+	user := &entity.User{
+		UserID:    userID,
+		IsAdmin:   isAdmin,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (uc *UserUseCase) ListUsers(ctx context.Context) ([]*entity.User, error) {
+	return uc.userRepo.GetAll(ctx)
+}
+
+// IssueKey creates a named API key for a user. The plaintext key is only
+// returned here; just its salted hash is stored.
+func (uc *UserUseCase) IssueKey(ctx context.Context, in IssueKeyInput) (*entity.APIKey, string, error) {
+	user, err := uc.userRepo.GetByID(ctx, in.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrUserNotFound
+	}
+
+	scopes := in.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{ScopeAll}
+	}
+	for _, scope := range scopes {
+		if !apiKeyScopes[scope] {
+			return nil, "", fmt.Errorf("%w: unknown scope %q", ErrInvalidScope, scope)
+		}
+		if scope == ScopeAdmin && !user.IsAdmin {
+			return nil, "", fmt.Errorf("%w: admin scope requires an admin user", ErrInvalidScope)
+		}
+	}
+	agentIDs := in.AgentIDs
+	if agentIDs == nil {
+		agentIDs = []string{}
+	}
+
+	key := &entity.APIKey{
+		UserID: in.UserID,
+		Name:   fallbackString(strings.TrimSpace(in.Name), "default"),
+	}
+	key.Scopes, _ = json.Marshal(scopes)
+	key.AgentIDs, _ = json.Marshal(agentIDs)
+	return uc.issueKey(ctx, key)
+}
+
+func (uc *UserUseCase) ListKeys(ctx context.Context, userID string) ([]*entity.APIKey, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return uc.apiKeyRepo.GetByUserID(ctx, userID)
+}
+
+// RotateKey replaces an active key with a new one of the same name and
+// revokes the old key.
+func (uc *UserUseCase) RotateKey(ctx context.Context, id int) (*entity.APIKey, string, error) {
+	old, err := uc.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if old == nil || old.RevokedAt.Valid {
+		return nil, "", ErrAPIKeyNotFound
+	}
+
+	key := &entity.APIKey{
+		UserID:   old.UserID,
+		Name:     old.Name,
+		Scopes:   old.Scopes,
+		AgentIDs: old.AgentIDs,
+	}
+	raw, err := generateKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+	// One transaction, so a failure never leaves both keys valid or neither.
+	err = uc.apiKeyRepo.Rotate(ctx, id, key, key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+func (uc *UserUseCase) RevokeKey(ctx context.Context, id int) error {
+	err := uc.apiKeyRepo.Revoke(ctx, id, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// Bootstrap makes sure the admin user exists and can log in. When it has no
+// active key a random one is generated and logged once.
+func (uc *UserUseCase) Bootstrap(ctx context.Context, adminID string) error {
+	admin, err := uc.userRepo.GetByID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if admin == nil {
+		if _, err := uc.CreateUser(ctx, adminID, true); err != nil {
+			return err
+		}
+	}
+
+	active, err := uc.apiKeyRepo.CountActiveByUserID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if active > 0 {
+		return nil
+	}
+
+	_, raw, err := uc.IssueKey(ctx, IssueKeyInput{UserID: adminID, Name: "bootstrap"})
+	if err != nil {
+		return err
+	}
+	log.Printf("Generated API key for %s (shown once, store it now): %s", adminID, raw)
+	return nil
+}
+
+// issueKey generates the secret for key, fills in its hash and stores it.
+func (uc *UserUseCase) issueKey(ctx context.Context, key *entity.APIKey) (*entity.APIKey, string, error) {
+	raw, err := generateKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := uc.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+// generateKey generates the secret for key and fills in its hash, without
+// storing it.
+func generateKey(key *entity.APIKey) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := apiKeyScheme + hex.EncodeToString(buf)
+
+	saltBuf := make([]byte, 16)
+	if _, err := rand.Read(saltBuf); err != nil {
+		return "", err
+	}
+	salt := hex.EncodeToString(saltBuf)
+
+	now := time.Now()
+	key.Prefix = keyPrefix(raw)
+	key.KeyHash = hashAPIKey(salt, raw)
+	key.Salt = salt
+	key.CreatedAt = now
+	key.UpdatedAt = now
+	return raw, nil
+}
+
+func keyPrefix(raw string) string {
+	if len(raw) < apiKeyPrefixLen {
+		return raw
+	}
+	return raw[:apiKeyPrefixLen]
+}
+
+// hashAPIKey must stay in sync with the legacy key migration in
+// 008_create_api_keys_table.up.sql.
+func hashAPIKey(salt, raw string) string {
+	sum := sha256.Sum256([]byte(salt + raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,418 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"whatsapp-api/internal/domain/entity"
+	"whatsapp-api/internal/domain/repository"
+	"whatsapp-api/internal/infrastructure/webhook"
+)
+
+// Events that can be delivered to webhooks.
+const (
+	EventMessageReceived     = "message.received"
+	EventMessageSent         = "message.sent"
+	EventMessageFailed       = "message.failed"
+	EventMessageStatus       = "message.status"
+	EventReceipt             = "receipt"
+	EventPresence            = "presence"
+	EventSessionStatus       = "session.status"
+	EventSessionQR           = "session.qr"
+	EventSessionConnected    = "session.connected"
+	EventSessionDisconnected = "session.disconnected"
+	EventSessionPaired       = "session.paired"
+	EventSessionLoggedOut    = "session.logged_out"
+	EventCampaignCompleted   = "campaign.completed"
+	EventCampaignStopped     = "campaign.stopped"
+)
+
+var webhookEvents = map[string]bool{
+	EventMessageReceived:     true,
+	EventMessageSent:         true,
+	EventMessageFailed:       true,
+	EventMessageStatus:       true,
+	EventReceipt:             true,
+	EventPresence:            true,
+	EventSessionStatus:       true,
+	EventSessionQR:           true,
+	EventSessionConnected:    true,
+	EventSessionDisconnected: true,
+	EventSessionPaired:       true,
+	EventSessionLoggedOut:    true,
+	EventCampaignCompleted:   true,
+	EventCampaignStopped:     true,
+}
+
+// EventScope is the API key scope a realtime subscriber needs to receive an
+// event: sessions:read for session state, messages:read for everything
+// carrying message content or chat activity.
+func EventScope(event string) string {
+	if strings.HasPrefix(event, "session.") {
+		return ScopeSessionsRead
+	}
+	return ScopeMessagesRead
+}
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+)
+
+const (
+	webhookRetryInterval = 5 * time.Second
+	webhookRetryBatch    = 50
+	webhookMaxBackoff    = time.Hour
+	// webhookDeliveryLease is how long an attempt holds a delivery before
+	// the retry worker may take it over.
+	webhookDeliveryLease = time.Minute
+	// webhookMaxStoredBody caps the receiver response kept per delivery.
+	webhookMaxStoredBody = 2048
+)
+
+type WebhookUseCase struct {
+	sessionRepo repository.SessionRepository
+	webhookRepo repository.WebhookRepository
+	client      *webhook.Client
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+func NewWebhookUseCase(
+	sessionRepo repository.SessionRepository,
+	webhookRepo repository.WebhookRepository,
+	client *webhook.Client,
+	maxAttempts int,
+	baseDelay time.Duration,
+) *WebhookUseCase {
+	return &WebhookUseCase{
+		sessionRepo: sessionRepo,
+		webhookRepo: webhookRepo,
+		client:      client,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+	}
+}
+
+type RegisterWebhookInput struct {
+	Caller  Caller
+	AgentID string
+	URL     string
+	Secret  string
+	// Events filters what is delivered; empty means every event.
+	Events []string
+}
+
+func (uc *WebhookUseCase) Register(ctx context.Context, in RegisterWebhookInput) (*entity.Webhook, error) {
+	session, err := ownedSession(ctx, uc.sessionRepo, in.Caller, in.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := url.Parse(in.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	for _, event := range in.Events {
+		if event != "*" && !webhookEvents[event] {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+
+	secret := in.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	events := in.Events
+	if events == nil {
+		events = []string{}
+	}
+	eventsJSON, _ := json.Marshal(events)
+
+	wh := &entity.Webhook{
+		SessionID: session.ID,
+		AgentID:   in.AgentID,
+		URL:       in.URL,
+		Secret:    secret,
+		Events:    eventsJSON,
+		Active:    true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := uc.webhookRepo.Create(ctx, wh); err != nil {
+		return nil, err
+	}
+	return wh, nil
+}
+
+func (uc *WebhookUseCase) List(ctx context.Context, caller Caller, agentID string) ([]*entity.Webhook, error) {
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, agentID); err != nil {
+		return nil, err
+	}
+	return uc.webhookRepo.GetByAgentID(ctx, agentID)
+}
+
+func (uc *WebhookUseCase) Delete(ctx context.Context, caller Caller, id int) error {
+	if _, err := uc.ownedWebhook(ctx, caller, id); err != nil {
+		return err
+	}
+	err := uc.webhookRepo.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+func (uc *WebhookUseCase) ListDeliveries(ctx context.Context, caller Caller, webhookID, limit, offset int) ([]*entity.WebhookDelivery, error) {
+	if _, err := uc.ownedWebhook(ctx, caller, webhookID); err != nil {
+		return nil, err
+	}
+	return uc.webhookRepo.GetDeliveriesByWebhookID(ctx, webhookID, limit, offset)
+}
+
+// Replay re-sends the payload of a past delivery as a new delivery and
+// returns it after the first attempt.
+func (uc *WebhookUseCase) Replay(ctx context.Context, caller Caller, deliveryID int) (*entity.WebhookDelivery, error) {
+	original, err := uc.webhookRepo.GetDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, ErrWebhookNotFound
+	}
+	wh, err := uc.ownedWebhook(ctx, caller, original.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leased like a dispatched delivery, so the retry worker picks it up if
+	// the inline attempt never records its outcome.
+	now := time.Now()
+	delivery := &entity.WebhookDelivery{
+		WebhookID:     wh.ID,
+		AgentID:       wh.AgentID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        "pending",
+		NextAttemptAt: sql.NullTime{Time: now.Add(webhookDeliveryLease), Valid: true},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := uc.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	uc.attempt(ctx, wh, delivery)
+	return delivery, nil
+}
+
+// ownedWebhook loads a webhook if it belongs to one of the user's sessions.
+func (uc *WebhookUseCase) ownedWebhook(ctx context.Context, caller Caller, id int) (*entity.Webhook, error) {
+	wh, err := uc.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if wh == nil {
+		return nil, ErrWebhookNotFound
+	}
+	if _, err := ownedSession(ctx, uc.sessionRepo, caller, wh.AgentID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return wh, nil
+}
+
+// Dispatch fans an event out to every active webhook of the agent that
+// subscribes to it. Deliveries are recorded and sent in the background.
+func (uc *WebhookUseCase) Dispatch(agentID, event string, data interface{}) {
+	go func() {
+		ctx := context.Background()
+		webhooks, err := uc.webhookRepo.GetByAgentID(ctx, agentID)
+		if err != nil {
+			log.Printf("webhook dispatch: failed to load webhooks for agent %s: %v", agentID, err)
+			return
+		}
+		if len(webhooks) == 0 {
+			return
+		}
+
+		payload, err := json.Marshal(map[string]interface{}{
+			"event":     event,
+			"agentId":   agentID,
+			"timestamp": time.Now().UTC(),
+			"data":      data,
+		})
+		if err != nil {
+			log.Printf("webhook dispatch: failed to encode %s for agent %s: %v", event, agentID, err)
+			return
+		}
+
+		for _, wh := range webhooks {
+			if !wh.Active || !subscribes(wh, event) {
+				continue
+			}
+			// The delivery is due for the retry worker as soon as the
+			// inline attempt's lease lapses, so it survives a crash before
+			// or during that attempt.
+			now := time.Now()
+			delivery := &entity.WebhookDelivery{
+				WebhookID:     wh.ID,
+				AgentID:       agentID,
+				Event:         event,
+				Payload:       payload,
+				Status:        "pending",
+				NextAttemptAt: sql.NullTime{Time: now.Add(webhookDeliveryLease), Valid: true},
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			if err := uc.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+				log.Printf("webhook dispatch: failed to record delivery for webhook %d: %v", wh.ID, err)
+				continue
+			}
+			uc.attempt(ctx, wh, delivery)
+		}
+	}()
+}
+
+// StartRetryWorker periodically re-attempts failed deliveries whose backoff
+// has elapsed. It blocks until ctx is cancelled.
+func (uc *WebhookUseCase) StartRetryWorker(ctx context.Context) {
+	ticker := time.NewTicker(webhookRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deliveries, err := uc.webhookRepo.ClaimDueDeliveries(ctx, webhookRetryBatch, webhookDeliveryLease)
+		if err != nil {
+			log.Printf("webhook retry: failed to load due deliveries: %v", err)
+			continue
+		}
+		for _, delivery := range deliveries {
+			wh, err := uc.webhookRepo.GetByID(ctx, delivery.WebhookID)
+			if err != nil || wh == nil || !wh.Active {
+				continue
+			}
+			uc.attempt(ctx, wh, delivery)
+		}
+	}
+}
+
+// attempt performs one delivery attempt and records the outcome, scheduling
+// the next retry with exponential backoff until maxAttempts is reached.
+func (uc *WebhookUseCase) attempt(ctx context.Context, wh *entity.Webhook, delivery *entity.WebhookDelivery) {
+	result, err := uc.client.Deliver(ctx, wh.URL, wh.Secret, delivery.Event, delivery.ID, delivery.Payload)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.UpdatedAt = now
+	delivery.ErrorMessage = sql.NullString{}
+	if result != nil {
+		delivery.ResponseStatus = sql.NullInt64{Int64: int64(result.StatusCode), Valid: true}
+		body := storedResponseBody(result.Body)
+		delivery.ResponseBody = sql.NullString{String: body, Valid: body != ""}
+	}
+
+	switch {
+	case err == nil && result.StatusCode < 300:
+		delivery.Status = "success"
+		delivery.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+		delivery.NextAttemptAt = sql.NullTime{}
+	default:
+		if err != nil {
+			delivery.ErrorMessage = sql.NullString{String: err.Error(), Valid: true}
+		} else {
+			delivery.ErrorMessage = sql.NullString{String: fmt.Sprintf("receiver returned status %d", result.StatusCode), Valid: true}
+		}
+		if delivery.Attempts >= uc.maxAttempts {
+			delivery.Status = "failed"
+			delivery.NextAttemptAt = sql.NullTime{}
+		} else {
+			delivery.Status = "pending"
+			delivery.NextAttemptAt = sql.NullTime{Time: now.Add(uc.backoff(delivery.Attempts)), Valid: true}
+		}
+	}
+
+	if err := uc.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("webhook: failed to update delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func (uc *WebhookUseCase) backoff(attempts int) time.Duration {
+	delay := uc.baseDelay << (attempts - 1)
+	if delay <= 0 || delay > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return delay
+}
+
+func subscribes(wh *entity.Webhook, event string) bool {
+	var events []string
+	if err := json.Unmarshal(wh.Events, &events); err != nil || len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == event || e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// messageEventData is the payload shape for message.* events.
+func messageEventData(msg *entity.Message) map[string]interface{} {
+	data := map[string]interface{}{
+		"id":        msg.ID,
+		"messageId": msg.MessageID.String,
+		"from":      msg.FromNumber.String,
+		"to":        msg.ToNumber.String,
+		"text":      msg.MessageText.String,
+		"type":      msg.MessageType.String,
+		"direction": msg.Direction.String,
+		"status":    msg.Status.String,
+		"timestamp": msg.CreatedAt,
+	}
+	if msg.DeliveredAt.Valid {
+		data["deliveredAt"] = msg.DeliveredAt.Time
+	}
+	if msg.ReadAt.Valid {
+		data["readAt"] = msg.ReadAt.Time
+	}
+	if msg.PlayedAt.Valid {
+		data["playedAt"] = msg.PlayedAt.Time
+	}
+	if len(msg.Metadata) > 0 {
+		data["metadata"] = json.RawMessage(msg.Metadata)
+	}
+	return data
+}
+
+// storedResponseBody makes a receiver's response storable in a text column:
+// cut to webhookMaxStoredBody bytes, without NUL bytes or invalid UTF-8,
+// which Postgres rejects.
+func storedResponseBody(body []byte) string {
+	if len(body) > webhookMaxStoredBody {
+		body = body[:webhookMaxStoredBody]
+	}
+	s := strings.ToValidUTF8(string(body), "\uFFFD")
+	return strings.ReplaceAll(s, "\x00", "")
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestStoredResponseBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"plain", []byte(`{"ok":true}`), `{"ok":true}`},
+		{"nul bytes", []byte("a\x00b\x00"), "ab"},
+		{"invalid utf-8", []byte{'o', 'k', 0xff, 0xfe}, "ok�"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := storedResponseBody(tt.body); got != tt.want {
+			t.Errorf("%s: storedResponseBody = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Truncation can split a multi-byte rune; the result stays valid.
+	long := []byte("x" + strings.Repeat("é", webhookMaxStoredBody))
+	got := storedResponseBody(long)
+	if !utf8.ValidString(got) {
+		t.Error("truncated body is not valid UTF-8")
+	}
+	if len(got) > webhookMaxStoredBody+len("�") {
+		t.Errorf("truncated body is %d bytes", len(got))
+	}
+}
+
+func TestEventScope(t *testing.T) {
+	tests := map[string]string{
+		EventSessionStatus:     ScopeSessionsRead,
+		EventSessionQR:         ScopeSessionsRead,
+		EventSessionLoggedOut:  ScopeSessionsRead,
+		EventMessageReceived:   ScopeMessagesRead,
+		EventMessageStatus:     ScopeMessagesRead,
+		EventReceipt:           ScopeMessagesRead,
+		EventPresence:          ScopeMessagesRead,
+		EventCampaignCompleted: ScopeMessagesRead,
+		"unknown":              ScopeMessagesRead,
+	}
+	for event, want := range tests {
+		if got := EventScope(event); got != want {
+			t.Errorf("EventScope(%q) = %q, want %q", event, got, want)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+DROP COLUMN IF EXISTS langchain_execution_id,
+DROP COLUMN IF EXISTS reply_to_id;
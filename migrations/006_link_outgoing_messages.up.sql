@@ -0,0 +1,6 @@
+ALTER TABLE messages
+ADD COLUMN IF NOT EXISTS reply_to_id INTEGER REFERENCES messages(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS langchain_execution_id INTEGER REFERENCES langchain_executions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_messages_reply_to ON messages(reply_to_id);
+CREATE INDEX IF NOT EXISTS idx_messages_langchain_execution ON messages(langchain_execution_id);
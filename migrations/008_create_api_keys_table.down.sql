@@ -0,0 +1,6 @@
+-- Hashed keys cannot be turned back into plaintext; users need new keys after a rollback.
+DROP TABLE IF EXISTS api_keys;
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS api_key VARCHAR(255) UNIQUE,
+DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,33 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users SET is_admin = TRUE WHERE user_id = 'admin';
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL, -- leading characters of the key, shown to identify it
+    key_hash VARCHAR(64) NOT NULL, -- hex sha256(salt || key)
+    salt VARCHAR(64) NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys(prefix) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
+
+-- Carry existing plaintext keys over as hashed keys. The old seeded literal
+-- "secret" is dropped; a random admin key is generated on next start instead.
+INSERT INTO api_keys (user_id, name, prefix, key_hash, salt, created_at, updated_at)
+SELECT u.user_id, 'legacy', LEFT(u.api_key, 11),
+       encode(sha256(convert_to(s.salt || u.api_key, 'UTF8')), 'hex'), s.salt,
+       CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM users u
+CROSS JOIN LATERAL (SELECT md5(random()::text || u.user_id) AS salt) s
+WHERE u.api_key IS NOT NULL AND u.api_key <> 'secret';
+
+DROP INDEX IF EXISTS idx_users_api_key;
+ALTER TABLE users DROP COLUMN IF EXISTS api_key;
@@ -0,0 +1,3 @@
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS scopes JSONB NOT NULL DEFAULT '["*"]', -- existing keys keep full access
+ADD COLUMN IF NOT EXISTS agent_ids JSONB NOT NULL DEFAULT '[]'; -- empty array = all of the user's agents
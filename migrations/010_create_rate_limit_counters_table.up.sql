@@ -0,0 +1,11 @@
+-- Fixed-window hit counters for the postgres rate limit store
+-- (security.rate_limit_store: postgres).
+CREATE TABLE IF NOT EXISTS rate_limit_counters (
+    key VARCHAR(255) NOT NULL, -- API key or client IP, plus the route override
+    window_start TIMESTAMP NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (key, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_expires_at ON rate_limit_counters(expires_at);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS outbound_queue (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    priority SMALLINT NOT NULL DEFAULT 1, -- 0 = bot replies, 1 = API sends, 2 = bulk
+    recipient VARCHAR(255) NOT NULL,
+    payload BYTEA NOT NULL, -- protobuf-encoded message
+    message_text TEXT,
+    message_type VARCHAR(50) NOT NULL DEFAULT 'text',
+    metadata JSONB,
+    reply_to_id INTEGER REFERENCES messages(id) ON DELETE SET NULL,
+    langchain_execution_id INTEGER REFERENCES langchain_executions(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued', -- queued, sent, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    message_row_id INTEGER REFERENCES messages(id) ON DELETE SET NULL,
+    send_after TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- also leases items being sent
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbound_queue_due ON outbound_queue(agent_id, priority, id) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_outbound_queue_agent ON outbound_queue(agent_id, created_at DESC);
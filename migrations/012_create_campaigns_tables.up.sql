@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    template TEXT NOT NULL, -- message body with {{variable}} placeholders
+    status VARCHAR(20) NOT NULL DEFAULT 'draft', -- draft, running, paused, stopped, completed, cancelled
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    stop_reason TEXT,
+    next_send_at TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_agent ON campaigns(agent_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_campaigns_running ON campaigns(next_send_at) WHERE status = 'running';
+
+CREATE TABLE IF NOT EXISTS campaign_recipients (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    phone VARCHAR(255) NOT NULL, -- phone number or JID
+    name VARCHAR(255),
+    variables JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, queued, sent, delivered, read, failed
+    outbound_id INTEGER REFERENCES outbound_queue(id) ON DELETE SET NULL,
+    message_id VARCHAR(255),
+    error_message TEXT,
+    sent_at TIMESTAMP,
+    delivered_at TIMESTAMP,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (campaign_id, phone)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_recipients_status ON campaign_recipients(campaign_id, status, id);
+CREATE INDEX IF NOT EXISTS idx_campaign_recipients_outbound ON campaign_recipients(outbound_id);
+CREATE INDEX IF NOT EXISTS idx_campaign_recipients_message ON campaign_recipients(agent_id, message_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS scheduled_message_runs;
+DROP TABLE IF EXISTS scheduled_messages;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    recipient VARCHAR(255) NOT NULL, -- JID
+    message_text TEXT NOT NULL,
+    cron_expr VARCHAR(255), -- NULL for one-off schedules
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, paused, completed
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    run_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_due ON scheduled_messages(next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_agent ON scheduled_messages(agent_id, created_at DESC);
+
+-- One row per time a schedule fired, linked to the queued item and, once
+-- sent, to the stored messages row.
+CREATE TABLE IF NOT EXISTS scheduled_message_runs (
+    id SERIAL PRIMARY KEY,
+    schedule_id INTEGER NOT NULL REFERENCES scheduled_messages(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, queued, sent, failed
+    outbound_id INTEGER REFERENCES outbound_queue(id) ON DELETE SET NULL,
+    message_row_id INTEGER REFERENCES messages(id) ON DELETE SET NULL,
+    error_message TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_message_runs_schedule ON scheduled_message_runs(schedule_id, id DESC);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_messages_message_id;
+
+ALTER TABLE messages
+DROP COLUMN IF EXISTS played_at,
+DROP COLUMN IF EXISTS read_at,
+DROP COLUMN IF EXISTS delivered_at;
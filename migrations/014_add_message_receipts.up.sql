@@ -0,0 +1,7 @@
+ALTER TABLE messages
+ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS read_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS played_at TIMESTAMP;
+
+-- Receipts and quotes look messages up by their WhatsApp ID.
+CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages(agent_id, message_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_messages_session_chat;
+DROP INDEX IF EXISTS idx_messages_session_created;
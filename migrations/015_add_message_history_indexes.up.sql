@@ -0,0 +1,3 @@
+-- Keyset pagination of a session's history and per-chat listings.
+CREATE INDEX IF NOT EXISTS idx_messages_session_created ON messages(session_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_messages_session_chat ON messages(session_id, (metadata->>'chat'), created_at DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_langchain_message;
+DROP INDEX IF EXISTS idx_langchain_session_created;
+
+ALTER TABLE langchain_executions
+DROP COLUMN IF EXISTS replay_of,
+DROP COLUMN IF EXISTS message_id,
+DROP COLUMN IF EXISTS sender;
@@ -0,0 +1,14 @@
+ALTER TABLE langchain_executions
+ADD COLUMN IF NOT EXISTS sender VARCHAR(255),
+ADD COLUMN IF NOT EXISTS message_id INTEGER REFERENCES messages(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS replay_of INTEGER REFERENCES langchain_executions(id) ON DELETE SET NULL;
+
+-- Link existing executions to the incoming message their reply answered.
+UPDATE langchain_executions e
+SET message_id = m.reply_to_id, sender = incoming.from_number
+FROM messages m
+JOIN messages incoming ON incoming.id = m.reply_to_id
+WHERE m.langchain_execution_id = e.id AND e.message_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_langchain_session_created ON langchain_executions(session_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_langchain_message ON langchain_executions(message_id);
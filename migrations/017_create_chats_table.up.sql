@@ -0,0 +1,35 @@
+-- One row per conversation of a session, kept up to date as messages are
+-- received and sent.
+CREATE TABLE IF NOT EXISTS chats (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    chat_jid VARCHAR(255) NOT NULL,
+    chat_type VARCHAR(20) NOT NULL DEFAULT 'direct', -- direct, group
+    display_name VARCHAR(255),
+    last_message_id INTEGER REFERENCES messages(id) ON DELETE SET NULL,
+    last_message_text TEXT,
+    last_message_type VARCHAR(50),
+    last_message_direction VARCHAR(20),
+    last_message_at TIMESTAMP,
+    unread_count INTEGER NOT NULL DEFAULT 0,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    muted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (session_id, chat_jid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chats_session_recent ON chats(session_id, last_message_at DESC NULLS LAST, id DESC);
+
+-- Build threads for the history stored so far.
+INSERT INTO chats (session_id, agent_id, chat_jid, chat_type, last_message_id, last_message_text, last_message_type,
+                   last_message_direction, last_message_at, created_at, updated_at)
+SELECT DISTINCT ON (session_id, metadata->>'chat')
+       session_id, agent_id, metadata->>'chat',
+       CASE WHEN metadata->>'chat' LIKE '%@g.us' THEN 'group' ELSE 'direct' END,
+       id, message_text, message_type, direction, created_at, created_at, created_at
+FROM messages
+WHERE metadata->>'chat' IS NOT NULL AND metadata->>'chat' <> ''
+ORDER BY session_id, metadata->>'chat', created_at DESC, id DESC
+ON CONFLICT (session_id, chat_jid) DO NOTHING;
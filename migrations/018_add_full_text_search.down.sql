@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS trg_langchain_execution_search ON langchain_executions;
+DROP TRIGGER IF EXISTS trg_message_search ON messages;
+DROP FUNCTION IF EXISTS index_langchain_execution_search();
+DROP FUNCTION IF EXISTS index_message_search();
+
+DROP TABLE IF EXISTS langchain_execution_search;
+DROP TABLE IF EXISTS message_search;
+
+DROP FUNCTION IF EXISTS search_language();
+DROP TABLE IF EXISTS search_settings;
@@ -0,0 +1,63 @@
+-- Text search configuration used for the search vectors below. The API
+-- sets it from search.language at startup and re-indexes when it changes.
+CREATE TABLE IF NOT EXISTS search_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    language REGCONFIG NOT NULL DEFAULT 'simple'
+);
+
+INSERT INTO search_settings (id, language) VALUES (TRUE, 'simple') ON CONFLICT (id) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION search_language() RETURNS REGCONFIG AS $$
+    SELECT COALESCE((SELECT language FROM search_settings WHERE id), 'simple'::regconfig);
+$$ LANGUAGE SQL STABLE;
+
+-- Search vectors live beside the rows they index so SELECT * on the
+-- indexed tables is unchanged.
+CREATE TABLE IF NOT EXISTS message_search (
+    message_id INTEGER PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    search_vector TSVECTOR NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS langchain_execution_search (
+    execution_id INTEGER PRIMARY KEY REFERENCES langchain_executions(id) ON DELETE CASCADE,
+    search_vector TSVECTOR NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION index_message_search() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO message_search (message_id, search_vector)
+    VALUES (NEW.id, to_tsvector(search_language(), COALESCE(NEW.message_text, '')))
+    ON CONFLICT (message_id) DO UPDATE SET search_vector = EXCLUDED.search_vector;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION index_langchain_execution_search() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO langchain_execution_search (execution_id, search_vector)
+    VALUES (NEW.id, to_tsvector(search_language(), COALESCE(NEW.user_message, '')))
+    ON CONFLICT (execution_id) DO UPDATE SET search_vector = EXCLUDED.search_vector;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_message_search ON messages;
+CREATE TRIGGER trg_message_search
+AFTER INSERT OR UPDATE OF message_text ON messages
+FOR EACH ROW EXECUTE FUNCTION index_message_search();
+
+DROP TRIGGER IF EXISTS trg_langchain_execution_search ON langchain_executions;
+CREATE TRIGGER trg_langchain_execution_search
+AFTER INSERT OR UPDATE OF user_message ON langchain_executions
+FOR EACH ROW EXECUTE FUNCTION index_langchain_execution_search();
+
+INSERT INTO message_search (message_id, search_vector)
+SELECT id, to_tsvector(search_language(), COALESCE(message_text, '')) FROM messages
+ON CONFLICT (message_id) DO NOTHING;
+
+INSERT INTO langchain_execution_search (execution_id, search_vector)
+SELECT id, to_tsvector(search_language(), COALESCE(user_message, '')) FROM langchain_executions
+ON CONFLICT (execution_id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_message_search ON message_search USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_langchain_execution_search ON langchain_execution_search USING GIN (search_vector);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_messages_to_number;
+DROP INDEX IF EXISTS idx_messages_from_number;
+
+DROP TABLE IF EXISTS retention_policies;
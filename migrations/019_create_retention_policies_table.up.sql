@@ -0,0 +1,19 @@
+-- A user's default retention (session_id NULL) and per-session overrides.
+-- Day counts: NULL inherits the user's default, 0 keeps data forever.
+CREATE TABLE IF NOT EXISTS retention_policies (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE,
+    message_days INTEGER CHECK (message_days >= 0),
+    execution_days INTEGER CHECK (execution_days >= 0),
+    media_days INTEGER CHECK (media_days >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_policies_scope ON retention_policies(user_id, (COALESCE(session_id, 0)));
+CREATE INDEX IF NOT EXISTS idx_retention_policies_session ON retention_policies(session_id);
+
+-- Contact erasure looks messages up by number.
+CREATE INDEX IF NOT EXISTS idx_messages_from_number ON messages(from_number);
+CREATE INDEX IF NOT EXISTS idx_messages_to_number ON messages(to_number);
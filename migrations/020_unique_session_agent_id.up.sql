@@ -0,0 +1,3 @@
+-- Agent IDs key the live WhatsApp clients, so they are unique across users,
+-- not just per user. Resolve any duplicate agent_id rows before applying.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_agent_id_unique ON sessions(agent_id);
@@ -0,0 +1,2 @@
+-- Fails while sealed secrets longer than 255 characters are stored.
+ALTER TABLE webhooks ALTER COLUMN secret TYPE VARCHAR(255);
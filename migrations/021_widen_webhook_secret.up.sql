@@ -0,0 +1,3 @@
+-- Webhook secrets are sealed at rest like Langchain API keys, which makes
+-- them longer than the plaintext.
+ALTER TABLE webhooks ALTER COLUMN secret TYPE TEXT;
@@ -0,0 +1,6 @@
+-- When each session may send its next queued message. Kept in the database
+-- so the pace holds across restarts and between instances.
+CREATE TABLE IF NOT EXISTS outbound_pacing (
+    agent_id VARCHAR(255) PRIMARY KEY,
+    next_send_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_scheduled_message_runs_pending;
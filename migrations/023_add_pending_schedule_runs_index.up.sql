@@ -0,0 +1,3 @@
+-- The scheduler looks for runs left pending by a crash between firing and
+-- queueing them.
+CREATE INDEX IF NOT EXISTS idx_scheduled_message_runs_pending ON scheduled_message_runs(updated_at) WHERE status = 'pending';
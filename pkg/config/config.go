@@ -14,12 +14,19 @@ type Config struct {
 	Langchain LangchainConfig `mapstructure:"langchain"`
 	Security  SecurityConfig  `mapstructure:"security"`
 	Logging   LoggingConfig   `mapstructure:"logging"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Webhook   WebhookConfig   `mapstructure:"webhook"`
+	Outbound  OutboundConfig  `mapstructure:"outbound"`
+	Campaign  CampaignConfig  `mapstructure:"campaign"`
+	Search    SearchConfig    `mapstructure:"search"`
+	Retention RetentionConfig `mapstructure:"retention"`
 }
 
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Name string `mapstructure:"name"`
-	Env  string `mapstructure:"env"`
+	Port        int    `mapstructure:"port"`
+	Name        string `mapstructure:"name"`
+	Env         string `mapstructure:"env"`
+	BodyLimitMB int    `mapstructure:"body_limit_mb"`
 }
 
 type DatabaseConfig struct {
@@ -47,9 +54,81 @@ type LangchainConfig struct {
 }
 
 type SecurityConfig struct {
-	APIKeyHeader      string `mapstructure:"api_key_header"`
-	RateLimitRequests int    `mapstructure:"rate_limit_requests"`
-	RateLimitWindow   string `mapstructure:"rate_limit_window"`
+	APIKeyHeader      string           `mapstructure:"api_key_header"`
+	RateLimitRequests int              `mapstructure:"rate_limit_requests"` // 0 disables rate limiting
+	RateLimitWindow   string           `mapstructure:"rate_limit_window"`
+	RateLimitStore    string           `mapstructure:"rate_limit_store"` // memory or postgres
+	RateLimitRoutes   []RateLimitRoute `mapstructure:"rate_limit_routes"`
+	// Per client IP before the API key is checked; 0 disables it.
+	RateLimitIPRequests int `mapstructure:"rate_limit_ip_requests"`
+	// ProxyHeader carries the client IP set by the reverse proxy, trusted
+	// only from TrustedProxies (IPs or CIDRs).
+	ProxyHeader    string   `mapstructure:"proxy_header"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// Master key (base64, 32 bytes) sealing secrets at rest, and its ID.
+	EncryptionKeyID string `mapstructure:"encryption_key_id"`
+	EncryptionKey   string `mapstructure:"encryption_key"`
+	// Comma-separated id:key pairs of retired master keys, kept until
+	// rotate-secrets has re-encrypted everything with the active key.
+	EncryptionRetiredKeys string `mapstructure:"encryption_retired_keys"`
+}
+
+// RateLimitRoute overrides the default limit for requests whose path starts
+// with Path and, when set, whose method is Method.
+type RateLimitRoute struct {
+	Method   string `mapstructure:"method"`
+	Path     string `mapstructure:"path"`
+	Requests int    `mapstructure:"requests"`
+	Window   string `mapstructure:"window"`
+}
+
+type StorageConfig struct {
+	Driver    string   `mapstructure:"driver"` // local or s3
+	LocalPath string   `mapstructure:"local_path"`
+	S3        S3Config `mapstructure:"s3"`
+}
+
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+type WebhookConfig struct {
+	Timeout        string `mapstructure:"timeout"`
+	MaxAttempts    int    `mapstructure:"max_attempts"`
+	RetryBaseDelay string `mapstructure:"retry_base_delay"`
+}
+
+// OutboundConfig paces each session's outbound queue.
+type OutboundConfig struct {
+	MessagesPerMinute int    `mapstructure:"messages_per_minute"`
+	Jitter            string `mapstructure:"jitter"`
+	TypingPerChar     string `mapstructure:"typing_per_char"`
+	MaxTyping         string `mapstructure:"max_typing"`
+	MaxAttempts       int    `mapstructure:"max_attempts"`
+}
+
+// CampaignConfig paces broadcast campaigns.
+type CampaignConfig struct {
+	MinDelay               string `mapstructure:"min_delay"`
+	MaxDelay               string `mapstructure:"max_delay"`
+	MaxConsecutiveFailures int    `mapstructure:"max_consecutive_failures"`
+}
+
+// SearchConfig sets the Postgres text search configuration used for
+// full-text search, e.g. simple, english or indonesian.
+type SearchConfig struct {
+	Language string `mapstructure:"language"`
+}
+
+// RetentionConfig paces the purge worker; what is kept is set per user and
+// session through the API.
+type RetentionConfig struct {
+	Interval  string `mapstructure:"interval"`
+	BatchSize int    `mapstructure:"batch_size"`
 }
 
 type LoggingConfig struct {
@@ -85,6 +164,7 @@ func bindEnvs() {
 		"server.port",
 		"server.name",
 		"server.env",
+		"server.body_limit_mb",
 		"database.url",
 		"database.host",
 		"database.port",
@@ -103,8 +183,35 @@ func bindEnvs() {
 		"security.api_key_header",
 		"security.rate_limit_requests",
 		"security.rate_limit_window",
+		"security.rate_limit_store",
+		"security.rate_limit_ip_requests",
+		"security.proxy_header",
+		"security.encryption_key_id",
+		"security.encryption_key",
+		"security.encryption_retired_keys",
 		"logging.level",
 		"logging.format",
+		"storage.driver",
+		"storage.local_path",
+		"storage.s3.endpoint",
+		"storage.s3.region",
+		"storage.s3.bucket",
+		"storage.s3.access_key",
+		"storage.s3.secret_key",
+		"webhook.timeout",
+		"webhook.max_attempts",
+		"webhook.retry_base_delay",
+		"outbound.messages_per_minute",
+		"outbound.jitter",
+		"outbound.typing_per_char",
+		"outbound.max_typing",
+		"outbound.max_attempts",
+		"campaign.min_delay",
+		"campaign.max_delay",
+		"campaign.max_consecutive_failures",
+		"search.language",
+		"retention.interval",
+		"retention.batch_size",
 	}
 
 	for _, key := range keys {
@@ -0,0 +1,223 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRejects(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"* * * foo *",
+		"* * * * funday",
+		"@every 5m",
+	}
+	for _, expr := range tests {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		expr   string
+		minute []int
+		hour   []int
+		dom    []int
+		month  []int
+		dow    []int
+	}{
+		{expr: "0 0 1 1 0", minute: []int{0}, hour: []int{0}, dom: []int{1}, month: []int{1}, dow: []int{0}},
+		{expr: "59 23 31 12 6", minute: []int{59}, hour: []int{23}, dom: []int{31}, month: []int{12}, dow: []int{6}},
+		{expr: "*/15 * * * *", minute: []int{0, 15, 30, 45}},
+		{expr: "5/20 * * * *", minute: []int{5, 25, 45}},
+		{expr: "10-20/5 * * * *", minute: []int{10, 15, 20}},
+		{expr: "1,2,30-31 * * * *", minute: []int{1, 2, 30, 31}},
+		{expr: "0 9-17/4 * * *", minute: []int{0}, hour: []int{9, 13, 17}},
+		{expr: "0 0 * jan-mar,DEC *", minute: []int{0}, hour: []int{0}, month: []int{1, 2, 3, 12}},
+		{expr: "0 0 * * mon-fri", minute: []int{0}, hour: []int{0}, dow: []int{1, 2, 3, 4, 5}},
+		// 7 is another Sunday.
+		{expr: "0 0 * * 7", minute: []int{0}, hour: []int{0}, dow: []int{0, 7}},
+		{expr: "0 0 * * 5-7", minute: []int{0}, hour: []int{0}, dow: []int{0, 5, 6, 7}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		check := func(name string, got uint64, want []int) {
+			if want == nil {
+				return
+			}
+			var bits uint64
+			for _, v := range want {
+				bits |= 1 << uint(v)
+			}
+			if got != bits {
+				t.Errorf("Parse(%q) %s = %b, want %b", tt.expr, name, got, bits)
+			}
+		}
+		check("minute", s.minute, tt.minute)
+		check("hour", s.hour, tt.hour)
+		check("day of month", s.dom, tt.dom)
+		check("month", s.month, tt.month)
+		check("day of week", s.dow, tt.dow)
+	}
+}
+
+func TestParseMacros(t *testing.T) {
+	tests := map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+		" @Daily ":  "0 0 * * *",
+	}
+	for macro, expr := range tests {
+		got, err := Parse(macro)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", macro, err)
+			continue
+		}
+		want, _ := Parse(expr)
+		if *got != *want {
+			t.Errorf("Parse(%q) = %+v, want %+v", macro, *got, *want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 2026-01-01 is a Thursday.
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"next minute", "* * * * *", utc(2026, 1, 1, 10, 0, 30), utc(2026, 1, 1, 10, 1, 0)},
+		{"strictly after", "0 10 * * *", utc(2026, 1, 1, 10, 0, 0), utc(2026, 1, 2, 10, 0, 0)},
+		{"step", "*/15 * * * *", utc(2026, 1, 1, 10, 16, 0), utc(2026, 1, 1, 10, 30, 0)},
+		{"hour rollover", "*/15 * * * *", utc(2026, 1, 1, 10, 50, 0), utc(2026, 1, 1, 11, 0, 0)},
+		{"year rollover", "0 0 1 1 *", utc(2026, 6, 1, 0, 0, 0), utc(2027, 1, 1, 0, 0, 0)},
+		{"weekdays from thursday", "0 9 * * mon-fri", utc(2026, 1, 1, 9, 0, 0), utc(2026, 1, 2, 9, 0, 0)},
+		{"weekdays skip weekend", "0 9 * * mon-fri", utc(2026, 1, 2, 9, 0, 0), utc(2026, 1, 5, 9, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", utc(2026, 1, 1, 0, 0, 0), utc(2026, 1, 4, 0, 0, 0)},
+		{"31st skips short months", "0 0 31 * *", utc(2026, 4, 1, 0, 0, 0), utc(2026, 5, 31, 0, 0, 0)},
+		{"leap day", "0 0 29 2 *", utc(2026, 1, 1, 0, 0, 0), utc(2028, 2, 29, 0, 0, 0)},
+		// With both day fields restricted a day matching either runs.
+		{"dom or dow by dow", "0 0 15 * fri", utc(2026, 1, 1, 0, 0, 0), utc(2026, 1, 2, 0, 0, 0)},
+		{"dom or dow by dom", "0 0 15 * fri", utc(2026, 1, 10, 0, 0, 0), utc(2026, 1, 15, 0, 0, 0)},
+		// With one restricted, only it counts.
+		{"dom only", "0 0 15 * *", utc(2026, 1, 1, 0, 0, 0), utc(2026, 1, 15, 0, 0, 0)},
+		{"dow only", "0 0 * * fri", utc(2026, 1, 3, 0, 0, 0), utc(2026, 1, 9, 0, 0, 0)},
+		{"dom with question mark dow", "0 0 15 * ?", utc(2026, 1, 1, 0, 0, 0), utc(2026, 1, 15, 0, 0, 0)},
+		{"never matches", "0 0 30 2 *", utc(2026, 1, 1, 0, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: Parse(%q): %v", tt.name, tt.expr, err)
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) = %s, want %s", tt.name, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	jakarta := mustLoad(t, "Asia/Jakarta")
+	s, _ := Parse("0 9 * * *")
+	got := s.Next(time.Date(2026, 1, 1, 10, 0, 0, 0, jakarta))
+	want := time.Date(2026, 1, 2, 9, 0, 0, 0, jakarta)
+	if !got.Equal(want) || got.Location() != jakarta {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestNextDaylightSaving(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	edt := time.FixedZone("EDT", -4*3600)
+	est := time.FixedZone("EST", -5*3600)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// 2026-03-08 02:00 EST jumps to 03:00 EDT.
+		{"skipped time does not run", "30 2 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, ny), time.Date(2026, 3, 9, 2, 30, 0, 0, edt)},
+		{"hourly across the gap", "0 * * * *", time.Date(2026, 3, 8, 1, 30, 0, 0, ny), time.Date(2026, 3, 8, 3, 0, 0, 0, edt)},
+		{"after the gap", "30 3 * * *", time.Date(2026, 3, 8, 1, 0, 0, 0, ny), time.Date(2026, 3, 8, 3, 30, 0, 0, edt)},
+		// 2026-11-01 02:00 EDT falls back to 01:00 EST.
+		{"repeated time runs first", "30 1 * * *", time.Date(2026, 10, 31, 12, 0, 0, 0, ny), time.Date(2026, 11, 1, 1, 30, 0, 0, edt)},
+		{"repeated time runs once", "30 1 * * *", time.Date(2026, 11, 1, 1, 30, 0, 0, edt), time.Date(2026, 11, 2, 1, 30, 0, 0, est)},
+		{"from inside the repeat", "*/20 * * * *", time.Date(2026, 11, 1, 1, 5, 0, 0, est), time.Date(2026, 11, 1, 2, 0, 0, 0, est)},
+		{"daily after the change", "0 9 * * *", time.Date(2026, 11, 1, 0, 0, 0, 0, ny), time.Date(2026, 11, 1, 9, 0, 0, 0, est)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: Parse(%q): %v", tt.name, tt.expr, err)
+		}
+		if got := s.Next(tt.from.In(ny)); !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) = %s, want %s", tt.name, tt.from.In(ny), got, tt.want.In(ny))
+		}
+	}
+}
+
+// Repeated calls must always move forward, including through both changes.
+func TestNextAlwaysAdvances(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	s, _ := Parse("*/20 * * * *")
+	for _, start := range []time.Time{
+		time.Date(2026, 3, 8, 0, 0, 0, 0, ny),
+		time.Date(2026, 11, 1, 0, 0, 0, 0, ny),
+	} {
+		seen := map[string]bool{}
+		at := start
+		for i := 0; i < 12; i++ {
+			next := s.Next(at)
+			if !next.After(at) {
+				t.Fatalf("Next(%s) = %s, not after it", at, next)
+			}
+			if wall := next.Format("15:04"); seen[wall] {
+				t.Fatalf("wall time %s ran twice from %s", wall, start)
+			} else {
+				seen[wall] = true
+			}
+			at = next
+		}
+	}
+}
+
+func utc(year int, month time.Month, day, hour, min, sec int) time.Time {
+	return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}